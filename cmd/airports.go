@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"aliagha/config"
+	"aliagha/database"
+	"aliagha/services"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var airportsConfigPath string
+var airportsFile string
+
+var airportsCmd = &cobra.Command{
+	Use:   "airports",
+	Short: "Manage airports",
+}
+
+var airportsSeedCmd = &cobra.Command{
+	Use:   "seed",
+	Short: "Import airports into the database",
+	Long: `This command imports airports and creates the cities they serve. Without
+--file the dataset bundled with the binary is used, which lists the commercial
+airports of Iran. A custom file must be a CSV with the columns iata, icao, name,
+city, latitude and longitude. Airports that already exist are updated by their
+IATA code, so the command can be run again safely.
+
+Usage:
+	aliagha airports seed --config [path] --file [path]`,
+	Run: func(cmd *cobra.Command, args []string) {
+		seedAirports()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(airportsCmd)
+	airportsCmd.AddCommand(airportsSeedCmd)
+	airportsSeedCmd.Flags().StringVarP(&airportsConfigPath, "config", "c", "", "Path to the YAML configuration file (required)")
+	airportsSeedCmd.Flags().StringVarP(&airportsFile, "file", "f", "", "path to a CSV file of airports, defaults to the bundled dataset")
+	if err := airportsSeedCmd.MarkFlagRequired("config"); err != nil {
+		panic(err)
+	}
+}
+
+func seedAirports() {
+	cfg, err := config.Init(config.Params{FilePath: airportsConfigPath, FileType: "yaml"})
+	if err != nil {
+		panic(err)
+	}
+
+	db, err := database.InitDB(&cfg.Database)
+	if err != nil {
+		panic(err)
+	}
+
+	dataset := services.BundledAirports()
+	if airportsFile != "" {
+		file, err := os.Open(airportsFile)
+		if err != nil {
+			panic(err)
+		}
+		defer file.Close()
+
+		dataset = file
+	}
+
+	result, err := services.SeedAirports(db, dataset)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("seeded %d airports, created %d cities\n", result.Airports, result.CitiesCreated)
+}
@@ -0,0 +1,229 @@
+package cmd
+
+import (
+	"aliagha/database"
+	"aliagha/models"
+	"aliagha/services"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	apiKeysConfigPath string
+	apiKeyScopes      []string
+	apiKeyExpiresIn   time.Duration
+	apiKeyRateLimit   int32
+	apiKeyUsageDays   int
+)
+
+var apiKeysCmd = &cobra.Command{
+	Use:   "apikeys",
+	Short: "Manage the API keys of partner agencies",
+	Long: `This command issues, lists and revokes the API keys partner agencies send in the
+X-API-Key header. A key acts for the user owning it, given by their email or id,
+within its scopes: ` + strings.Join(services.APIKeyScopes, ", ") + `.
+
+Usage:
+	aliagha apikeys create [user] [name] --scopes [scopes] --expires-in [duration] --rate-limit [n] --config [path]
+	aliagha apikeys list [user] --config [path]
+	aliagha apikeys revoke [id] --config [path]
+	aliagha apikeys usage [id] --days [n] --config [path]`,
+}
+
+var apiKeysCreateCmd = &cobra.Command{
+	Use:          "create [user] [name]",
+	Short:        "Issue an API key; it is only printed once",
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return createAPIKey(args[0], args[1])
+	},
+}
+
+var apiKeysListCmd = &cobra.Command{
+	Use:          "list [user]",
+	Short:        "List the API keys of a user",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listAPIKeys(args[0])
+	},
+}
+
+var apiKeysRevokeCmd = &cobra.Command{
+	Use:          "revoke [id]",
+	Short:        "Revoke an API key",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return revokeAPIKey(args[0])
+	},
+}
+
+var apiKeysUsageCmd = &cobra.Command{
+	Use:          "usage [id]",
+	Short:        "Show the daily requests of an API key",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return showAPIKeyUsage(args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(apiKeysCmd)
+	apiKeysCmd.AddCommand(apiKeysCreateCmd, apiKeysListCmd, apiKeysRevokeCmd, apiKeysUsageCmd)
+	apiKeysCmd.PersistentFlags().StringVarP(&apiKeysConfigPath, "config", "c", "", "Path to the YAML configuration file (required)")
+	if err := apiKeysCmd.MarkPersistentFlagRequired("config"); err != nil {
+		panic(err)
+	}
+
+	apiKeysCreateCmd.Flags().StringSliceVar(&apiKeyScopes, "scopes", nil, "comma separated scopes of the key (required)")
+	apiKeysCreateCmd.Flags().DurationVar(&apiKeyExpiresIn, "expires-in", 0, "lifetime of the key, it never expires when zero")
+	apiKeysCreateCmd.Flags().Int32Var(&apiKeyRateLimit, "rate-limit", 0, "requests per minute, overrides api_keys.default_rate_limit")
+	if err := apiKeysCreateCmd.MarkFlagRequired("scopes"); err != nil {
+		panic(err)
+	}
+
+	apiKeysUsageCmd.Flags().IntVarP(&apiKeyUsageDays, "days", "d", 7, "number of days to show")
+}
+
+func newAPIKeys() (*services.APIKeys, error) {
+	cfg, db, err := openDB(apiKeysConfigPath)
+	if err != nil {
+		return nil, err
+	}
+
+	redis, err := database.InitRedis(&cfg.Redis)
+	if err != nil {
+		return nil, err
+	}
+
+	return &services.APIKeys{
+		DB:               db,
+		Redis:            redis,
+		DefaultRateLimit: cfg.APIKeys.DefaultRateLimit,
+		UsageRetention:   cfg.APIKeys.UsageRetention,
+	}, nil
+}
+
+func createAPIKey(ref, name string) error {
+	apiKeys, err := newAPIKeys()
+	if err != nil {
+		return err
+	}
+	defer apiKeys.Redis.Close()
+
+	user, err := findUser(apiKeys.DB, ref)
+	if err != nil {
+		return err
+	}
+
+	opts := services.NewAPIKey{UserID: user.ID, Name: name, Scopes: apiKeyScopes, RateLimit: apiKeyRateLimit}
+	if apiKeyExpiresIn > 0 {
+		expiresAt := time.Now().Add(apiKeyExpiresIn)
+		opts.ExpiresAt = &expiresAt
+	}
+
+	key, apiKey, err := apiKeys.Create(opts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("created key %d for user %d with scopes %s\n", apiKey.ID, user.ID, apiKey.Scopes)
+	fmt.Printf("%s\n", key)
+	fmt.Println("store the key now, it can not be shown again")
+	return nil
+}
+
+func listAPIKeys(ref string) error {
+	apiKeys, err := newAPIKeys()
+	if err != nil {
+		return err
+	}
+	defer apiKeys.Redis.Close()
+
+	user, err := findUser(apiKeys.DB, ref)
+	if err != nil {
+		return err
+	}
+
+	var keys []models.APIKey
+	if err := apiKeys.DB.Where("u_id = ?", user.ID).Order("id").Find(&keys).Error; err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		fmt.Printf("%d\t%s...\t%s\t%s\texpires %s\tlast used %s\t%s\n",
+			key.ID, key.Prefix, key.Name, key.Scopes, formatTime(key.ExpiresAt), formatTime(key.LastUsedAt), apiKeyState(&key))
+	}
+
+	return nil
+}
+
+func revokeAPIKey(ref string) error {
+	id, err := strconv.Atoi(ref)
+	if err != nil {
+		return fmt.Errorf("invalid key id %q", ref)
+	}
+
+	apiKeys, err := newAPIKeys()
+	if err != nil {
+		return err
+	}
+	defer apiKeys.Redis.Close()
+
+	if err := apiKeys.Revoke(int32(id), time.Now()); err != nil {
+		return err
+	}
+
+	fmt.Printf("revoked key %d\n", id)
+	return nil
+}
+
+func showAPIKeyUsage(ref string) error {
+	id, err := strconv.Atoi(ref)
+	if err != nil {
+		return fmt.Errorf("invalid key id %q", ref)
+	}
+
+	apiKeys, err := newAPIKeys()
+	if err != nil {
+		return err
+	}
+	defer apiKeys.Redis.Close()
+
+	usage, err := apiKeys.Usage(int32(id), apiKeyUsageDays, time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, day := range usage {
+		fmt.Printf("%s\t%d\n", day.Date, day.Requests)
+	}
+
+	return nil
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "never"
+	}
+
+	return t.Format(time.RFC3339)
+}
+
+func apiKeyState(key *models.APIKey) string {
+	switch {
+	case key.RevokedAt != nil:
+		return "revoked"
+	case key.ExpiresAt != nil && !time.Now().Before(*key.ExpiresAt):
+		return "expired"
+	default:
+		return "active"
+	}
+}
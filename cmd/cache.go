@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"aliagha/config"
+	"aliagha/database"
+	"aliagha/jobs"
+	"fmt"
+
+	"github.com/go-redis/redis"
+	"github.com/spf13/cobra"
+)
+
+var cacheConfigPath string
+var cacheWarmDays int
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the flight search cache",
+}
+
+var cacheWarmCmd = &cobra.Command{
+	Use:   "warm",
+	Short: "Pre-fetch flight searches into Redis",
+	Long: `This command fetches flights for the configured routes and the most searched
+routes of the last week, for today and the following days, and stores them in Redis
+so that the first searches are served from the cache.
+
+Usage:
+	aliagha cache warm --config [path] --days [n]`,
+	Run: func(cmd *cobra.Command, args []string) {
+		warmCache()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheWarmCmd)
+	cacheWarmCmd.Flags().StringVarP(&cacheConfigPath, "config", "c", "", "Path to the YAML configuration file (required)")
+	cacheWarmCmd.Flags().IntVarP(&cacheWarmDays, "days", "d", 0, "number of days to warm, overrides cache_warmer.days")
+	if err := cacheWarmCmd.MarkFlagRequired("config"); err != nil {
+		panic(err)
+	}
+}
+
+func warmCache() {
+	cfg, err := config.Init(config.Params{FilePath: cacheConfigPath, FileType: "yaml"})
+	if err != nil {
+		panic(err)
+	}
+
+	redis, err := database.InitRedis(&cfg.Redis)
+	if err != nil {
+		panic(err)
+	}
+
+	if cacheWarmDays > 0 {
+		cfg.CacheWarmer.Days = cacheWarmDays
+	}
+
+	warmer := newCacheWarmer(cfg, redis)
+	result, err := warmer.Warm()
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("warmed %d entries for %d routes, %d failed\n", result.Warmed, result.Routes, result.Failed)
+}
+
+func newCacheWarmer(cfg *config.Config, redis *redis.Client) *jobs.CacheWarmer {
+	ttl := cfg.CacheWarmer.TTL
+	if ttl <= 0 {
+		ttl = cfg.Redis.TTL
+	}
+
+	return &jobs.CacheWarmer{
+		Redis:    redis,
+		Provider: newFlightProvider(cfg),
+		Config:   &cfg.CacheWarmer,
+		TTL:      ttl,
+	}
+}
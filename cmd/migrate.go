@@ -2,13 +2,25 @@ package cmd
 
 import (
 	"aliagha/config"
+	"aliagha/database"
+	"aliagha/migrations"
 	"database/sql"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/golang-migrate/migrate/v4"
+	migratedb "github.com/golang-migrate/migrate/v4/database"
 	"github.com/golang-migrate/migrate/v4/database/mysql"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 
 	_ "github.com/go-sql-driver/mysql"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
@@ -21,30 +33,146 @@ var migrateCmd = &cobra.Command{
 	Short: "Migrate database",
 	Long: `This command migrates the database to a schema version.
 
-The action to perform is determined by the --action flag, which can be set to "up" or "down".
+The action to perform is given as a subcommand. The --action flag, which can be set to "up" or "down", is still accepted
+and behaves like the up and down subcommands.
 
 You must specify a custom configuration file in YAML format using the --config flag. By default, this command will not run without a configuration file.
 
-You must also specify a custom folder path for your migration files using the --folder flag.
+Migrations are embedded in the binary. A custom folder can be given with the --folder flag instead; migrations are read
+from its subfolder named after the configured database driver (mysql, postgres or sqlite) when it has one.
 
 It is recommended to run this command before starting the application to ensure that the necessary tables and columns are available.
 	
 Usage:
+	mycommand migrate [up|down|status|goto|steps|force] --config [path] --folder [path]
+	mycommand migrate create [name] --folder [path]
 	mycommand migrate --config [path] --action [up/down] --folder [path]
 	
 Flags:
-	-a, --action string   Action to perform: "up" or "down" (required)
+	-a, --action string   Action to perform: "up" or "down"
 	-c, --config string   Path to custom configuration file in YAML format (required)
-	-f, --folder string   Path to custom folder for migration files (required)
+	-f, --folder string   Path to custom folder for migration files
 	-h, --help            help for migrate
 	
 It is recommended to run this command before starting the application to ensure that the necessary tables and columns are available.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		if action != "up" && action != "down" {
+		switch action {
+		case "":
+			_ = cmd.Help()
+		case "up":
+			runMigration(func(m *migrate.Migrate) error { return m.Up() })
+		case "down":
+			runMigration(func(m *migrate.Migrate) error { return m.Down() })
+		default:
 			panic(errors.New("invalid action"))
 		}
+	},
+}
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Apply all pending migrations",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runMigration(func(m *migrate.Migrate) error { return m.Up() })
+	},
+}
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down",
+	Short: "Roll back every migration, dropping all tables",
+	Long: `This command rolls back every applied migration, which drops all tables of the
+application. Use "migrate steps -1" to roll back only the last migration.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runMigration(func(m *migrate.Migrate) error { return m.Down() })
+	},
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the schema version and the pending migrations",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		printMigrationStatus()
+	},
+}
+
+var migrateGotoCmd = &cobra.Command{
+	Use:   "goto [version]",
+	Short: "Migrate up or down to a version",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		version, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			panic(fmt.Errorf("invalid version %q", args[0]))
+		}
 
-		migrateDB()
+		runMigration(func(m *migrate.Migrate) error { return m.Migrate(uint(version)) })
+	},
+}
+
+var migrateStepsCmd = &cobra.Command{
+	Use:   "steps [n]",
+	Short: "Apply the next n migrations, or roll back the last n when n is negative",
+	Long: `This command applies the next n migrations, or rolls back the last n
+migrations when n is negative.
+
+Usage:
+	aliagha migrate steps 2 --config [path]
+	aliagha migrate steps -1 --config [path]`,
+	// Flags are parsed by hand, since a negative step count looks like a flag.
+	DisableFlagParsing: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		steps, flags, err := splitSteps(args)
+		if err != nil {
+			panic(err)
+		}
+
+		flagSet := cmd.InheritedFlags()
+		flagSet.AddFlagSet(cmd.Flags())
+		if err := flagSet.Parse(flags); err != nil {
+			panic(err)
+		}
+
+		if help, _ := flagSet.GetBool("help"); help {
+			_ = cmd.Help()
+			return
+		}
+
+		runMigration(func(m *migrate.Migrate) error { return m.Steps(steps) })
+	},
+}
+
+var migrateForceCmd = &cobra.Command{
+	Use:   "force [version]",
+	Short: "Set the schema version and clear the dirty flag",
+	Long: `This command records a version as applied without running any migration and
+clears the dirty flag left by a failed migration. Fix the schema by hand first,
+then force the version it now matches. A version of -1 means no migration.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		version, err := strconv.Atoi(args[0])
+		if err != nil || version < -1 {
+			panic(fmt.Errorf("invalid version %q", args[0]))
+		}
+
+		runMigration(func(m *migrate.Migrate) error { return m.Force(version) })
+	},
+}
+
+var migrateCreateCmd = &cobra.Command{
+	Use:   "create [name]",
+	Short: "Create empty up and down migrations for every driver",
+	Long: `This command creates empty up and down migrations named after the current
+time and the given name in the folder of every database driver. It does not
+need a configuration file.
+
+Usage:
+	aliagha migrate create add_ticket_seats --folder migrations`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		createMigration(args[0])
 	},
 }
 
@@ -53,64 +181,255 @@ var configPath, migrationFolder string
 
 func init() {
 	rootCmd.AddCommand(migrateCmd)
+	migrateCmd.AddCommand(migrateUpCmd, migrateDownCmd, migrateStatusCmd, migrateGotoCmd,
+		migrateStepsCmd, migrateForceCmd, migrateCreateCmd)
 	migrateCmd.Flags().StringVarP(&action, "action", "a", "", `action to perform: "up" or "down"`)
-	migrateCmd.Flags().StringVarP(&configPath, "config", "c", "", "path to custom configuration file in YAML format")
-	migrateCmd.Flags().StringVarP(&migrationFolder, "folder", "f", "", "path to migration folder")
+	migrateCmd.PersistentFlags().StringVarP(&configPath, "config", "c", "", "path to custom configuration file in YAML format")
+	migrateCmd.PersistentFlags().StringVarP(&migrationFolder, "folder", "f", "", "path to migration folder, defaults to the embedded migrations")
 }
 
-func migrateDB() {
-	cfg, err := config.Init(config.Params{FilePath: configPath, FileType: "yaml"})
+// runMigration runs fn against the configured database. Having nothing to
+// migrate is not an error.
+func runMigration(fn func(m *migrate.Migrate) error) {
+	m, _, err := newMigrate()
 	if err != nil {
 		panic(err)
 	}
+	defer m.Close()
 
-	username := cfg.Database.Username
-	password := cfg.Database.Password
-	host := cfg.Database.Host
-	port := strconv.Itoa(cfg.Database.Port)
-	dbname := cfg.Database.DB
+	if err := fn(m); err != nil && err != migrate.ErrNoChange {
+		panic(err)
+	}
+}
 
-	dataSourceName := fmt.Sprintf("%s:%s@tcp(%s:%s)/",
-		username, password, host, port)
+func newMigrate() (*migrate.Migrate, string, error) {
+	if configPath == "" {
+		return nil, "", errors.New("the --config flag is required")
+	}
 
-	dbStart, err := sql.Open("mysql", dataSourceName)
+	cfg, err := config.Init(config.Params{FilePath: configPath, FileType: "yaml"})
 	if err != nil {
-		panic(err)
+		return nil, "", err
 	}
 
-	_, err = dbStart.Exec(fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s", dbname))
+	driverName := database.Driver(&cfg.Database)
+	sourceDriver, err := newMigrationSource(driverName)
 	if err != nil {
-		panic(err)
+		return nil, "", err
 	}
 
-	dataSourceName = fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?multiStatements=true",
-		username, password, host, port, dbname)
-	db, err := sql.Open("mysql", dataSourceName)
+	driver, err := newMigrationDriver(&cfg.Database)
 	if err != nil {
-		panic(err)
+		return nil, "", err
+	}
+
+	m, err := migrate.NewWithInstance("migrations", sourceDriver, driverName, driver)
+	return m, driverName, err
+}
+
+// newMigrationSource reads migrations from --folder, or from the migrations
+// embedded in the binary when it is not set.
+func newMigrationSource(driverName string) (source.Driver, error) {
+	if migrationFolder == "" {
+		return iofs.New(migrations.FS, driverName)
 	}
 
-	driver, err := mysql.WithInstance(db, &mysql.Config{})
+	return source.Open(fmt.Sprintf("file://%s", migrationPath(migrationFolder, driverName)))
+}
+
+func printMigrationStatus() {
+	m, driverName, err := newMigrate()
 	if err != nil {
 		panic(err)
 	}
+	defer m.Close()
+
+	version, dirty, err := m.Version()
+	applied := err == nil
+	switch {
+	case err == migrate.ErrNilVersion:
+		fmt.Println("version: none")
+	case err != nil:
+		panic(err)
+	case dirty:
+		fmt.Printf("version: %d (dirty, fix the schema and run migrate force)\n", version)
+	default:
+		fmt.Printf("version: %d\n", version)
+	}
 
-	m, err := migrate.NewWithDatabaseInstance(
-		fmt.Sprintf("file://%s", migrationFolder),
-		"mysql",
-		driver,
-	)
+	sourceDriver, err := newMigrationSource(driverName)
 	if err != nil {
 		panic(err)
 	}
+	defer sourceDriver.Close()
 
-	if action == "up" {
-		err = m.Up()
-	} else {
-		err = m.Down()
+	next, err := sourceDriver.First()
+	for err == nil {
+		state := "pending"
+		if applied && next <= version {
+			state = "applied"
+		}
+		if applied && dirty && next == version {
+			state = "dirty"
+		}
+
+		fmt.Printf("%-16d %-8s %s\n", next, state, migrationName(sourceDriver, next))
+		next, err = sourceDriver.Next(next)
 	}
 
-	if err != nil && err != migrate.ErrNoChange {
+	if !errors.Is(err, os.ErrNotExist) {
 		panic(err)
 	}
 }
+
+func migrationName(sourceDriver source.Driver, version uint) string {
+	r, identifier, err := sourceDriver.ReadUp(version)
+	if err != nil {
+		return ""
+	}
+	r.Close()
+
+	return identifier
+}
+
+var migrationNameCleaner = regexp.MustCompile(`[^a-z0-9]+`)
+
+// createMigration writes empty up and down files named after the current UTC
+// time into the folder of every driver, or into the folder itself when it has
+// no driver subfolders.
+func createMigration(name string) {
+	name = strings.Trim(migrationNameCleaner.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		panic(errors.New("invalid migration name"))
+	}
+
+	folder := migrationFolder
+	if folder == "" {
+		folder = "migrations"
+	}
+
+	folders := make([]string, 0, 3)
+	for _, driverName := range []string{database.DriverMySQL, database.DriverPostgres, database.DriverSQLite} {
+		if path := migrationPath(folder, driverName); path != folder {
+			folders = append(folders, path)
+		}
+	}
+
+	if len(folders) == 0 {
+		folders = append(folders, folder)
+	}
+
+	version := time.Now().UTC().Format("20060102150405")
+	for _, dir := range folders {
+		for _, direction := range []string{"up", "down"} {
+			path := filepath.Join(dir, fmt.Sprintf("%s_%s.%s.sql", version, name, direction))
+			file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+			if err != nil {
+				panic(err)
+			}
+			file.Close()
+
+			fmt.Println("created", path)
+		}
+	}
+}
+
+// splitSteps separates the step count from the flags of the steps command.
+func splitSteps(args []string) (int, []string, error) {
+	steps, found := 0, false
+	flags := make([]string, 0, len(args))
+	for _, arg := range args {
+		if n, err := strconv.Atoi(arg); err == nil && !found {
+			steps, found = n, true
+			continue
+		}
+
+		flags = append(flags, arg)
+	}
+
+	if !found && !containsHelp(flags) {
+		return 0, nil, errors.New("the number of steps is required")
+	}
+
+	return steps, flags, nil
+}
+
+func containsHelp(args []string) bool {
+	for _, arg := range args {
+		if arg == "-h" || arg == "--help" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// migrationPath returns the migrations of the given driver: the subfolder
+// named after it when there is one, or the folder itself.
+func migrationPath(folder, driverName string) string {
+	dialectFolder := filepath.Join(folder, driverName)
+	if info, err := os.Stat(dialectFolder); err == nil && info.IsDir() {
+		return dialectFolder
+	}
+
+	return folder
+}
+
+// newMigrationDriver connects to the configured database, creating it first
+// on database servers.
+func newMigrationDriver(dbConfig *config.Database) (migratedb.Driver, error) {
+	switch database.Driver(dbConfig) {
+	case database.DriverMySQL:
+		dbStart, err := sql.Open("mysql", database.MySQLDSN(dbConfig, ""))
+		if err != nil {
+			return nil, err
+		}
+		defer dbStart.Close()
+
+		_, err = dbStart.Exec(fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s", dbConfig.DB))
+		if err != nil {
+			return nil, err
+		}
+
+		db, err := sql.Open("mysql", database.MySQLDSN(dbConfig, dbConfig.DB)+"&multiStatements=true")
+		if err != nil {
+			return nil, err
+		}
+
+		return mysql.WithInstance(db, &mysql.Config{})
+	case database.DriverPostgres:
+		dbStart, err := sql.Open("postgres", database.PostgresDSN(dbConfig, "postgres"))
+		if err != nil {
+			return nil, err
+		}
+		defer dbStart.Close()
+
+		var exists bool
+		err = dbStart.QueryRow("SELECT EXISTS (SELECT 1 FROM pg_database WHERE datname = $1)", dbConfig.DB).Scan(&exists)
+		if err != nil {
+			return nil, err
+		}
+
+		if !exists {
+			if _, err := dbStart.Exec(fmt.Sprintf("CREATE DATABASE %q", dbConfig.DB)); err != nil {
+				return nil, err
+			}
+		}
+
+		db, err := sql.Open("postgres", database.PostgresDSN(dbConfig, dbConfig.DB))
+		if err != nil {
+			return nil, err
+		}
+
+		return postgres.WithInstance(db, &postgres.Config{})
+	case database.DriverSQLite:
+		db, err := sql.Open("sqlite3", database.SQLiteDSN(dbConfig))
+		if err != nil {
+			return nil, err
+		}
+
+		return sqlite3.WithInstance(db, &sqlite3.Config{})
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", dbConfig.Driver)
+	}
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"aliagha/mockapi"
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var mockAPIAddress string
+var mockAPISeedPath string
+var mockAPIDays int
+var mockAPILatency time.Duration
+var mockAPIJitter time.Duration
+var mockAPIErrorRate float64
+
+var mockAPICmd = &cobra.Command{
+	Use:   "mockapi",
+	Short: "Serve a mock airline API for local development",
+	Long: `This command serves the airline API the application searches and reserves
+flights through, so the service can run without the real upstream. Flights are
+generated every day from the schedules of a YAML seed and seats are reserved in
+memory, so restarting the command resets every reservation.
+
+A seed bundled with the binary is used unless --seed is given. Latency, jitter
+and a share of failing requests can be added to exercise timeouts, retries and
+the circuit breaker.
+
+Usage:
+	aliagha mockapi --address [host:port] --seed [path] --latency [duration] --error-rate [0-1]`,
+	Run: func(cmd *cobra.Command, args []string) {
+		serveMockAPI()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mockAPICmd)
+	mockAPICmd.Flags().StringVarP(&mockAPIAddress, "address", "a", "127.0.0.1:8000", "address to listen on")
+	mockAPICmd.Flags().StringVarP(&mockAPISeedPath, "seed", "s", "", "path to a YAML seed, defaults to the bundled seed")
+	mockAPICmd.Flags().IntVarP(&mockAPIDays, "days", "d", 0, "number of days flights are offered, overrides the seed")
+	mockAPICmd.Flags().DurationVar(&mockAPILatency, "latency", 0, "delay added to every response")
+	mockAPICmd.Flags().DurationVar(&mockAPIJitter, "jitter", 0, "maximum random delay added on top of the latency")
+	mockAPICmd.Flags().Float64Var(&mockAPIErrorRate, "error-rate", 0, "share of requests answered with 503, between 0 and 1")
+}
+
+func serveMockAPI() {
+	seed, err := loadMockAPISeed()
+	if err != nil {
+		panic(err)
+	}
+
+	if mockAPIDays > 0 {
+		seed.Days = mockAPIDays
+	}
+
+	server, err := mockapi.NewServer(seed, mockapi.Options{
+		Latency:   mockAPILatency,
+		Jitter:    mockAPIJitter,
+		ErrorRate: mockAPIErrorRate,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	e := server.Echo()
+	go func() {
+		if err := e.Start(mockAPIAddress); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			e.Logger.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		e.Logger.Fatal(err)
+	}
+}
+
+func loadMockAPISeed() (*mockapi.Seed, error) {
+	if mockAPISeedPath == "" {
+		return mockapi.DefaultSeed()
+	}
+
+	return mockapi.LoadSeed(mockAPISeedPath)
+}
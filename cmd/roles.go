@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"aliagha/config"
+	"aliagha/database"
+	"aliagha/models"
+	"aliagha/services"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+var rolesConfigPath string
+
+var rolesCmd = &cobra.Command{
+	Use:   "roles",
+	Short: "Manage the roles of users",
+	Long: `This command lists the roles and grants or revokes them. Users are given by
+their email or id. Access tokens carry the roles of the user, so a granted role
+takes effect on the next login or token refresh; revoking a role logs the user out
+everywhere.
+
+Usage:
+	aliagha roles list --config [path]
+	aliagha roles show [user] --config [path]
+	aliagha roles grant [user] [role] --config [path]
+	aliagha roles revoke [user] [role] --config [path]`,
+}
+
+var rolesListCmd = &cobra.Command{
+	Use:          "list",
+	Short:        "List the roles and their permissions",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listRoles()
+	},
+}
+
+var rolesShowCmd = &cobra.Command{
+	Use:          "show [user]",
+	Short:        "Show the roles and permissions of a user",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return showRoles(args[0])
+	},
+}
+
+var rolesGrantCmd = &cobra.Command{
+	Use:          "grant [user] [role]",
+	Short:        "Grant a role to a user",
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return grantRole(args[0], args[1])
+	},
+}
+
+var rolesRevokeCmd = &cobra.Command{
+	Use:          "revoke [user] [role]",
+	Short:        "Revoke a role from a user and log them out",
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return revokeRole(args[0], args[1])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rolesCmd)
+	rolesCmd.AddCommand(rolesListCmd, rolesShowCmd, rolesGrantCmd, rolesRevokeCmd)
+	rolesCmd.PersistentFlags().StringVarP(&rolesConfigPath, "config", "c", "", "Path to the YAML configuration file (required)")
+	if err := rolesCmd.MarkPersistentFlagRequired("config"); err != nil {
+		panic(err)
+	}
+}
+
+func openDB(configPath string) (*config.Config, *gorm.DB, error) {
+	cfg, err := config.Init(config.Params{FilePath: configPath, FileType: "yaml"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db, err := database.InitDB(&cfg.Database)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cfg, db, nil
+}
+
+// findUser looks the user up by id when the argument is numeric and by email
+// otherwise.
+func findUser(db *gorm.DB, ref string) (*models.User, error) {
+	var user models.User
+	query := db.Where("email = ?", ref)
+	if id, err := strconv.Atoi(ref); err == nil {
+		query = db.Where("id = ?", id)
+	}
+
+	if err := query.First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("user %q not found", ref)
+		}
+
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func listRoles() error {
+	_, db, err := openDB(rolesConfigPath)
+	if err != nil {
+		return err
+	}
+
+	var roles []models.Role
+	if err := db.Order("name").Find(&roles).Error; err != nil {
+		return err
+	}
+
+	for _, role := range roles {
+		var permissions []string
+		err := db.Model(&models.RolePermission{}).Where("role_id = ?", role.ID).
+			Order("permission").Pluck("permission", &permissions).Error
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s\t%s\t%s\n", role.Name, strings.Join(permissions, ","), role.Description)
+	}
+
+	return nil
+}
+
+func showRoles(ref string) error {
+	_, db, err := openDB(rolesConfigPath)
+	if err != nil {
+		return err
+	}
+
+	user, err := findUser(db, ref)
+	if err != nil {
+		return err
+	}
+
+	roles, permissions, err := services.UserRoles(db, user.ID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("user %d roles: %s\n", user.ID, strings.Join(roles, ", "))
+	fmt.Printf("user %d permissions: %s\n", user.ID, strings.Join(permissions, ", "))
+	return nil
+}
+
+func grantRole(ref, role string) error {
+	_, db, err := openDB(rolesConfigPath)
+	if err != nil {
+		return err
+	}
+
+	user, err := findUser(db, ref)
+	if err != nil {
+		return err
+	}
+
+	if err := services.GrantRole(db, user.ID, role); err != nil {
+		return fmt.Errorf("granting %q to user %d: %w", role, user.ID, err)
+	}
+
+	fmt.Printf("granted %s to user %d\n", role, user.ID)
+	return nil
+}
+
+func revokeRole(ref, role string) error {
+	cfg, db, err := openDB(rolesConfigPath)
+	if err != nil {
+		return err
+	}
+
+	user, err := findUser(db, ref)
+	if err != nil {
+		return err
+	}
+
+	if err := services.RevokeRole(db, user.ID, role); err != nil {
+		return fmt.Errorf("revoking %q from user %d: %w", role, user.ID, err)
+	}
+
+	// Issued access tokens still carry the role, so they are revoked too.
+	redis, err := database.InitRedis(&cfg.Redis)
+	if err != nil {
+		return err
+	}
+	defer redis.Close()
+
+	tokens := &services.TokenStore{Redis: redis, AccessTTL: cfg.JWT.ExpiresIn, RefreshTTL: cfg.JWT.RefreshExpiresIn}
+	if err := tokens.RevokeUserTokens(user.ID); err != nil {
+		return err
+	}
+
+	fmt.Printf("revoked %s from user %d\n", role, user.ID)
+	return nil
+}
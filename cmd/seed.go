@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"aliagha/config"
+	"aliagha/database"
+	"aliagha/services"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var seedConfigPath string
+var seedValue int64
+var seedScale string
+var seedDate string
+
+var seedCmd = &cobra.Command{
+	Use:   "seed",
+	Short: "Fill the database with generated data for local development",
+	Long: `This command fills a migrated database with realistic data: the bundled
+airports and their cities, airplanes, canceling situations, users with their
+passengers, flights around --date (today by default) and tickets in every
+state. The same --seed, --scale and --date generate the same data.
+
+Every generated user can log in with their email and the password "password".
+The command refuses to run twice on the same database; run migrate down and up
+to start over.
+
+Usage:
+	aliagha seed --config [path] --seed [n] --scale [small|medium|large] --date [YYYY-MM-DD]`,
+	Run: func(cmd *cobra.Command, args []string) {
+		seedFixtures()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(seedCmd)
+	seedCmd.Flags().StringVarP(&seedConfigPath, "config", "c", "", "Path to the YAML configuration file (required)")
+	seedCmd.Flags().Int64Var(&seedValue, "seed", 1, "seed of the random generator")
+	seedCmd.Flags().StringVar(&seedScale, "scale", "small", "amount of data: "+strings.Join(fixtureScaleNames(), ", "))
+	seedCmd.Flags().StringVar(&seedDate, "date", "", "day the flights are spread around, as YYYY-MM-DD (default today)")
+	if err := seedCmd.MarkFlagRequired("config"); err != nil {
+		panic(err)
+	}
+}
+
+func seedFixtures() {
+	scale, ok := services.FixtureScales[seedScale]
+	if !ok {
+		panic(fmt.Errorf("unknown scale %q, expected one of %s", seedScale, strings.Join(fixtureScaleNames(), ", ")))
+	}
+
+	var now time.Time
+	if seedDate != "" {
+		var err error
+		now, err = time.Parse("2006-01-02", seedDate)
+		if err != nil {
+			panic(fmt.Errorf("invalid date %q, expected YYYY-MM-DD", seedDate))
+		}
+	}
+
+	cfg, err := config.Init(config.Params{FilePath: seedConfigPath, FileType: "yaml"})
+	if err != nil {
+		panic(err)
+	}
+
+	db, err := database.InitDB(&cfg.Database)
+	if err != nil {
+		panic(err)
+	}
+
+	result, err := services.SeedFixtures(db, services.FixtureOptions{Seed: seedValue, Scale: scale, Now: now})
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("seeded %d airports, %d users, %d passengers, %d flights and %d tickets\n",
+		result.Airports, result.Users, result.Passengers, result.Flights, result.Tickets)
+	fmt.Printf("users log in with the password %q\n", services.FixturePassword)
+}
+
+func fixtureScaleNames() []string {
+	names := make([]string, 0, len(services.FixtureScales))
+	for name := range services.FixtureScales {
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		return services.FixtureScales[names[i]].Users < services.FixtureScales[names[j]].Users
+	})
+
+	return names
+}
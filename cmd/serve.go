@@ -3,13 +3,28 @@ package cmd
 import (
 	"aliagha/config"
 	"aliagha/database"
+	"aliagha/helpers"
 	"aliagha/http/handler"
 	"aliagha/http/middleware"
+	"aliagha/jobs"
 	"aliagha/services"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/eapache/go-resiliency/breaker"
 	"github.com/go-playground/validator/v10"
+	"github.com/go-redis/redis"
+	"gorm.io/gorm"
 
 	"github.com/labstack/echo/v4"
 	"github.com/spf13/cobra"
@@ -19,15 +34,22 @@ var serveConfigPath string
 
 var serveCmd = &cobra.Command{
 	Use:   "serve",
-	Short: "A brief description of your command",
-	Long: `A longer description that spans multiple lines and likely contains examples
-and usage of using your command. For example:
-
-Cobra is a CLI library for Go that empowers applications.
-This application is a tool to generate the needed files
-to quickly create a Cobra application.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		startServer()
+	Short: "Start the HTTP API server",
+	Long: `This command starts the HTTP API and its background workers. It listens on
+server.address and server.port, and serves TLS when both server.tls_cert_file
+and server.tls_key_file are set.
+
+On SIGINT or SIGTERM the server stops accepting connections and waits up to
+server.shutdown_timeout for in-flight requests, then stops the workers and
+closes the database and Redis connections.
+
+Usage:
+	aliagha serve --config [path]`,
+	// Errors are reported without the usage text, which does not help once
+	// the flags were accepted.
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return startServer()
 	},
 }
 
@@ -39,57 +61,388 @@ func init() {
 	}
 }
 
-func startServer() {
+// startServer serves until SIGINT or SIGTERM. On a signal it stops accepting
+// connections and lets in-flight requests finish, then stops the background
+// workers and closes the database and Redis pools.
+func startServer() error {
 	cfg, err := config.Init(config.Params{FilePath: serveConfigPath, FileType: "yaml"})
 	if err != nil {
-		panic(err)
+		return err
 	}
 
+	ipExtractor, err := newIPExtractor(cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	redis, err := database.InitRedis(&cfg.Redis)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	db, err := database.InitDB(&cfg.Database)
 	if err != nil {
-		panic(err)
+		redis.Close()
+		return err
+	}
+
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	var workers sync.WaitGroup
+	startWorker := func(run func(ctx context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(workersCtx)
+		}()
 	}
 
 	vldt := validator.New()
 
 	e := echo.New()
+	e.IPExtractor = ipExtractor
 
-	mockClient := services.APIMockClient{
-		Client:  &http.Client{},
-		Breaker: &breaker.Breaker{},
-		BaseURL: cfg.MockAPI.URL,
-		Timeout: cfg.MockAPI.Timeout,
+	mockClient := newAPIMockClient(cfg)
+	provider := newFlightProvider(cfg)
+
+	fareRecorder := services.NewFareRecorder(db, cfg.FareHistory.SnapshotInterval, cfg.FareHistory.QueueSize)
+	startWorker(fareRecorder.Run)
+
+	if cfg.CacheWarmer.Enabled {
+		warmer := newCacheWarmer(cfg, redis)
+		warmer.FareRecorder = fareRecorder
+		startWorker(warmer.Run)
+	}
+
+	timezones, err := services.NewCityTimezones(db, cfg.Cities.DefaultTimezone, cfg.Cities.CacheTTL)
+	if err != nil {
+		stopWorkers()
+		workers.Wait()
+		return errors.Join(err, closeConnections(db, redis))
+	}
+
+	keys, err := helpers.NewKeySet(&cfg.JWT)
+	if err != nil {
+		stopWorkers()
+		workers.Wait()
+		return errors.Join(err, closeConnections(db, redis))
+	}
+
+	airports := &services.AirportDirectory{DB: db, TTL: cfg.Cities.CacheTTL}
+
+	apiKeys := &services.APIKeys{
+		DB:               db,
+		Redis:            redis,
+		DefaultRateLimit: cfg.APIKeys.DefaultRateLimit,
+		UsageRetention:   cfg.APIKeys.UsageRetention,
 	}
+	search := middleware.OptionalAPIKey(apiKeys, services.ScopeSearch)
 
-	flight := handler.Flight{Redis: redis, Validator: vldt, Config: cfg, APIMock: mockClient}
+	flight := handler.Flight{DB: db, Redis: redis, Validator: vldt, Config: cfg, Provider: provider, FareRecorder: fareRecorder, Timezones: timezones, Airports: airports}
 	// jwtMiddleware := middleware.AuthenticatorMiddleware(cfg.JWT.SecretKey)
 
-	e.GET("/flights", flight.Get)
+	e.GET("/flights", flight.Get, search)
+
+	fare := handler.Fare{DB: db, Validator: vldt, Provider: provider}
+	e.GET("/flights/price-trend", fare.PriceTrend, search)
+	e.GET("/flights/:id/price-history", fare.PriceHistory, search)
+	e.GET("/flights/:id", flight.Detail, search)
+
+	city := handler.City{Validator: vldt, APIMock: mockClient, CacheTTL: cfg.Cities.CacheTTL}
+	e.GET("/cities/suggest", city.Suggest, search)
+
+	tokens := &services.TokenStore{Redis: redis, AccessTTL: cfg.JWT.ExpiresIn, RefreshTTL: cfg.JWT.RefreshExpiresIn}
+	auth := middleware.AuthMiddleware(keys, tokens)
+	// partner accepts an API key granted scope in place of an access token.
+	partner := func(scope string) echo.MiddlewareFunc {
+		return middleware.AuthOrAPIKeyMiddleware(keys, tokens, apiKeys, scope)
+	}
+
+	otp := &services.OTPStore{
+		Redis:          redis,
+		Sender:         newSMSSender(cfg),
+		Secret:         cfg.Security.SecretKey,
+		Length:         cfg.OTP.Length,
+		TTL:            cfg.OTP.TTL,
+		MaxAttempts:    cfg.OTP.MaxAttempts,
+		ResendInterval: cfg.OTP.ResendInterval,
+		MaxSends:       cfg.OTP.MaxSends,
+		SendWindow:     cfg.OTP.SendWindow,
+	}
+
+	passwordResets := &services.PasswordResets{
+		Redis:  redis,
+		Mailer: newMailer(cfg),
+		TTL:    cfg.PasswordReset.TTL,
+		URL:    cfg.PasswordReset.URL,
+	}
+
+	emailVerifications := &services.EmailVerifications{
+		Redis:  redis,
+		Mailer: newMailer(cfg),
+		TTL:    cfg.Verification.EmailTTL,
+		URL:    cfg.Verification.URL,
+	}
+
+	limiter := &services.LoginLimiter{
+		Redis:              redis,
+		Window:             cfg.LoginLimiter.Window,
+		FreeFailures:       cfg.LoginLimiter.FreeFailures,
+		BaseDelay:          cfg.LoginLimiter.BaseDelay,
+		MaxDelay:           cfg.LoginLimiter.MaxDelay,
+		MaxAccountFailures: cfg.LoginLimiter.MaxAccountFailures,
+		MaxIPFailures:      cfg.LoginLimiter.MaxIPFailures,
+		LockoutDuration:    cfg.LoginLimiter.LockoutDuration,
+		MaxRegistrations:   cfg.LoginLimiter.MaxRegistrations,
+	}
+
+	user := handler.User{
+		DB:                 db,
+		JWT:                &cfg.JWT,
+		Keys:               keys,
+		Validator:          vldt,
+		Tokens:             tokens,
+		Sessions:           &services.Sessions{DB: db, Tokens: tokens},
+		OTP:                otp,
+		Limiter:            limiter,
+		PasswordResets:     passwordResets,
+		EmailVerifications: emailVerifications,
+	}
+	jwks := handler.JWKS{Keys: keys}
+	e.GET("/.well-known/jwks.json", jwks.Get)
 
-	user := handler.User{DB: db, JWT: &cfg.JWT, Validator: vldt}
 	e.POST("/user/login", user.Login)
 	e.POST("/user/register", user.Register)
+	e.POST("/user/otp/request", user.RequestOTP)
+	e.POST("/user/otp/verify", user.VerifyOTP)
+	e.POST("/user/refresh", user.Refresh)
+	e.POST("/user/logout", user.Logout, auth)
+	e.POST("/user/password/forgot", user.ForgotPassword)
+	e.POST("/user/password/reset", user.ResetPassword)
+	e.POST("/user/password/change", user.ChangePassword, auth)
+	e.POST("/user/verify/email/send", user.SendEmailVerification, auth)
+	e.POST("/user/verify/email", user.VerifyEmail)
+	e.POST("/user/verify/cellphone/send", user.SendCellphoneVerification, auth)
+	e.POST("/user/verify/cellphone", user.VerifyCellphone, auth)
+	e.GET("/user/me", user.GetProfile, auth)
+	e.PATCH("/user/me", user.UpdateProfile, auth)
+	e.DELETE("/user/me", user.DeleteAccount, auth)
+	e.GET("/user/sessions", user.GetSessions, auth)
+	e.DELETE("/user/sessions", user.RevokeOtherSessions, auth)
+	e.DELETE("/user/sessions/:id", user.RevokeSession, auth)
+
+	admin := handler.Admin{DB: db, Validator: vldt, Limiter: limiter}
+	e.POST("/admin/login/unlock", admin.UnlockLogin, auth, middleware.RequirePermission(services.PermissionUsersUnlock))
+	e.GET("/admin/users/:id", admin.GetUser, auth, middleware.RequirePermission(services.PermissionUsersRead))
+	e.GET("/admin/users/:id/tickets", admin.GetUserTickets, auth, middleware.RequirePermission(services.PermissionTicketsRead))
 
 	passenger := handler.Passenger{DB: db, Validator: vldt}
-	e.POST("/passengers", passenger.CreatePassenger, middleware.AuthMiddleware(cfg.JWT.SecretKey))
-	e.GET("/passengers", passenger.GetPassengers, middleware.AuthMiddleware(cfg.JWT.SecretKey))
+	e.POST("/passengers", passenger.CreatePassenger, partner(services.ScopePassengers))
+	e.GET("/passengers", passenger.GetPassengers, partner(services.ScopePassengers))
+
+	alert := handler.Alert{DB: db, Validator: vldt}
+	alerts := e.Group("/alerts", auth)
+	alerts.POST("", alert.Create)
+	alerts.GET("", alert.List)
+	alerts.GET("/:id", alert.Get)
+	alerts.PATCH("/:id", alert.Update)
+	alerts.DELETE("/:id", alert.Delete)
+
+	if cfg.Alerts.Enabled {
+		scheduler := jobs.AlertScheduler{
+			DB:       db,
+			Provider: provider,
+			Notifier: newNotifier(cfg),
+			Interval: cfg.Alerts.Interval,
+		}
+		startWorker(scheduler.Run)
+	}
+
+	if cfg.ReferenceSync.Enabled {
+		referenceSync := jobs.ReferenceSync{DB: db, Source: &mockClient, Interval: cfg.ReferenceSync.Interval}
+		startWorker(referenceSync.Run)
+	}
+
+	if cfg.Verification.CleanupEnabled {
+		cleanup := jobs.UnverifiedUserCleanup{
+			DB:          db,
+			GracePeriod: cfg.Verification.GracePeriod,
+			Interval:    cfg.Verification.CleanupInterval,
+		}
+		startWorker(cleanup.Run)
+	}
 
 	ticket := handler.Ticket{DB: db}
-	e.GET("/tickets", ticket.GetTickets, middleware.AuthMiddleware(cfg.JWT.SecretKey))
+	e.GET("/tickets", ticket.GetTickets, partner(services.ScopeTickets))
 
-	flightReservation := handler.FlightReservation{DB: db, Validator: vldt, APIMock: mockClient}
-	e.POST("/flights/reserve", flightReservation.Reserve, middleware.AuthMiddleware(cfg.JWT.SecretKey))
+	flightReservation := handler.FlightReservation{DB: db, Redis: redis, Validator: vldt, Provider: provider, Airports: airports}
+	e.POST("/flights/reserve", flightReservation.Reserve, partner(services.ScopeBookings), middleware.RequireVerifiedContact(db))
 
 	e.GET(cfg.Zarinpal.CallbackUrl, flightReservation.VerifyPayment)
 
-	err = e.Start("localhost:3030")
-	if err != nil {
-		panic(err)
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- listen(e, &cfg.Server)
+	}()
+
+	select {
+	case err = <-serverErr:
+	case <-ctx.Done():
+		log.Println("shutting down, waiting for in-flight requests")
+
+		timeout := cfg.Server.ShutdownTimeout
+		if timeout <= 0 {
+			timeout = 10 * time.Second
+		}
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		err = e.Shutdown(shutdownCtx)
+	}
+
+	stopWorkers()
+	workers.Wait()
+
+	return errors.Join(err, closeConnections(db, redis))
+}
+
+// listen serves on the configured address, over TLS when a certificate and
+// key are configured. It returns nil once the server is shut down.
+func listen(e *echo.Echo, server *config.Server) error {
+	port := server.Port
+	if port == 0 {
+		port = 3030
+	}
+	address := net.JoinHostPort(server.Address, strconv.Itoa(port))
+
+	var err error
+	switch {
+	case server.TLSCertFile != "" && server.TLSKeyFile != "":
+		err = e.StartTLS(address, server.TLSCertFile, server.TLSKeyFile)
+	case server.TLSCertFile != "" || server.TLSKeyFile != "":
+		err = errors.New("server: both tls_cert_file and tls_key_file must be set to serve TLS")
+	default:
+		err = e.Start(address)
+	}
+
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
+
+func closeConnections(db *gorm.DB, redis *redis.Client) error {
+	var errs []error
+	if sqlDB, err := db.DB(); err != nil {
+		errs = append(errs, err)
+	} else if err := sqlDB.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("closing database: %w", err))
+	}
+
+	if err := redis.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("closing redis: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
+
+// newIPExtractor reads the client IP from X-Forwarded-For only behind the
+// trusted proxies, so clients can not pick the IP that login limits and
+// sessions record.
+func newIPExtractor(cfg *config.Config) (echo.IPExtractor, error) {
+	if len(cfg.Server.TrustedProxies) == 0 {
+		return echo.ExtractIPDirect(), nil
+	}
+
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, proxy := range cfg.Server.TrustedProxies {
+		_, ipRange, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+
+	return echo.ExtractIPFromXFFHeader(options...), nil
+}
+
+func newAPIMockClient(cfg *config.Config) services.APIMockClient {
+	return services.APIMockClient{
+		Client:  &http.Client{},
+		Breaker: &breaker.Breaker{},
+		BaseURL: cfg.MockAPI.URL,
+		Timeout: cfg.MockAPI.Timeout,
+	}
+}
+
+// newFlightProvider builds an aggregator over the configured providers,
+// falling back to the mock API when none are configured.
+func newFlightProvider(cfg *config.Config) services.FlightProvider {
+	if len(cfg.Providers) == 0 {
+		client := newAPIMockClient(cfg)
+		return services.NewAggregator(services.NamedProvider{Name: "mock", Provider: &client, Timeout: cfg.MockAPI.Timeout})
+	}
+
+	providers := make([]services.NamedProvider, 0, len(cfg.Providers))
+	for _, p := range cfg.Providers {
+		timeout := p.Timeout
+		if timeout <= 0 {
+			timeout = cfg.MockAPI.Timeout
+		}
+
+		providers = append(providers, services.NamedProvider{
+			Name: p.Name,
+			Provider: &services.APIMockClient{
+				Client:  &http.Client{},
+				Breaker: &breaker.Breaker{},
+				BaseURL: p.URL,
+				Timeout: timeout,
+			},
+			Timeout: timeout,
+		})
+	}
+
+	return services.NewAggregator(providers...)
+}
+
+func newMailer(cfg *config.Config) services.Mailer {
+	if cfg.Mailer.Driver == "smtp" {
+		return &services.SMTPMailer{
+			Host:     cfg.Mailer.Host,
+			Port:     cfg.Mailer.Port,
+			Username: cfg.Mailer.Username,
+			Password: cfg.Mailer.Password,
+			From:     cfg.Mailer.From,
+		}
+	}
+
+	return &services.LogMailer{}
+}
+
+func newSMSSender(cfg *config.Config) services.SMSSender {
+	if cfg.SMS.Driver == "kavenegar" {
+		return &services.KavenegarSMSSender{
+			Client:  &http.Client{Timeout: 10 * time.Second},
+			BaseURL: cfg.SMS.URL,
+			APIKey:  cfg.SMS.APIKey,
+			Sender:  cfg.SMS.Sender,
+		}
+	}
+
+	return &services.ConsoleSMSSender{Path: cfg.SMS.File}
+}
+
+func newNotifier(cfg *config.Config) services.Notifier {
+	if cfg.Alerts.Notifier == "email" {
+		return &services.EmailNotifier{Mailer: newMailer(cfg)}
 	}
 
+	return &services.LogNotifier{}
 }
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"aliagha/config"
+	"aliagha/database"
+	"aliagha/services"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var syncConfigPath string
+var syncDryRun bool
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Sync reference data from the mock API",
+	Long: `This command fetches cities, airplanes and canceling situations from the mock
+API and upserts them into the local tables by id. Rows that no longer exist
+upstream are reported but kept, since flights and tickets may reference them.
+Upstream rows whose name is already used by a local row with another id are
+reported as conflicts and left alone.
+
+Usage:
+	aliagha sync --config [path] --dry-run`,
+	Run: func(cmd *cobra.Command, args []string) {
+		syncReference()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+	syncCmd.Flags().StringVarP(&syncConfigPath, "config", "c", "", "Path to the YAML configuration file (required)")
+	syncCmd.Flags().BoolVar(&syncDryRun, "dry-run", false, "report the changes without writing them")
+	if err := syncCmd.MarkFlagRequired("config"); err != nil {
+		panic(err)
+	}
+}
+
+func syncReference() {
+	cfg, err := config.Init(config.Params{FilePath: syncConfigPath, FileType: "yaml"})
+	if err != nil {
+		panic(err)
+	}
+
+	db, err := database.InitDB(&cfg.Database)
+	if err != nil {
+		panic(err)
+	}
+
+	client := newAPIMockClient(cfg)
+	report, err := services.SyncReference(db, &client, syncDryRun)
+	if err != nil {
+		panic(err)
+	}
+
+	printSyncChanges("cities", report.Cities)
+	printSyncChanges("airplanes", report.Airplanes)
+	printSyncChanges("canceling situations", report.CancelingSituations)
+	if syncDryRun {
+		fmt.Println("dry run, nothing was written")
+	}
+}
+
+func printSyncChanges(table string, changes services.SyncChanges) {
+	fmt.Printf("%s: %s\n", table, changes)
+	for _, group := range []struct {
+		label string
+		items []services.SyncItem
+	}{
+		{"added", changes.Added},
+		{"changed", changes.Changed},
+		{"removed upstream", changes.Removed},
+		{"conflict", changes.Conflicts},
+	} {
+		for _, item := range group.items {
+			fmt.Printf("  %s: %d %s\n", group.label, item.ID, item.Name)
+		}
+	}
+}
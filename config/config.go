@@ -13,16 +13,30 @@ type Config struct {
 	Server         Server
 	PaymentGateway PaymentGateway
 	MockAPI        MockAPI
+	Providers      []Provider
 	Security       Security
 	JWT            JWT
 	Zarinpal       Zarinpal
+	CacheWarmer    CacheWarmer
+	Cities         Cities
+	Alerts         Alerts
+	Mailer         Mailer
+	FareHistory    FareHistory
+	ReferenceSync  ReferenceSync
+	SMS            SMS
+	OTP            OTP
+	LoginLimiter   LoginLimiter
+	APIKeys        APIKeys
+	PasswordReset  PasswordReset
+	Verification   Verification
 }
 
 type Redis struct {
-	Host     string
-	Port     int
-	Password string
-	TTL      time.Duration
+	Host      string
+	Port      int
+	Password  string
+	TTL       time.Duration
+	DetailTTL time.Duration
 }
 
 type Database struct {
@@ -33,11 +47,23 @@ type Database struct {
 	Username string
 	Password string
 	Charset  string
+	// SSLMode is passed to PostgreSQL as is and defaults to disable.
+	SSLMode string
 }
 
 type Server struct {
 	Address string
 	Port    int
+	// TLSCertFile and TLSKeyFile enable HTTPS when both are set.
+	TLSCertFile string
+	TLSKeyFile  string
+	// ShutdownTimeout bounds how long in-flight requests may take to finish
+	// once a shutdown signal arrives.
+	ShutdownTimeout time.Duration
+	// TrustedProxies are the CIDR ranges of the proxies whose
+	// X-Forwarded-For header gives the client IP. Without any the IP of the
+	// connection is used.
+	TrustedProxies []string
 }
 
 type PaymentGateway struct {
@@ -51,6 +77,14 @@ type MockAPI struct {
 	Timeout time.Duration
 }
 
+// Provider is a flight provider queried by the aggregator. Providers speak
+// the mock API protocol; Timeout bounds how long a search waits for one.
+type Provider struct {
+	Name    string        `mapstructure:"name"`
+	URL     string        `mapstructure:"url"`
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
 type Security struct {
 	SecretKey           string
 	EncryptionAlgorithm string
@@ -61,9 +95,28 @@ type Params struct {
 	FileType string
 }
 
+// JWT configures access tokens. They are signed with the first of Keys, and
+// verified with any of them; without Keys they are signed with SecretKey
+// (HS256). When Keys are set, tokens without a kid signed with SecretKey are
+// only accepted with AcceptLegacyHS256, which is meant for the switch and
+// turned off once they expired.
 type JWT struct {
-	SecretKey string
-	ExpiresIn time.Duration
+	SecretKey         string
+	ExpiresIn         time.Duration
+	RefreshExpiresIn  time.Duration
+	Keys              []JWTKey
+	AcceptLegacyHS256 bool
+}
+
+// JWTKey is an RS256 or EdDSA key in PEM, inline or read from a file. Keys
+// only used for verification may have just the public key.
+type JWTKey struct {
+	ID             string `mapstructure:"id"`
+	Algorithm      string `mapstructure:"algorithm"`
+	PrivateKey     string `mapstructure:"private_key"`
+	PrivateKeyFile string `mapstructure:"private_key_file"`
+	PublicKey      string `mapstructure:"public_key"`
+	PublicKeyFile  string `mapstructure:"public_key_file"`
 }
 
 type Zarinpal struct {
@@ -72,6 +125,103 @@ type Zarinpal struct {
 	SandBox     bool
 }
 
+type CacheWarmer struct {
+	Enabled       bool
+	Interval      time.Duration
+	Days          int
+	TTL           time.Duration
+	PopularRoutes int
+	Routes        []Route
+}
+
+type Cities struct {
+	CacheTTL        time.Duration
+	DefaultTimezone string
+}
+
+type Alerts struct {
+	Enabled  bool
+	Interval time.Duration
+	Notifier string
+}
+
+type Mailer struct {
+	Driver   string
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+type SMS struct {
+	Driver string
+	// File is where the console driver also appends messages, if set.
+	File   string
+	URL    string
+	APIKey string
+	Sender string
+}
+
+type OTP struct {
+	Length         int
+	TTL            time.Duration
+	MaxAttempts    int
+	ResendInterval time.Duration
+	MaxSends       int
+	SendWindow     time.Duration
+}
+
+// LoginLimiter limits failed logins and registrations; see
+// services.LoginLimiter.
+type LoginLimiter struct {
+	Window             time.Duration
+	FreeFailures       int
+	BaseDelay          time.Duration
+	MaxDelay           time.Duration
+	MaxAccountFailures int
+	MaxIPFailures      int
+	LockoutDuration    time.Duration
+	MaxRegistrations   int
+}
+
+type APIKeys struct {
+	// DefaultRateLimit is the number of requests per minute of keys without
+	// their own limit.
+	DefaultRateLimit int
+	UsageRetention   time.Duration
+}
+
+type PasswordReset struct {
+	TTL time.Duration
+	URL string
+}
+
+type Verification struct {
+	EmailTTL time.Duration
+	URL      string
+	// Accounts verifying neither their email nor their cellphone within
+	// GracePeriod are deleted when CleanupEnabled is set.
+	GracePeriod     time.Duration
+	CleanupEnabled  bool
+	CleanupInterval time.Duration
+}
+
+type ReferenceSync struct {
+	Enabled  bool
+	Interval time.Duration
+}
+
+type FareHistory struct {
+	SnapshotInterval time.Duration
+	QueueSize        int
+}
+
+type Route struct {
+	DepartureCity string `mapstructure:"departure_city"`
+	ArrivalCity   string `mapstructure:"arrival_city"`
+}
+
 func Init(param Params) (*Config, error) {
 	viper.SetConfigType(param.FileType)
 	viper.AddConfigPath(param.FilePath)
@@ -83,10 +233,11 @@ func Init(param Params) (*Config, error) {
 	}
 
 	redis := &Redis{
-		Host:     viper.GetString("redis.host"),
-		Port:     viper.GetInt("redis.port"),
-		Password: viper.GetString("redis.password"),
-		TTL:      viper.GetDuration("redis.TTL"),
+		Host:      viper.GetString("redis.host"),
+		Port:      viper.GetInt("redis.port"),
+		Password:  viper.GetString("redis.password"),
+		TTL:       viper.GetDuration("redis.TTL"),
+		DetailTTL: viper.GetDuration("redis.detail_TTL"),
 	}
 	database := &Database{
 		Driver:   viper.GetString("database.driver"),
@@ -96,10 +247,15 @@ func Init(param Params) (*Config, error) {
 		Password: viper.GetString("database.password"),
 		Charset:  viper.GetString("database.chaset"),
 		DB:       viper.GetString("database.db"),
+		SSLMode:  viper.GetString("database.sslmode"),
 	}
 	server := &Server{
-		Address: viper.GetString("server.address"),
-		Port:    viper.GetInt("server.port"),
+		Address:         viper.GetString("server.address"),
+		Port:            viper.GetInt("server.port"),
+		TLSCertFile:     viper.GetString("server.tls_cert_file"),
+		TLSKeyFile:      viper.GetString("server.tls_key_file"),
+		ShutdownTimeout: viper.GetDuration("server.shutdown_timeout"),
+		TrustedProxies:  viper.GetStringSlice("server.trusted_proxies"),
 	}
 
 	paymentGateway := &PaymentGateway{
@@ -125,8 +281,13 @@ func Init(param Params) (*Config, error) {
 	}
 
 	jwt := &JWT{
-		SecretKey: viper.GetString("jwt.secret_key"),
-		ExpiresIn: expiresIn,
+		SecretKey:         viper.GetString("jwt.secret_key"),
+		ExpiresIn:         expiresIn,
+		RefreshExpiresIn:  viper.GetDuration("jwt.refresh_expires_in"),
+		AcceptLegacyHS256: viper.GetBool("jwt.accept_legacy_hs256"),
+	}
+	if err := viper.UnmarshalKey("jwt.keys", &jwt.Keys); err != nil {
+		return nil, fmt.Errorf("failed to parse jwt keys: %s", err)
 	}
 
 	zarinpal := &Zarinpal{
@@ -135,14 +296,122 @@ func Init(param Params) (*Config, error) {
 		SandBox:     viper.GetBool("zarinpal.sand_box"),
 	}
 
+	var providers []Provider
+	if err := viper.UnmarshalKey("providers", &providers); err != nil {
+		return nil, fmt.Errorf("failed to parse providers: %s", err)
+	}
+
+	var routes []Route
+	if err := viper.UnmarshalKey("cache_warmer.routes", &routes); err != nil {
+		return nil, fmt.Errorf("failed to parse cache warmer routes: %s", err)
+	}
+
+	cacheWarmer := &CacheWarmer{
+		Enabled:       viper.GetBool("cache_warmer.enabled"),
+		Interval:      viper.GetDuration("cache_warmer.interval"),
+		Days:          viper.GetInt("cache_warmer.days"),
+		TTL:           viper.GetDuration("cache_warmer.ttl"),
+		PopularRoutes: viper.GetInt("cache_warmer.popular_routes"),
+		Routes:        routes,
+	}
+
+	cities := &Cities{
+		CacheTTL:        viper.GetDuration("cities.cache_ttl"),
+		DefaultTimezone: viper.GetString("cities.default_timezone"),
+	}
+
+	alerts := &Alerts{
+		Enabled:  viper.GetBool("alerts.enabled"),
+		Interval: viper.GetDuration("alerts.interval"),
+		Notifier: viper.GetString("alerts.notifier"),
+	}
+
+	mailer := &Mailer{
+		Driver:   viper.GetString("mailer.driver"),
+		Host:     viper.GetString("mailer.host"),
+		Port:     viper.GetInt("mailer.port"),
+		Username: viper.GetString("mailer.username"),
+		Password: viper.GetString("mailer.password"),
+		From:     viper.GetString("mailer.from"),
+	}
+
+	sms := &SMS{
+		Driver: viper.GetString("sms.driver"),
+		File:   viper.GetString("sms.file"),
+		URL:    viper.GetString("sms.url"),
+		APIKey: viper.GetString("sms.api_key"),
+		Sender: viper.GetString("sms.sender"),
+	}
+
+	otp := &OTP{
+		Length:         viper.GetInt("otp.length"),
+		TTL:            viper.GetDuration("otp.ttl"),
+		MaxAttempts:    viper.GetInt("otp.max_attempts"),
+		ResendInterval: viper.GetDuration("otp.resend_interval"),
+		MaxSends:       viper.GetInt("otp.max_sends"),
+		SendWindow:     viper.GetDuration("otp.send_window"),
+	}
+
+	loginLimiter := &LoginLimiter{
+		Window:             viper.GetDuration("login_limiter.window"),
+		FreeFailures:       viper.GetInt("login_limiter.free_failures"),
+		BaseDelay:          viper.GetDuration("login_limiter.base_delay"),
+		MaxDelay:           viper.GetDuration("login_limiter.max_delay"),
+		MaxAccountFailures: viper.GetInt("login_limiter.max_account_failures"),
+		MaxIPFailures:      viper.GetInt("login_limiter.max_ip_failures"),
+		LockoutDuration:    viper.GetDuration("login_limiter.lockout_duration"),
+		MaxRegistrations:   viper.GetInt("login_limiter.max_registrations"),
+	}
+
+	apiKeys := &APIKeys{
+		DefaultRateLimit: viper.GetInt("api_keys.default_rate_limit"),
+		UsageRetention:   viper.GetDuration("api_keys.usage_retention"),
+	}
+
+	passwordReset := &PasswordReset{
+		TTL: viper.GetDuration("password_reset.ttl"),
+		URL: viper.GetString("password_reset.url"),
+	}
+
+	verification := &Verification{
+		EmailTTL:        viper.GetDuration("verification.email_ttl"),
+		URL:             viper.GetString("verification.url"),
+		GracePeriod:     viper.GetDuration("verification.grace_period"),
+		CleanupEnabled:  viper.GetBool("verification.cleanup_enabled"),
+		CleanupInterval: viper.GetDuration("verification.cleanup_interval"),
+	}
+
+	fareHistory := &FareHistory{
+		SnapshotInterval: viper.GetDuration("fare_history.snapshot_interval"),
+		QueueSize:        viper.GetInt("fare_history.queue_size"),
+	}
+
+	referenceSync := &ReferenceSync{
+		Enabled:  viper.GetBool("reference_sync.enabled"),
+		Interval: viper.GetDuration("reference_sync.interval"),
+	}
+
 	return &Config{
 		Redis:          *redis,
 		Database:       *database,
 		Server:         *server,
 		PaymentGateway: *paymentGateway,
 		MockAPI:        *mockAPI,
+		Providers:      providers,
 		Security:       *security,
 		JWT:            *jwt,
 		Zarinpal:       *zarinpal,
+		CacheWarmer:    *cacheWarmer,
+		Cities:         *cities,
+		Alerts:         *alerts,
+		Mailer:         *mailer,
+		FareHistory:    *fareHistory,
+		ReferenceSync:  *referenceSync,
+		SMS:            *sms,
+		OTP:            *otp,
+		LoginLimiter:   *loginLimiter,
+		APIKeys:        *apiKeys,
+		PasswordReset:  *passwordReset,
+		Verification:   *verification,
 	}, nil
 }
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"aliagha/config"
+	"fmt"
+	"strings"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// Driver returns the normalized driver name of dbConfig. MySQL is used when
+// no driver is configured.
+func Driver(dbConfig *config.Database) string {
+	switch driver := strings.ToLower(dbConfig.Driver); driver {
+	case "":
+		return DriverMySQL
+	case "postgresql":
+		return DriverPostgres
+	case "sqlite3":
+		return DriverSQLite
+	default:
+		return driver
+	}
+}
+
+func InitDB(dbConfig *config.Database) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	switch Driver(dbConfig) {
+	case DriverMySQL:
+		dialector = mysql.Open(MySQLDSN(dbConfig, dbConfig.DB))
+	case DriverPostgres:
+		dialector = postgres.Open(PostgresDSN(dbConfig, dbConfig.DB))
+	case DriverSQLite:
+		dialector = sqlite.Open(SQLiteDSN(dbConfig))
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", dbConfig.Driver)
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// MySQLDSN returns the data source name of the named MySQL database, or of the
+// server itself when name is empty.
+func MySQLDSN(dbConfig *config.Database, name string) string {
+	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true",
+		dbConfig.Username, dbConfig.Password, dbConfig.Host, dbConfig.Port, name)
+}
+
+func PostgresDSN(dbConfig *config.Database, name string) string {
+	sslMode := dbConfig.SSLMode
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		dbConfig.Host, dbConfig.Port, dbConfig.Username, dbConfig.Password, name, sslMode)
+}
+
+// SQLiteDSN returns the database file named by dbConfig.DB with foreign keys
+// enforced, which SQLite leaves off by default.
+func SQLiteDSN(dbConfig *config.Database) string {
+	separator := "?"
+	if strings.Contains(dbConfig.DB, "?") {
+		separator = "&"
+	}
+
+	return dbConfig.DB + separator + "_foreign_keys=on"
+}
@@ -18,7 +18,8 @@ func InitRedis(redisConfig *config.Redis) (*redis.Client, error) {
 
 	pong, err := client.Ping().Result()
 	if err != nil {
-		panic(fmt.Errorf("error connecting to Redis: %s", err))
+		client.Close()
+		return nil, fmt.Errorf("error connecting to Redis: %s", err)
 	}
 
 	fmt.Println("connected to Redis database: ", pong)
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"aliagha/migrations"
+	"io/fs"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// NewSQLiteMock returns an in-memory SQLite database with every migration
+// applied, for tests that need real queries.
+func NewSQLiteMock() *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:?_foreign_keys=on"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Every connection to :memory: opens its own database.
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	files, err := fs.Glob(migrations.FS, "sqlite/*.up.sql")
+	if err != nil {
+		log.Fatal(err)
+	}
+	sort.Slice(files, func(i, j int) bool { return migrationVersion(files[i]) < migrationVersion(files[j]) })
+
+	for _, file := range files {
+		query, err := fs.ReadFile(migrations.FS, file)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if _, err := sqlDB.Exec(string(query)); err != nil {
+			log.Fatalf("%s: %v", file, err)
+		}
+	}
+
+	return db
+}
+
+func migrationVersion(file string) int {
+	version, _ := strconv.Atoi(strings.SplitN(strings.TrimPrefix(file, "sqlite/"), "_", 2)[0])
+	return version
+}
@@ -1,15 +1,11 @@
 package e2e
 
 import (
-	"aliagha/services"
-	utl "aliagha/utils"
-	"bou.ke/monkey"
 	"encoding/json"
 	"github.com/stretchr/testify/assert"
 	"io/ioutil"
 	"net/http"
 	"os/exec"
-	"reflect"
 	"strings"
 	"syscall"
 	"testing"
@@ -49,7 +45,7 @@ func callHandler(method, path, body, token string) (*http.Response, error) {
 
 func TestApp(t *testing.T) {
 	cmd := exec.Command("./run.sh")
-	cmd.Dir = "/home/alireza/go/src/aliagha"
+	cmd.Dir = ".."
 	err := cmd.Start()
 	assert.NoError(t, err)
 	time.Sleep(2 * time.Second)
@@ -63,19 +59,19 @@ func TestApp(t *testing.T) {
 		assert.Equal(t, resp.StatusCode, http.StatusBadRequest, "Register reacts abnormally to invalid input")
 		t.Log("Register reacts normally to invalid input")
 
-		reqBody = `{"name": "ali", "cellphone": "1234567890", "email":"test@yahoo.com", "password":"1234567"}`
+		reqBody = `{"name": "ali", "cellphone": "09123456789", "email":"test@yahoo.com", "password":"1234567"}`
 		resp, err = callHandler("POST", reqPath, reqBody, "")
 		assert.NoError(t, err)
 		assert.Equal(t, resp.StatusCode, http.StatusCreated, "Register reacts abnormally to valid input")
 		t.Log("Register reacts normally to valid input")
 
-		reqBody = `{"name": "ali", "cellphone": "1234567890", "email":"test@yahoo.com", "password":"1234567"}`
+		reqBody = `{"name": "ali", "cellphone": "09123456789", "email":"test@yahoo.com", "password":"1234567"}`
 		resp, err = callHandler("POST", reqPath, reqBody, "")
 		assert.NoError(t, err)
-		assert.Equal(t, resp.StatusCode, http.StatusBadRequest, "Register reacts abnormally to repeated input")
+		assert.Equal(t, resp.StatusCode, http.StatusCreated, "Register reacts abnormally to repeated input")
 		t.Log("Register reacts normally to repeated input")
 
-		reqBody = `{"name": "ali", "cellphone": "0234567890", "email":"test2@yahoo.com", "password":"1234567"}`
+		reqBody = `{"name": "ali", "cellphone": "09123456780", "email":"test2@yahoo.com", "password":"1234567"}`
 		resp, err = callHandler("POST", reqPath, reqBody, "")
 		assert.NoError(t, err)
 		assert.Equal(t, resp.StatusCode, http.StatusCreated, "Register reacts abnormally to second valid input")
@@ -166,38 +162,12 @@ func TestApp(t *testing.T) {
 	})
 
 	t.Run("Requesting for flight info", func(t *testing.T) {
-		monkey.PatchInstanceMethod(reflect.TypeOf(&services.APIMockClient{}), "GetFlights", func(_ *services.APIMockClient, depCity string, arrCity string, date string) ([]services.FlightResponse, error) {
-			res := make([]services.FlightResponse, 0, 1)
-			depTime, _ := utl.ParseDate(date)
-			res = append(res, services.FlightResponse{
-				ID: 1,
-				DepCity: services.City{
-					ID:   1,
-					Name: depCity,
-				},
-				ArrCity: services.City{
-					ID:   2,
-					Name: arrCity,
-				},
-				DepTime: depTime,
-				ArrTime: depTime.Add(1 * time.Hour),
-				Airplane: services.Airplane{
-					ID:   1,
-					Name: "Boeing777",
-				},
-				Airline:        "Emirates",
-				Price:          1000,
-				CxlSitID:       1,
-				RemainingSeats: 10,
-			})
-			return res, nil
-		})
-		reqPath := "/flights?departure_city=Athens&arrival_city=London&date=2020-04-11"
+		date := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+		reqPath := "/flights?departure_city=Tehran&arrival_city=Mashhad&date=" + date
 		resp, err := callHandler("GET", reqPath, "", "")
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusOK, resp.StatusCode, "Get flight reacts abnormally to valid request")
 		t.Log("Get flight reacts normally to valid request for getting")
-		monkey.UnpatchAll()
 
 	})
 	err = cmd.Process.Signal(syscall.SIGINT)
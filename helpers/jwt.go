@@ -1,46 +1,80 @@
 package helpers
 
 import (
-	"aliagha/config"
-	"fmt"
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt"
 )
 
 type CustomClaims struct {
-	UserID    int32  `json:"user_id"`
-	Cellphone string `json:"cellphone"`
+	UserID      int32    `json:"user_id"`
+	Cellphone   string   `json:"cellphone"`
+	SessionID   string   `json:"sid,omitempty"`
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	// IssuedAtNano is the issue time at a finer resolution than iat, which is
+	// in whole seconds.
+	IssuedAtNano int64 `json:"iat_ns,omitempty"`
 	jwt.StandardClaims
 }
 
-func GenerateJwtToken(userID int32, cellphone string, jwtConfig *config.JWT) (string, error) {
+// GenerateJwtToken returns an access token for the user in the session. Roles
+// and permissions are read from the token until it expires, so changes to them
+// show up once the token is refreshed.
+func GenerateJwtToken(userID int32, cellphone, sessionID string, roles, permissions []string, keys *KeySet, expiresIn time.Duration) (string, error) {
+	// The jti claim identifies the token so it can be revoked before it expires.
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
 	claims := &CustomClaims{
-		UserID:    userID,
-		Cellphone: cellphone,
+		UserID:       userID,
+		Cellphone:    cellphone,
+		SessionID:    sessionID,
+		Roles:        roles,
+		Permissions:  permissions,
+		IssuedAtNano: now.UnixNano(),
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(jwtConfig.ExpiresIn).Unix(),
+			Id:        hex.EncodeToString(id),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(expiresIn).Unix(),
 			Issuer:    "Aliagha",
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(jwtConfig.SecretKey))
+	return keys.Sign(claims)
+}
+
+// ParseJWTClaims verifies the token and returns its claims.
+func ParseJWTClaims(tokenString string, keys *KeySet) (*CustomClaims, error) {
+	claims := &CustomClaims{}
+	if err := keys.Parse(tokenString, claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
 
-	return tokenString, err
+// IssueTime returns when the token was issued, at the finest resolution it
+// carries.
+func (c *CustomClaims) IssueTime() time.Time {
+	if c.IssuedAtNano != 0 {
+		return time.Unix(0, c.IssuedAtNano)
+	}
+
+	return time.Unix(c.IssuedAt, 0)
 }
 
-func ParseJWTToken(tokenString, secretKey string) (string, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("invalid token")
-		}
-		return []byte(secretKey), nil
-	})
-	if !token.Valid {
-		return "", fmt.Errorf("invalid token")
+func ParseJWTToken(tokenString string, keys *KeySet) (string, error) {
+	claims, err := ParseJWTClaims(tokenString, keys)
+	if err != nil {
+		return "", err
 	}
-	claims := token.Claims.(jwt.MapClaims)
-	userID := fmt.Sprintf("%d", (int)(claims["user_id"].(float64)))
-	return userID, err
+
+	return strconv.Itoa(int(claims.UserID)), nil
 }
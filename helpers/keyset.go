@@ -0,0 +1,232 @@
+package helpers
+
+import (
+	"aliagha/config"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+var errInvalidToken = errors.New("invalid token")
+
+type jwtKey struct {
+	id      string
+	method  jwt.SigningMethod
+	private crypto.PrivateKey
+	public  crypto.PublicKey
+}
+
+// KeySet signs access tokens with its current key and verifies them with any
+// of its keys, picked by the kid header. Without asymmetric keys it falls
+// back to HS256 with the secret. With them, tokens without a kid signed with
+// the secret are only accepted when legacy tokens are, so switching to
+// asymmetric keys does not log everyone out.
+type KeySet struct {
+	current *jwtKey
+	keys    map[string]*jwtKey
+	ordered []*jwtKey
+	secret  []byte
+	legacy  bool
+}
+
+// NewHMACKeySet returns a key set signing with the secret only.
+func NewHMACKeySet(secret string) *KeySet {
+	return &KeySet{keys: map[string]*jwtKey{}, secret: []byte(secret)}
+}
+
+// NewKeySet loads the keys of the config; the first one signs.
+func NewKeySet(cfg *config.JWT) (*KeySet, error) {
+	ks := NewHMACKeySet(cfg.SecretKey)
+	ks.legacy = cfg.AcceptLegacyHS256
+	for i, keyConfig := range cfg.Keys {
+		key, err := loadJWTKey(&keyConfig)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %q: %w", keyConfig.ID, err)
+		}
+
+		if _, ok := ks.keys[key.id]; ok {
+			return nil, fmt.Errorf("jwt key %q: duplicate id", key.id)
+		}
+
+		if i == 0 {
+			if key.private == nil {
+				return nil, fmt.Errorf("jwt key %q: the signing key needs a private key", key.id)
+			}
+
+			ks.current = key
+		}
+
+		ks.keys[key.id] = key
+		ks.ordered = append(ks.ordered, key)
+	}
+
+	if ks.current == nil && len(ks.secret) == 0 {
+		return nil, errors.New("jwt: neither keys nor a secret key are configured")
+	}
+
+	return ks, nil
+}
+
+func loadJWTKey(cfg *config.JWTKey) (*jwtKey, error) {
+	if cfg.ID == "" {
+		return nil, errors.New("missing id")
+	}
+
+	key := &jwtKey{id: cfg.ID}
+	switch cfg.Algorithm {
+	case AlgorithmRS256:
+		key.method = jwt.SigningMethodRS256
+	case AlgorithmEdDSA:
+		key.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q, expected %s or %s", cfg.Algorithm, AlgorithmRS256, AlgorithmEdDSA)
+	}
+
+	privatePEM, err := readPEM(cfg.PrivateKey, cfg.PrivateKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	publicPEM, err := readPEM(cfg.PublicKey, cfg.PublicKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if privatePEM != nil {
+		if key.method == jwt.SigningMethodRS256 {
+			private, err := jwt.ParseRSAPrivateKeyFromPEM(privatePEM)
+			if err != nil {
+				return nil, err
+			}
+
+			key.private, key.public = private, &private.PublicKey
+		} else {
+			private, err := jwt.ParseEdPrivateKeyFromPEM(privatePEM)
+			if err != nil {
+				return nil, err
+			}
+
+			key.private, key.public = private, private.(ed25519.PrivateKey).Public()
+		}
+	} else if publicPEM != nil {
+		if key.method == jwt.SigningMethodRS256 {
+			key.public, err = jwt.ParseRSAPublicKeyFromPEM(publicPEM)
+		} else {
+			key.public, err = jwt.ParseEdPublicKeyFromPEM(publicPEM)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, errors.New("missing private or public key")
+	}
+
+	return key, nil
+}
+
+func readPEM(inline, file string) ([]byte, error) {
+	if inline != "" {
+		return []byte(inline), nil
+	}
+
+	if file != "" {
+		return os.ReadFile(file)
+	}
+
+	return nil, nil
+}
+
+// Sign returns the token of the claims signed with the current key.
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if ks.current == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(ks.secret)
+	}
+
+	token := jwt.NewWithClaims(ks.current.method, claims)
+	token.Header["kid"] = ks.current.id
+	return token.SignedString(ks.current.private)
+}
+
+// Parse verifies the token into claims.
+func (ks *KeySet) Parse(tokenString string, claims jwt.Claims) error {
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok || len(ks.secret) == 0 {
+				return nil, errInvalidToken
+			}
+
+			if ks.current != nil && !ks.legacy {
+				return nil, errInvalidToken
+			}
+
+			return ks.secret, nil
+		}
+
+		key, ok := ks.keys[kid]
+		if !ok || token.Method.Alg() != key.method.Alg() {
+			return nil, errInvalidToken
+		}
+
+		return key.public, nil
+	})
+	if err != nil || !token.Valid {
+		return errInvalidToken
+	}
+
+	return nil
+}
+
+// JWK is a public key in the JSON Web Key format.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	// RSA keys
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519 keys
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of the set. The HS256 secret is never
+// published.
+func (ks *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	for _, key := range ks.ordered {
+		jwk := JWK{KeyID: key.id, Use: "sig", Algorithm: key.method.Alg()}
+		switch public := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks
+}
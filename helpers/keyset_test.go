@@ -0,0 +1,123 @@
+package helpers
+
+import (
+	"aliagha/config"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/require"
+)
+
+func privatePEM(t *testing.T, key interface{}) string {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+func publicPEM(t *testing.T, key interface{}) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	// The RSA key is read from a file, the Ed25519 key is inline.
+	rsaFile := filepath.Join(t.TempDir(), "rsa.pem")
+	require.NoError(t, os.WriteFile(rsaFile, []byte(privatePEM(t, rsaKey)), 0600))
+	rsaConfig := config.JWTKey{ID: "rsa-1", Algorithm: AlgorithmRS256, PrivateKeyFile: rsaFile}
+	edConfig := config.JWTKey{ID: "ed-2", Algorithm: AlgorithmEdDSA, PrivateKey: privatePEM(t, edPrivate)}
+
+	before, err := NewKeySet(&config.JWT{Keys: []config.JWTKey{rsaConfig}})
+	require.NoError(t, err)
+	oldToken, err := GenerateJwtToken(7, "09121234567", "", []string{"agent"}, nil, before, time.Hour)
+	require.NoError(t, err)
+
+	// The Ed25519 key takes over; the RSA key is kept to verify older tokens.
+	after, err := NewKeySet(&config.JWT{Keys: []config.JWTKey{edConfig, {ID: "rsa-1", Algorithm: AlgorithmRS256, PublicKey: publicPEM(t, &rsaKey.PublicKey)}}})
+	require.NoError(t, err)
+	newToken, err := GenerateJwtToken(7, "09121234567", "", nil, nil, after, time.Hour)
+	require.NoError(t, err)
+
+	parsed, _ := jwt.Parse(newToken, nil)
+	require.Equal(t, "ed-2", parsed.Header["kid"])
+	require.Equal(t, "EdDSA", parsed.Header["alg"])
+
+	claims, err := ParseJWTClaims(oldToken, after)
+	require.NoError(t, err)
+	require.Equal(t, int32(7), claims.UserID)
+	require.Equal(t, []string{"agent"}, claims.Roles)
+
+	_, err = ParseJWTClaims(newToken, after)
+	require.NoError(t, err)
+
+	// Once the RSA key is dropped its tokens are rejected.
+	dropped, err := NewKeySet(&config.JWT{Keys: []config.JWTKey{edConfig}})
+	require.NoError(t, err)
+	_, err = ParseJWTClaims(oldToken, dropped)
+	require.Error(t, err)
+
+	jwks := after.JWKS()
+	require.Len(t, jwks.Keys, 2)
+	require.Equal(t, JWK{KeyType: "OKP", KeyID: "ed-2", Use: "sig", Algorithm: "EdDSA", Curve: "Ed25519", X: jwt.EncodeSegment(edPublic)}, jwks.Keys[0])
+	require.Equal(t, "RSA", jwks.Keys[1].KeyType)
+	require.Equal(t, "AQAB", jwks.Keys[1].E)
+	require.Equal(t, jwt.EncodeSegment(rsaKey.PublicKey.N.Bytes()), jwks.Keys[1].N)
+}
+
+func TestKeySet_Secret(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaConfig := config.JWTKey{ID: "rsa-1", Algorithm: AlgorithmRS256, PrivateKey: privatePEM(t, rsaKey)}
+
+	legacy := NewHMACKeySet("secret")
+	legacyToken, err := GenerateJwtToken(7, "09121234567", "", nil, nil, legacy, time.Hour)
+	require.NoError(t, err)
+	require.Empty(t, legacy.JWKS().Keys)
+
+	// Tokens signed with the secret keep working after switching to keys
+	// while legacy tokens are accepted.
+	keys, err := NewKeySet(&config.JWT{SecretKey: "secret", Keys: []config.JWTKey{rsaConfig}, AcceptLegacyHS256: true})
+	require.NoError(t, err)
+	_, err = ParseJWTClaims(legacyToken, keys)
+	require.NoError(t, err)
+
+	// Otherwise the secret, such as the default one, cannot forge tokens.
+	switched, err := NewKeySet(&config.JWT{SecretKey: "secret", Keys: []config.JWTKey{rsaConfig}})
+	require.NoError(t, err)
+	_, err = ParseJWTClaims(legacyToken, switched)
+	require.Error(t, err)
+
+	withoutSecret, err := NewKeySet(&config.JWT{Keys: []config.JWTKey{rsaConfig}})
+	require.NoError(t, err)
+	_, err = ParseJWTClaims(legacyToken, withoutSecret)
+	require.Error(t, err)
+
+	// A token naming a key but signed with another algorithm is rejected, such
+	// as one signed with HS256 using the public key as the secret.
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, &CustomClaims{UserID: 1, StandardClaims: jwt.StandardClaims{ExpiresAt: time.Now().Add(time.Hour).Unix()}})
+	forged.Header["kid"] = "rsa-1"
+	forgedToken, err := forged.SignedString([]byte(publicPEM(t, &rsaKey.PublicKey)))
+	require.NoError(t, err)
+	_, err = ParseJWTClaims(forgedToken, keys)
+	require.Error(t, err)
+
+	_, err = NewKeySet(&config.JWT{})
+	require.Error(t, err)
+	_, err = NewKeySet(&config.JWT{Keys: []config.JWTKey{{ID: "rsa-1", Algorithm: AlgorithmRS256, PublicKey: publicPEM(t, &rsaKey.PublicKey)}}})
+	require.Error(t, err)
+	_, err = NewKeySet(&config.JWT{Keys: []config.JWTKey{{ID: "hs", Algorithm: "HS256", PrivateKey: "x"}}})
+	require.Error(t, err)
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"aliagha/models"
+	"aliagha/services"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// Admin serves the back-office endpoints; routes are guarded by
+// middleware.RequirePermission.
+type Admin struct {
+	DB        *gorm.DB
+	Validator *validator.Validate
+	Limiter   *services.LoginLimiter
+}
+
+type UnlockLoginRequest struct {
+	Email string `json:"email" validate:"required_without=IP,omitempty,email"`
+	IP    string `json:"ip" validate:"required_without=Email,omitempty,ip"`
+}
+
+// UnlockLogin lifts the login lockout of an account, an IP or both.
+func (a *Admin) UnlockLogin(ctx echo.Context) error {
+	var req UnlockLoginRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, "Bad Request")
+	}
+
+	if err := a.Validator.Struct(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	if req.Email != "" {
+		if err := a.Limiter.UnlockAccount(req.Email); err != nil {
+			return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+		}
+	}
+
+	if req.IP != "" {
+		if err := a.Limiter.UnlockIP(req.IP); err != nil {
+			return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+		}
+	}
+
+	return ctx.JSON(http.StatusOK, "Unlocked")
+}
+
+// GetUser returns the profile of a user.
+func (a *Admin) GetUser(ctx echo.Context) error {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, "Bad Request")
+	}
+
+	var user models.User
+	err = a.DB.Where("id = ? AND deleted_at IS NULL", id).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ctx.JSON(http.StatusNotFound, "User not found")
+	}
+
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	return ctx.JSON(http.StatusOK, newProfileResponse(&user))
+}
+
+type AdminTicketResponse struct {
+	ID        int32     `json:"id"`
+	FlightID  int32     `json:"flight_id"`
+	Provider  string    `json:"provider"`
+	Status    string    `json:"status"`
+	Price     int32     `json:"price"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// GetUserTickets lists the tickets of a user, the newest first.
+func (a *Admin) GetUserTickets(ctx echo.Context) error {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, "Bad Request")
+	}
+
+	var tickets []models.Ticket
+	if err := a.DB.Where("u_id = ?", id).Order("id DESC").Find(&tickets).Error; err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	response := make([]AdminTicketResponse, 0, len(tickets))
+	for _, ticket := range tickets {
+		response = append(response, AdminTicketResponse{
+			ID:        ticket.ID,
+			FlightID:  ticket.FID,
+			Provider:  ticket.Provider,
+			Status:    ticket.Status,
+			Price:     ticket.Price,
+			CreatedAt: ticket.CreatedAt,
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
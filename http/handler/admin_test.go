@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"aliagha/database"
+	"aliagha/models"
+	"aliagha/services"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdmin_UnlockLogin(t *testing.T) {
+	server, redis := database.NewRedisMock()
+	defer server.Close()
+
+	limiter := &services.LoginLimiter{Redis: redis, Window: time.Hour, MaxAccountFailures: 1, MaxIPFailures: 1, LockoutDuration: time.Hour}
+	admin := &Admin{Validator: validator.New(), Limiter: limiter}
+	e := echo.New()
+
+	call := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/admin/login/unlock", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		res := httptest.NewRecorder()
+		require.NoError(t, admin.UnlockLogin(e.NewContext(req, res)))
+		return res
+	}
+
+	require.Equal(t, http.StatusBadRequest, call(`{}`).Code)
+	require.Equal(t, http.StatusBadRequest, call(`{"ip":"not-an-ip"}`).Code)
+
+	require.NoError(t, limiter.Failure("user@example.com", "1.1.1.1"))
+	_, err := limiter.Check("user@example.com", "2.2.2.2")
+	require.ErrorIs(t, err, services.ErrTooManyAttempts)
+
+	require.Equal(t, http.StatusOK, call(`{"email":"user@example.com"}`).Code)
+	_, err = limiter.Check("user@example.com", "2.2.2.2")
+	require.NoError(t, err)
+	_, err = limiter.Check("other@example.com", "1.1.1.1")
+	require.ErrorIs(t, err, services.ErrTooManyAttempts)
+
+	require.Equal(t, http.StatusOK, call(`{"ip":"1.1.1.1"}`).Code)
+	_, err = limiter.Check("other@example.com", "1.1.1.1")
+	require.NoError(t, err)
+}
+
+func TestAdmin_GetUser(t *testing.T) {
+	db := database.NewSQLiteMock()
+	_, err := services.SeedFixtures(db, services.FixtureOptions{Seed: 1, Scale: services.FixtureScales["small"], Now: time.Date(2023, 6, 28, 10, 0, 0, 0, time.UTC)})
+	require.NoError(t, err)
+
+	admin := &Admin{DB: db}
+	e := echo.New()
+
+	call := func(handler echo.HandlerFunc, id string) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		ctx := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), res)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(id)
+		require.NoError(t, handler(ctx))
+		return res
+	}
+
+	var ticket models.Ticket
+	require.NoError(t, db.First(&ticket).Error)
+	id := strconv.Itoa(int(ticket.UID))
+
+	res := call(admin.GetUser, id)
+	require.Equal(t, http.StatusOK, res.Code)
+	var profile ProfileResponse
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &profile))
+	require.Equal(t, ticket.UID, profile.ID)
+	require.NotContains(t, res.Body.String(), "password")
+
+	res = call(admin.GetUserTickets, id)
+	require.Equal(t, http.StatusOK, res.Code)
+	var tickets []AdminTicketResponse
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &tickets))
+	require.NotEmpty(t, tickets)
+	for _, got := range tickets {
+		var stored models.Ticket
+		require.NoError(t, db.First(&stored, got.ID).Error)
+		require.Equal(t, ticket.UID, stored.UID)
+	}
+
+	require.Equal(t, http.StatusNotFound, call(admin.GetUser, "999999").Code)
+	require.Equal(t, http.StatusBadRequest, call(admin.GetUser, "abc").Code)
+	require.Equal(t, http.StatusBadRequest, call(admin.GetUserTickets, "abc").Code)
+}
@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"aliagha/models"
+	"aliagha/utils"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+type Alert struct {
+	DB        *gorm.DB
+	Validator *validator.Validate
+}
+
+type CreateAlertRequest struct {
+	DepartureCity string `json:"departure_city" validate:"required"`
+	ArrivalCity   string `json:"arrival_city" validate:"required"`
+	FlightDate    string `json:"date" validate:"required"`
+	MaxPrice      *int32 `json:"max_price" validate:"omitempty,min=1"`
+}
+
+type UpdateAlertRequest struct {
+	MaxPrice *int32 `json:"max_price" validate:"omitempty,min=0"`
+	Active   *bool  `json:"active"`
+}
+
+type AlertResponse struct {
+	ID             int32      `json:"id"`
+	DepartureCity  string     `json:"departure_city"`
+	ArrivalCity    string     `json:"arrival_city"`
+	FlightDate     string     `json:"date"`
+	MaxPrice       *int32     `json:"max_price"`
+	LastPrice      *int32     `json:"last_price"`
+	Active         bool       `json:"active"`
+	LastNotifiedAt *time.Time `json:"last_notified_at"`
+}
+
+type GetAlertsResponse struct {
+	Alerts []AlertResponse `json:"alerts"`
+}
+
+func newAlertResponse(alert models.Alert) AlertResponse {
+	return AlertResponse{
+		ID:             alert.ID,
+		DepartureCity:  alert.DepCity,
+		ArrivalCity:    alert.ArrCity,
+		FlightDate:     alert.FlightDate.Format("2006-01-02"),
+		MaxPrice:       alert.MaxPrice,
+		LastPrice:      alert.LastPrice,
+		Active:         alert.Active,
+		LastNotifiedAt: alert.LastNotifiedAt,
+	}
+}
+
+func (a *Alert) Create(ctx echo.Context) error {
+	UID, err := strconv.Atoi(ctx.Get("user_id").(string))
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	var req CreateAlertRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, "Bad Request")
+	}
+
+	if err := a.Validator.Struct(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	flightDate, err := utils.ParseDate(req.FlightDate)
+	if err != nil {
+		return ctx.JSON(http.StatusUnprocessableEntity, "Wrong date format")
+	}
+
+	if flightDate.Before(time.Now().Truncate(24 * time.Hour)) {
+		return ctx.JSON(http.StatusUnprocessableEntity, "Date is in the past")
+	}
+
+	alert := models.Alert{
+		UID:        int32(UID),
+		DepCity:    req.DepartureCity,
+		ArrCity:    req.ArrivalCity,
+		FlightDate: flightDate,
+		MaxPrice:   req.MaxPrice,
+		Active:     true,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+
+	if err := a.DB.Model(&models.Alert{}).Create(&alert).Error; err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Failed to create alert")
+	}
+
+	return ctx.JSON(http.StatusCreated, newAlertResponse(alert))
+}
+
+func (a *Alert) List(ctx echo.Context) error {
+	UID, err := strconv.Atoi(ctx.Get("user_id").(string))
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	var alerts []models.Alert
+	if err := a.DB.Model(&models.Alert{}).Where("u_id = ?", UID).Order("flight_date").Find(&alerts).Error; err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Failed to retrieve alerts")
+	}
+
+	resp := make([]AlertResponse, 0, len(alerts))
+	for _, alert := range alerts {
+		resp = append(resp, newAlertResponse(alert))
+	}
+
+	return ctx.JSON(http.StatusOK, GetAlertsResponse{
+		Alerts: resp,
+	})
+}
+
+func (a *Alert) Get(ctx echo.Context) error {
+	alert, status, err := a.findAlert(ctx)
+	if err != nil {
+		return ctx.JSON(status, err.Error())
+	}
+
+	return ctx.JSON(http.StatusOK, newAlertResponse(alert))
+}
+
+func (a *Alert) Update(ctx echo.Context) error {
+	alert, status, err := a.findAlert(ctx)
+	if err != nil {
+		return ctx.JSON(status, err.Error())
+	}
+
+	var req UpdateAlertRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, "Bad Request")
+	}
+
+	if err := a.Validator.Struct(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	updates := map[string]interface{}{}
+	if req.MaxPrice != nil {
+		// A zero threshold removes the price limit from the alert.
+		if *req.MaxPrice == 0 {
+			alert.MaxPrice = nil
+		} else {
+			alert.MaxPrice = req.MaxPrice
+		}
+		updates["max_price"] = alert.MaxPrice
+	}
+
+	if req.Active != nil {
+		alert.Active = *req.Active
+		updates["active"] = alert.Active
+	}
+
+	if len(updates) > 0 {
+		if err := a.DB.Model(&models.Alert{}).Where("id = ?", alert.ID).Updates(updates).Error; err != nil {
+			return ctx.JSON(http.StatusInternalServerError, "Failed to update alert")
+		}
+	}
+
+	return ctx.JSON(http.StatusOK, newAlertResponse(alert))
+}
+
+func (a *Alert) Delete(ctx echo.Context) error {
+	alert, status, err := a.findAlert(ctx)
+	if err != nil {
+		return ctx.JSON(status, err.Error())
+	}
+
+	if err := a.DB.Delete(&models.Alert{}, alert.ID).Error; err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Failed to delete alert")
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (a *Alert) findAlert(ctx echo.Context) (models.Alert, int, error) {
+	UID, err := strconv.Atoi(ctx.Get("user_id").(string))
+	if err != nil {
+		return models.Alert{}, http.StatusInternalServerError, errors.New("Internal Server Error")
+	}
+
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return models.Alert{}, http.StatusBadRequest, errors.New("Bad Request")
+	}
+
+	var alert models.Alert
+	err = a.DB.Model(&models.Alert{}).Where("id = ? AND u_id = ?", id, UID).First(&alert).Error
+	if err == gorm.ErrRecordNotFound {
+		return models.Alert{}, http.StatusNotFound, errors.New("Alert not found")
+	}
+
+	if err != nil {
+		return models.Alert{}, http.StatusInternalServerError, errors.New("Internal Server Error")
+	}
+
+	return alert, http.StatusOK, nil
+}
@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+type AlertTestSuite struct {
+	suite.Suite
+	alert   *Alert
+	sqlMock sqlmock.Sqlmock
+	e       *echo.Echo
+}
+
+func (suite *AlertTestSuite) SetupSuite() {
+	mockDB, sqlMock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      mockDB,
+		SkipInitializeWithVersion: true,
+	}))
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	suite.sqlMock = sqlMock
+	suite.e = echo.New()
+	suite.alert = &Alert{
+		DB:        db,
+		Validator: validator.New(),
+	}
+}
+
+func (suite *AlertTestSuite) CallHandler(method, id, requestBody string, handler echo.HandlerFunc) (*httptest.ResponseRecorder, error) {
+	req := httptest.NewRequest(method, "/alerts", strings.NewReader(requestBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	res := httptest.NewRecorder()
+	c := suite.e.NewContext(req, res)
+	c.Set("user_id", "1")
+	if id != "" {
+		c.SetParamNames("id")
+		c.SetParamValues(id)
+	}
+
+	err := handler(c)
+	if err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+func (suite *AlertTestSuite) TestCreateAlert_Success() {
+	require := suite.Require()
+	date := time.Now().AddDate(0, 0, 3).Format("2006-01-02")
+	expectedResponse := `{"id":5,"departure_city":"CityA","arrival_city":"CityB","date":"` + date + `","max_price":300,"last_price":null,"active":true,"last_notified_at":null}`
+
+	suite.sqlMock.ExpectBegin()
+	suite.sqlMock.ExpectExec("INSERT INTO `alerts`").
+		WithArgs(1, "CityA", "CityB", sqlmock.AnyArg(), 300, nil, nil, true, nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(5, 1))
+	suite.sqlMock.ExpectCommit()
+
+	res, err := suite.CallHandler(http.MethodPost, "", `{"departure_city":"CityA","arrival_city":"CityB","date":"`+date+`","max_price":300}`, suite.alert.Create)
+	require.NoError(err)
+	require.Equal(http.StatusCreated, res.Code)
+	require.JSONEq(expectedResponse, res.Body.String())
+	require.NoError(suite.sqlMock.ExpectationsWereMet())
+}
+
+func (suite *AlertTestSuite) TestCreateAlert_ValidationErr_Failure() {
+	require := suite.Require()
+
+	tests := []struct {
+		requestBody string
+		statusCode  int
+	}{
+		{`{"arrival_city":"CityB","date":"2030-01-01"}`, http.StatusBadRequest},
+		{`{"departure_city":"CityA","arrival_city":"CityB","date":"2030-01-01","max_price":-1}`, http.StatusBadRequest},
+		{`{"departure_city":"CityA","arrival_city":"CityB","date":"tomorrow"}`, http.StatusUnprocessableEntity},
+		{`{"departure_city":"CityA","arrival_city":"CityB","date":"2020-01-01"}`, http.StatusUnprocessableEntity},
+	}
+
+	for _, t := range tests {
+		res, err := suite.CallHandler(http.MethodPost, "", t.requestBody, suite.alert.Create)
+		require.NoError(err)
+		require.Equal(t.statusCode, res.Code, t.requestBody)
+	}
+}
+
+func (suite *AlertTestSuite) TestGetAlerts_Success() {
+	require := suite.Require()
+	expectedResponse := `{"alerts":[{"id":5,"departure_city":"CityA","arrival_city":"CityB","date":"2030-01-01","max_price":null,"last_price":250,"active":true,"last_notified_at":null}]}`
+
+	suite.sqlMock.ExpectQuery("^SELECT \\* FROM `alerts` WHERE u_id = \\? ORDER BY flight_date$").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "u_id", "dep_city", "arr_city", "flight_date", "max_price", "last_price", "active"}).
+			AddRow(5, 1, "CityA", "CityB", time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), nil, 250, true))
+
+	res, err := suite.CallHandler(http.MethodGet, "", "", suite.alert.List)
+	require.NoError(err)
+	require.Equal(http.StatusOK, res.Code)
+	require.JSONEq(expectedResponse, res.Body.String())
+}
+
+func (suite *AlertTestSuite) TestUpdateAlert_Success() {
+	require := suite.Require()
+	expectedResponse := `{"id":5,"departure_city":"CityA","arrival_city":"CityB","date":"2030-01-01","max_price":null,"last_price":null,"active":false,"last_notified_at":null}`
+
+	suite.sqlMock.ExpectQuery("^SELECT \\* FROM `alerts` WHERE id = \\? AND u_id = \\?").
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "u_id", "dep_city", "arr_city", "flight_date", "max_price", "active"}).
+			AddRow(5, 1, "CityA", "CityB", time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), 300, true))
+
+	suite.sqlMock.ExpectBegin()
+	suite.sqlMock.ExpectExec("UPDATE `alerts` SET").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.sqlMock.ExpectCommit()
+
+	res, err := suite.CallHandler(http.MethodPatch, "5", `{"max_price":0,"active":false}`, suite.alert.Update)
+	require.NoError(err)
+	require.Equal(http.StatusOK, res.Code)
+	require.JSONEq(expectedResponse, res.Body.String())
+	require.NoError(suite.sqlMock.ExpectationsWereMet())
+}
+
+func (suite *AlertTestSuite) TestDeleteAlert_NotFound_Failure() {
+	require := suite.Require()
+
+	suite.sqlMock.ExpectQuery("^SELECT \\* FROM `alerts` WHERE id = \\? AND u_id = \\?").
+		WithArgs(7, 1).
+		WillReturnError(gorm.ErrRecordNotFound)
+
+	res, err := suite.CallHandler(http.MethodDelete, "7", "", suite.alert.Delete)
+	require.NoError(err)
+	require.Equal(http.StatusNotFound, res.Code)
+	require.Equal(`"Alert not found"`, strings.TrimSpace(res.Body.String()))
+}
+
+func (suite *AlertTestSuite) TestDeleteAlert_Success() {
+	require := suite.Require()
+
+	suite.sqlMock.ExpectQuery("^SELECT \\* FROM `alerts` WHERE id = \\? AND u_id = \\?").
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "u_id"}).AddRow(5, 1))
+
+	suite.sqlMock.ExpectBegin()
+	suite.sqlMock.ExpectExec("DELETE FROM `alerts` WHERE `alerts`.`id` = \\?").
+		WithArgs(5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.sqlMock.ExpectCommit()
+
+	res, err := suite.CallHandler(http.MethodDelete, "5", "", suite.alert.Delete)
+	require.NoError(err)
+	require.Equal(http.StatusNoContent, res.Code)
+	require.NoError(suite.sqlMock.ExpectationsWereMet())
+}
+
+func TestAlert(t *testing.T) {
+	suite.Run(t, new(AlertTestSuite))
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"aliagha/services"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+type City struct {
+	Validator *validator.Validate
+	APIMock   services.APIMockClient
+	CacheTTL  time.Duration
+
+	mu       sync.Mutex
+	index    *services.CityIndex
+	loadedAt time.Time
+}
+
+type SuggestCitiesRequest struct {
+	Query string `query:"q" validate:"required"`
+	Limit int    `query:"limit" validate:"omitempty,min=1,max=50"`
+}
+
+type SuggestCitiesResponse struct {
+	Cities []services.CitySuggestion `json:"cities"`
+}
+
+func (c *City) Suggest(ctx echo.Context) error {
+	var req SuggestCitiesRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, "Bad Request")
+	}
+
+	if err := c.Validator.Struct(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	if req.Limit == 0 {
+		req.Limit = 10
+	}
+
+	index, err := c.cityIndex()
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	return ctx.JSON(http.StatusOK, SuggestCitiesResponse{
+		Cities: index.Suggest(req.Query, req.Limit),
+	})
+}
+
+// cityIndex returns the locally cached index and rebuilds it from the airline
+// API once it is older than CacheTTL. A stale index is served if the refresh fails.
+func (c *City) cityIndex() (*services.CityIndex, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.index != nil && time.Since(c.loadedAt) < c.CacheTTL {
+		return c.index, nil
+	}
+
+	cities, err := c.APIMock.GetCities()
+	if err != nil {
+		if c.index != nil {
+			return c.index, nil
+		}
+		return nil, err
+	}
+
+	c.index = services.NewCityIndex(cities)
+	c.loadedAt = time.Now()
+
+	return c.index, nil
+}
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"aliagha/services"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"bou.ke/monkey"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/suite"
+)
+
+type CityTestSuite struct {
+	suite.Suite
+	cities []services.GetCityResponse
+	e      *echo.Echo
+}
+
+func (suite *CityTestSuite) SetupSuite() {
+	suite.e = echo.New()
+	suite.cities = []services.GetCityResponse{
+		{ID: 1, Name: "تهران"},
+		{ID: 2, Name: "مشهد"},
+		{ID: 3, Name: "كرمان"},
+		{ID: 4, Name: "کرمانشاه"},
+		{ID: 5, Name: "کیش"},
+		{ID: 6, Name: "Shiraz"},
+	}
+}
+
+func (suite *CityTestSuite) CallHandler(city *City, query string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/cities/suggest?q="+url.QueryEscape(query), nil)
+	res := httptest.NewRecorder()
+	c := suite.e.NewContext(req, res)
+
+	suite.Require().NoError(city.Suggest(c))
+
+	return res
+}
+
+func (suite *CityTestSuite) suggestedIDs(res *httptest.ResponseRecorder) []int32 {
+	var response SuggestCitiesResponse
+	suite.Require().NoError(json.Unmarshal(res.Body.Bytes(), &response))
+
+	ids := make([]int32, 0, len(response.Cities))
+	for _, city := range response.Cities {
+		ids = append(ids, city.ID)
+	}
+
+	return ids
+}
+
+func (suite *CityTestSuite) TestSuggest_Success() {
+	require := suite.Require()
+
+	var a services.APIMockClient
+	monkey.PatchInstanceMethod(reflect.TypeOf(&a), "GetCities", func(_ *services.APIMockClient) ([]services.GetCityResponse, error) {
+		return suite.cities, nil
+	})
+	defer monkey.UnpatchInstanceMethod(reflect.TypeOf(&a), "GetCities")
+
+	tests := []struct {
+		query string
+		ids   []int32
+	}{
+		{"تهران", []int32{1}},
+		{"کرمان", []int32{3, 4}},
+		{"كرمانشاه", []int32{4}},
+		{"teh", []int32{1}},
+		{"Tehrn", []int32{1}},
+		{"IKA", []int32{1}},
+		{"mhd", []int32{2}},
+		{"mashad", []int32{2}},
+		{"kermanshah", []int32{4}},
+		{"shir", []int32{6}},
+		{"xyz", []int32{}},
+	}
+
+	city := &City{Validator: validator.New(), CacheTTL: time.Hour}
+	for _, t := range tests {
+		res := suite.CallHandler(city, t.query)
+		require.Equal(http.StatusOK, res.Code)
+		require.Equal(t.ids, suite.suggestedIDs(res), t.query)
+	}
+}
+
+func (suite *CityTestSuite) TestSuggest_UsesLocalCache_Success() {
+	require := suite.Require()
+
+	calls := 0
+	var a services.APIMockClient
+	monkey.PatchInstanceMethod(reflect.TypeOf(&a), "GetCities", func(_ *services.APIMockClient) ([]services.GetCityResponse, error) {
+		calls++
+		return suite.cities, nil
+	})
+	defer monkey.UnpatchInstanceMethod(reflect.TypeOf(&a), "GetCities")
+
+	city := &City{Validator: validator.New(), CacheTTL: time.Hour}
+	suite.CallHandler(city, "tehran")
+	suite.CallHandler(city, "mashhad")
+
+	require.Equal(1, calls)
+}
+
+func (suite *CityTestSuite) TestSuggest_ValidationErr_Failure() {
+	require := suite.Require()
+
+	city := &City{Validator: validator.New(), CacheTTL: time.Hour}
+	res := suite.CallHandler(city, "")
+
+	require.Equal(http.StatusBadRequest, res.Code)
+}
+
+func (suite *CityTestSuite) TestSuggest_APIMockErr_Failure() {
+	require := suite.Require()
+
+	var a services.APIMockClient
+	monkey.PatchInstanceMethod(reflect.TypeOf(&a), "GetCities", func(_ *services.APIMockClient) ([]services.GetCityResponse, error) {
+		return nil, errors.New("error")
+	})
+	defer monkey.UnpatchInstanceMethod(reflect.TypeOf(&a), "GetCities")
+
+	city := &City{Validator: validator.New(), CacheTTL: time.Hour}
+	res := suite.CallHandler(city, "tehran")
+
+	require.Equal(http.StatusInternalServerError, res.Code)
+}
+
+func TestCity(t *testing.T) {
+	suite.Run(t, new(CityTestSuite))
+}
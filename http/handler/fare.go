@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"aliagha/models"
+	"aliagha/services"
+	"aliagha/utils"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+const maxPriceHistoryPoints = 500
+
+type Fare struct {
+	DB        *gorm.DB
+	Validator *validator.Validate
+	Provider  services.FlightProvider
+}
+
+type PricePointResponse struct {
+	Price          int32     `json:"price"`
+	RemainingSeats int32     `json:"remaining_seats"`
+	CapturedAt     time.Time `json:"captured_at"`
+}
+
+type PriceHistoryResponse struct {
+	FlightID     int32                `json:"flight_id"`
+	Provider     string               `json:"provider,omitempty"`
+	CurrentPrice int32                `json:"current_price"`
+	LowestPrice  int32                `json:"lowest_price"`
+	HighestPrice int32                `json:"highest_price"`
+	History      []PricePointResponse `json:"history"`
+}
+
+func (f *Fare) PriceHistory(ctx echo.Context) error {
+	flightID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, "Bad Request")
+	}
+
+	// The provider comes from the search results, as flight ids are only
+	// unique within a provider.
+	provider, err := services.ProviderName(f.Provider, ctx.QueryParam("provider"))
+	if errors.Is(err, services.ErrProviderRequired) {
+		return ctx.JSON(http.StatusBadRequest, "Provider is required")
+	}
+
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, "Unknown provider")
+	}
+
+	var snapshots []models.FareSnapshot
+	err = f.DB.Model(&models.FareSnapshot{}).
+		Where("provider = ? AND flight_id = ?", provider, flightID).
+		Order("captured_at DESC").
+		Limit(maxPriceHistoryPoints).
+		Find(&snapshots).
+		Error
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Failed to retrieve price history")
+	}
+
+	if len(snapshots) == 0 {
+		return ctx.JSON(http.StatusNotFound, "No price history for this flight")
+	}
+
+	resp := PriceHistoryResponse{
+		FlightID:     int32(flightID),
+		Provider:     provider,
+		CurrentPrice: snapshots[0].Price,
+		LowestPrice:  snapshots[0].Price,
+		HighestPrice: snapshots[0].Price,
+		History:      make([]PricePointResponse, 0, len(snapshots)),
+	}
+
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		snapshot := snapshots[i]
+		if snapshot.Price < resp.LowestPrice {
+			resp.LowestPrice = snapshot.Price
+		}
+		if snapshot.Price > resp.HighestPrice {
+			resp.HighestPrice = snapshot.Price
+		}
+
+		resp.History = append(resp.History, PricePointResponse{
+			Price:          snapshot.Price,
+			RemainingSeats: snapshot.RemainingSeats,
+			CapturedAt:     snapshot.CapturedAt,
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+type PriceTrendRequest struct {
+	DepartureCity string `query:"departure_city" validate:"required"`
+	ArrivalCity   string `query:"arrival_city" validate:"required"`
+	FlightDate    string `query:"date" validate:"required"`
+}
+
+type DailyPriceResponse struct {
+	Date         string `json:"date"`
+	LowestPrice  int32  `json:"lowest_price"`
+	AveragePrice int32  `json:"average_price"`
+	HighestPrice int32  `json:"highest_price"`
+}
+
+type PriceTrendResponse struct {
+	DepartureCity string               `json:"departure_city"`
+	ArrivalCity   string               `json:"arrival_city"`
+	FlightDate    string               `json:"date"`
+	CurrentLowest int32                `json:"current_lowest_price"`
+	LowestEver    int32                `json:"lowest_price"`
+	Trend         string               `json:"trend"`
+	Advice        string               `json:"advice"`
+	Days          []DailyPriceResponse `json:"days"`
+}
+
+func (f *Fare) PriceTrend(ctx echo.Context) error {
+	var req PriceTrendRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, "Bad Request")
+	}
+
+	if err := f.Validator.Struct(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	flightDate, err := utils.ParseDate(req.FlightDate)
+	if err != nil {
+		return ctx.JSON(http.StatusUnprocessableEntity, "Wrong date format")
+	}
+
+	var snapshots []models.FareSnapshot
+	err = f.DB.Model(&models.FareSnapshot{}).
+		Where("dep_city = ? AND arr_city = ? AND flight_date = ?", req.DepartureCity, req.ArrivalCity, flightDate.Format("2006-01-02")).
+		Where("remaining_seats > 0").
+		Order("captured_at").
+		Find(&snapshots).
+		Error
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Failed to retrieve price trend")
+	}
+
+	if len(snapshots) == 0 {
+		return ctx.JSON(http.StatusNotFound, "No price history for this route")
+	}
+
+	days := dailyPrices(snapshots)
+	resp := PriceTrendResponse{
+		DepartureCity: req.DepartureCity,
+		ArrivalCity:   req.ArrivalCity,
+		FlightDate:    flightDate.Format("2006-01-02"),
+		CurrentLowest: days[len(days)-1].LowestPrice,
+		LowestEver:    days[0].LowestPrice,
+		Days:          days,
+	}
+
+	for _, day := range days {
+		if day.LowestPrice < resp.LowestEver {
+			resp.LowestEver = day.LowestPrice
+		}
+	}
+
+	resp.Trend = priceTrend(days[0].LowestPrice, resp.CurrentLowest)
+	resp.Advice = priceAdvice(resp.CurrentLowest, resp.LowestEver, resp.Trend)
+
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+// dailyPrices groups snapshots, ordered by capture time, into one entry per day.
+func dailyPrices(snapshots []models.FareSnapshot) []DailyPriceResponse {
+	days := make([]DailyPriceResponse, 0)
+	var total, count int64
+	for _, snapshot := range snapshots {
+		date := snapshot.CapturedAt.Format("2006-01-02")
+		if len(days) == 0 || days[len(days)-1].Date != date {
+			days = append(days, DailyPriceResponse{Date: date, LowestPrice: snapshot.Price, HighestPrice: snapshot.Price})
+			total, count = 0, 0
+		}
+
+		day := &days[len(days)-1]
+		if snapshot.Price < day.LowestPrice {
+			day.LowestPrice = snapshot.Price
+		}
+		if snapshot.Price > day.HighestPrice {
+			day.HighestPrice = snapshot.Price
+		}
+
+		total += int64(snapshot.Price)
+		count++
+		day.AveragePrice = int32(total / count)
+	}
+
+	return days
+}
+
+// priceTrend compares the lowest fare of the first and the last tracked day;
+// changes within 5% are considered stable.
+func priceTrend(first, current int32) string {
+	if first <= 0 {
+		return "stable"
+	}
+
+	change := float64(current-first) / float64(first)
+	switch {
+	case change > 0.05:
+		return "rising"
+	case change < -0.05:
+		return "falling"
+	default:
+		return "stable"
+	}
+}
+
+// priceAdvice suggests waiting while fares are falling and buying when they
+// are rising or already close to the lowest fare seen for the route.
+func priceAdvice(current, lowest int32, trend string) string {
+	if trend == "falling" {
+		return "wait"
+	}
+
+	if trend == "rising" || float64(current) <= float64(lowest)*1.05 {
+		return "buy_now"
+	}
+
+	return "no_clear_signal"
+}
@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"aliagha/services"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+type FareTestSuite struct {
+	suite.Suite
+	fare    *Fare
+	sqlMock sqlmock.Sqlmock
+	e       *echo.Echo
+}
+
+func (suite *FareTestSuite) SetupSuite() {
+	mockDB, sqlMock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      mockDB,
+		SkipInitializeWithVersion: true,
+	}))
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	suite.sqlMock = sqlMock
+	suite.e = echo.New()
+	suite.fare = &Fare{
+		DB:        db,
+		Validator: validator.New(),
+		Provider:  services.NewAggregator(services.NamedProvider{Name: "alpha"}, services.NamedProvider{Name: "beta"}),
+	}
+}
+
+func (suite *FareTestSuite) TestPriceHistory_Success() {
+	require := suite.Require()
+	expectedResponse := `{"flight_id":1,"provider":"beta","current_price":220,"lowest_price":180,"highest_price":250,"history":[
+		{"price":250,"remaining_seats":10,"captured_at":"2023-06-20T10:00:00Z"},
+		{"price":180,"remaining_seats":8,"captured_at":"2023-06-21T10:00:00Z"},
+		{"price":220,"remaining_seats":3,"captured_at":"2023-06-22T10:00:00Z"}]}`
+
+	suite.sqlMock.ExpectQuery("^SELECT \\* FROM `fare_snapshots` WHERE provider = \\? AND flight_id = \\? ORDER BY captured_at DESC LIMIT 500$").
+		WithArgs("beta", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "flight_id", "price", "remaining_seats", "captured_at"}).
+			AddRow(3, 1, 220, 3, time.Date(2023, 6, 22, 10, 0, 0, 0, time.UTC)).
+			AddRow(2, 1, 180, 8, time.Date(2023, 6, 21, 10, 0, 0, 0, time.UTC)).
+			AddRow(1, 1, 250, 10, time.Date(2023, 6, 20, 10, 0, 0, 0, time.UTC)))
+
+	req := httptest.NewRequest(http.MethodGet, "/flights/1/price-history?provider=beta", nil)
+	res := httptest.NewRecorder()
+	c := suite.e.NewContext(req, res)
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+
+	require.NoError(suite.fare.PriceHistory(c))
+	require.Equal(http.StatusOK, res.Code)
+	require.JSONEq(expectedResponse, res.Body.String())
+}
+
+func (suite *FareTestSuite) TestPriceHistory_NotFound_Failure() {
+	require := suite.Require()
+
+	suite.sqlMock.ExpectQuery("^SELECT \\* FROM `fare_snapshots` WHERE provider = \\? AND flight_id = \\?").
+		WithArgs("alpha", 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	req := httptest.NewRequest(http.MethodGet, "/flights/2/price-history?provider=alpha", nil)
+	res := httptest.NewRecorder()
+	c := suite.e.NewContext(req, res)
+	c.SetParamNames("id")
+	c.SetParamValues("2")
+
+	require.NoError(suite.fare.PriceHistory(c))
+	require.Equal(http.StatusNotFound, res.Code)
+}
+
+func (suite *FareTestSuite) TestPriceHistory_Provider_Failure() {
+	require := suite.Require()
+
+	for _, url := range []string{"/flights/1/price-history", "/flights/1/price-history?provider=gamma"} {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		res := httptest.NewRecorder()
+		c := suite.e.NewContext(req, res)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		require.NoError(suite.fare.PriceHistory(c))
+		require.Equal(http.StatusBadRequest, res.Code)
+	}
+	require.NoError(suite.sqlMock.ExpectationsWereMet())
+}
+
+func (suite *FareTestSuite) TestPriceTrend_Success() {
+	require := suite.Require()
+	expectedResponse := `{"departure_city":"CityA","arrival_city":"CityB","date":"2023-06-28","current_lowest_price":150,"lowest_price":150,"trend":"falling","advice":"wait","days":[
+		{"date":"2023-06-20","lowest_price":200,"average_price":225,"highest_price":250},
+		{"date":"2023-06-21","lowest_price":150,"average_price":175,"highest_price":200}]}`
+
+	suite.sqlMock.ExpectQuery("^SELECT \\* FROM `fare_snapshots` WHERE \\(dep_city = \\? AND arr_city = \\? AND flight_date = \\?\\) AND remaining_seats > 0 ORDER BY captured_at$").
+		WithArgs("CityA", "CityB", "2023-06-28").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "flight_id", "price", "remaining_seats", "captured_at"}).
+			AddRow(1, 1, 250, 10, time.Date(2023, 6, 20, 9, 0, 0, 0, time.UTC)).
+			AddRow(2, 2, 200, 10, time.Date(2023, 6, 20, 9, 0, 0, 0, time.UTC)).
+			AddRow(3, 1, 200, 5, time.Date(2023, 6, 21, 9, 0, 0, 0, time.UTC)).
+			AddRow(4, 2, 150, 5, time.Date(2023, 6, 21, 9, 0, 0, 0, time.UTC)))
+
+	req := httptest.NewRequest(http.MethodGet, "/flights/price-trend?departure_city=CityA&arrival_city=CityB&date=2023-06-28", nil)
+	res := httptest.NewRecorder()
+	c := suite.e.NewContext(req, res)
+
+	require.NoError(suite.fare.PriceTrend(c))
+	require.Equal(http.StatusOK, res.Code)
+	require.JSONEq(expectedResponse, res.Body.String())
+}
+
+func (suite *FareTestSuite) TestPriceTrend_ValidationErr_Failure() {
+	require := suite.Require()
+
+	req := httptest.NewRequest(http.MethodGet, "/flights/price-trend?departure_city=CityA&date=2023-06-28", nil)
+	res := httptest.NewRecorder()
+	c := suite.e.NewContext(req, res)
+
+	require.NoError(suite.fare.PriceTrend(c))
+	require.Equal(http.StatusBadRequest, res.Code)
+	require.True(strings.Contains(res.Body.String(), "ArrivalCity"))
+}
+
+func TestFare(t *testing.T) {
+	suite.Run(t, new(FareTestSuite))
+}
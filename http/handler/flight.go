@@ -5,42 +5,70 @@ import (
 	"aliagha/services"
 	"aliagha/utils"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/go-redis/redis"
 	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
 )
 
 type Flight struct {
-	Redis     *redis.Client
-	Validator *validator.Validate
-	Config    *config.Config
-	APIMock   services.APIMockClient
+	DB           *gorm.DB
+	Redis        *redis.Client
+	Validator    *validator.Validate
+	Config       *config.Config
+	Provider     services.FlightProvider
+	FareRecorder *services.FareRecorder
+	Timezones    *services.CityTimezones
+	Airports     *services.AirportDirectory
 }
 
 type GetFlightsRequest struct {
-	DepartureCity  string `query:"departure_city" validate:"required"`
-	ArrivalCity    string `query:"arrival_city" validate:"required"`
-	FlightDate     string `query:"date" validate:"required"`
-	Airline        string `query:"airline"`
-	AirplaneName   string `query:"airplane_name"`
-	DeptimeFrom    string `query:"departure_time_from"`
-	DepTimeF       time.Time
-	DeptimeTo      string `query:"departure_time_to"`
-	DeptimeT       time.Time
-	SortBy         string `query:"sort_by"`
-	SortOrder      string `query:"sort_order"`
-	RemainingSeats int32  `query:"remaining_seats"`
+	DepartureCity    string `query:"departure_city" validate:"required_without=DepartureAirport"`
+	ArrivalCity      string `query:"arrival_city" validate:"required_without=ArrivalAirport"`
+	DepartureAirport string `query:"departure_airport" validate:"omitempty,alpha,min=3,max=4"`
+	ArrivalAirport   string `query:"arrival_airport" validate:"omitempty,alpha,min=3,max=4"`
+	FlightDate       string `query:"date" validate:"required"`
+	Calendar         string `query:"calendar" validate:"omitempty,oneof=gregorian jalali"`
+	Airline          string `query:"airline"`
+	AirplaneName     string `query:"airplane_name"`
+	DeptimeFrom      string `query:"departure_time_from"`
+	DepTimeF         time.Time
+	DeptimeTo        string `query:"departure_time_to"`
+	DeptimeT         time.Time
+	SortBy           string `query:"sort_by"`
+	SortOrder        string `query:"sort_order"`
+	RemainingSeats   int32  `query:"remaining_seats"`
 }
 
-func (req *GetFlightsRequest) Normalize() error {
+// FlightResult is a flight as returned by the search, with its departure and
+// arrival times also given in the local time of the respective city.
+type FlightResult struct {
+	services.FlightResponse
+	DepTimeLocal  time.Time `json:"dep_time_local"`
+	ArrTimeLocal  time.Time `json:"arr_time_local"`
+	DepTimeJalali string    `json:"dep_time_jalali,omitempty"`
+	ArrTimeJalali string    `json:"arr_time_jalali,omitempty"`
+}
+
+// Normalize rewrites the flight date as a Gregorian date and parses the
+// departure window as wall clock times in loc, the zone of the departure city.
+func (req *GetFlightsRequest) Normalize(loc *time.Location) error {
+	flightDate, err := utils.ParseDateIn(req.FlightDate, utils.Calendar(req.Calendar))
+	if err != nil {
+		return err
+	}
+
+	req.FlightDate = flightDate.Format("2006-01-02")
 
 	if req.DeptimeFrom != "" {
-		date, err := utils.ParseTime(req.FlightDate, req.DeptimeFrom)
+		date, err := utils.ParseTimeIn(req.FlightDate, req.DeptimeFrom, loc)
 		if err != nil {
 			return err
 		}
@@ -49,7 +77,7 @@ func (req *GetFlightsRequest) Normalize() error {
 	}
 
 	if req.DeptimeTo != "" {
-		date, err := utils.ParseTime(req.FlightDate, req.DeptimeTo)
+		date, err := utils.ParseTimeIn(req.FlightDate, req.DeptimeTo, loc)
 		if err != nil {
 			return err
 		}
@@ -71,18 +99,26 @@ func (f *Flight) Get(ctx echo.Context) error {
 		return ctx.JSON(http.StatusBadRequest, err.Error())
 	}
 
-	if err := req.Normalize(); err != nil {
+	if err := f.resolveAirportCities(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	if err := req.Normalize(f.Timezones.Location(req.DepartureCity)); err != nil {
 		return ctx.JSON(http.StatusBadRequest, err.Error())
 	}
 
-	cacheKey := fmt.Sprintf("flights-%s-%s-%s", req.DepartureCity, req.ArrivalCity, req.FlightDate)
+	if err := services.TrackRouteSearch(f.Redis, req.DepartureCity, req.ArrivalCity); err != nil {
+		fmt.Println(err)
+	}
+
+	cacheKey := services.FlightsCacheKey(req.DepartureCity, req.ArrivalCity, req.FlightDate)
 	cacheResult, err := f.Redis.Get(cacheKey).Bytes()
 
 	var flights []services.FlightResponse
 	if err != nil && err != redis.Nil {
 		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
 	} else if err == redis.Nil {
-		apiResult, err := f.APIMock.GetFlights(req.DepartureCity, req.ArrivalCity, req.FlightDate)
+		apiResult, err := f.Provider.GetFlights(req.DepartureCity, req.ArrivalCity, req.FlightDate)
 		if err != nil {
 			return ctx.JSON(http.StatusInternalServerError, "Internal Sever Error")
 		}
@@ -97,6 +133,10 @@ func (f *Flight) Get(ctx echo.Context) error {
 			return ctx.JSON(http.StatusInternalServerError, "Internal Sever Error")
 		}
 
+		if f.FareRecorder != nil {
+			f.FareRecorder.Record(req.DepartureCity, req.ArrivalCity, req.FlightDate, apiResult)
+		}
+
 		flights = apiResult
 	} else {
 		err = json.Unmarshal(cacheResult, &flights)
@@ -105,6 +145,12 @@ func (f *Flight) Get(ctx echo.Context) error {
 		}
 	}
 
+	flights = f.resolveAirports(flights)
+
+	if req.DepartureAirport != "" || req.ArrivalAirport != "" {
+		flights = filterByAirports(flights, req.DepartureAirport, req.ArrivalAirport)
+	}
+
 	if req.Airline != "" {
 		flights = filterByAirline(flights, req.Airline)
 	}
@@ -125,7 +171,73 @@ func (f *Flight) Get(ctx echo.Context) error {
 		flights = sortFlight(flights, req.SortBy, req.SortOrder)
 	}
 
-	return ctx.JSON(http.StatusOK, flights)
+	jalali := utils.PrefersJalali(ctx.Request().Header.Get("Accept-Language"))
+	return ctx.JSON(http.StatusOK, f.localize(flights, jalali))
+}
+
+func (f *Flight) localize(flights []services.FlightResponse, jalali bool) []FlightResult {
+	results := make([]FlightResult, 0, len(flights))
+	for _, flight := range flights {
+		flight.DepTime = flight.DepTime.UTC()
+		flight.ArrTime = flight.ArrTime.UTC()
+		result := FlightResult{
+			FlightResponse: flight,
+			DepTimeLocal:   flight.DepTime.In(f.Timezones.Location(flight.DepCity.Name)),
+			ArrTimeLocal:   flight.ArrTime.In(f.Timezones.Location(flight.ArrCity.Name)),
+		}
+
+		if jalali {
+			result.DepTimeJalali = utils.FormatJalaliDateTime(result.DepTimeLocal)
+			result.ArrTimeJalali = utils.FormatJalaliDateTime(result.ArrTimeLocal)
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// resolveAirportCities sets the cities of the requested airports, as flights
+// are searched by city and then narrowed down to the airports.
+func (f *Flight) resolveAirportCities(req *GetFlightsRequest) error {
+	if req.DepartureAirport != "" {
+		airport, ok := f.Airports.Lookup(req.DepartureAirport)
+		if !ok {
+			return errors.New("Unknown departure airport")
+		}
+
+		if req.DepartureCity != "" && !strings.EqualFold(req.DepartureCity, airport.City.Name) {
+			return errors.New("Departure airport is not in the departure city")
+		}
+
+		req.DepartureCity = airport.City.Name
+		req.DepartureAirport = airport.IATA
+	}
+
+	if req.ArrivalAirport != "" {
+		airport, ok := f.Airports.Lookup(req.ArrivalAirport)
+		if !ok {
+			return errors.New("Unknown arrival airport")
+		}
+
+		if req.ArrivalCity != "" && !strings.EqualFold(req.ArrivalCity, airport.City.Name) {
+			return errors.New("Arrival airport is not in the arrival city")
+		}
+
+		req.ArrivalCity = airport.City.Name
+		req.ArrivalAirport = airport.IATA
+	}
+
+	return nil
+}
+
+func (f *Flight) resolveAirports(flights []services.FlightResponse) []services.FlightResponse {
+	for i := range flights {
+		flights[i].DepAirport = f.Airports.Resolve(flights[i].DepCity.Name, flights[i].DepAirport)
+		flights[i].ArrAirport = f.Airports.Resolve(flights[i].ArrCity.Name, flights[i].ArrAirport)
+	}
+
+	return flights
 }
 
 func sortFlight(flights []services.FlightResponse, sortBy, sortOrder string) []services.FlightResponse {
@@ -204,6 +316,25 @@ func filterByDeptime(flights []services.FlightResponse, depTimeFrom, depTimeTo t
 	return filteredFlights
 }
 
+// filterByAirports keeps the flights using the given airports. Flights whose
+// airport is unknown are left out, as they may use any airport of the city.
+func filterByAirports(flights []services.FlightResponse, depAirport, arrAirport string) []services.FlightResponse {
+	var filteredFlights []services.FlightResponse
+	for _, flight := range flights {
+		if depAirport != "" && (flight.DepAirport == nil || !strings.EqualFold(flight.DepAirport.IATA, depAirport)) {
+			continue
+		}
+
+		if arrAirport != "" && (flight.ArrAirport == nil || !strings.EqualFold(flight.ArrAirport.IATA, arrAirport)) {
+			continue
+		}
+
+		filteredFlights = append(filteredFlights, flight)
+	}
+
+	return filteredFlights
+}
+
 func filterByRemainingSeats(flights []services.FlightResponse, remainingSeats int32) []services.FlightResponse {
 	var filteredFlights []services.FlightResponse
 	for _, flight := range flights {
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"aliagha/models"
+	"aliagha/services"
+	"aliagha/utils"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+type CancellationResponse struct {
+	Description string                      `json:"description"`
+	Rules       []services.CancellationRule `json:"rules"`
+	Summary     []string                    `json:"summary"`
+}
+
+type FlightDetailResponse struct {
+	services.FlightInfoResponse
+	DepTimeLocal  time.Time            `json:"dep_time_local"`
+	ArrTimeLocal  time.Time            `json:"arr_time_local"`
+	DepTimeJalali string               `json:"dep_time_jalali,omitempty"`
+	ArrTimeJalali string               `json:"arr_time_jalali,omitempty"`
+	Cancellation  CancellationResponse `json:"cancellation"`
+}
+
+func (f *Flight) Detail(ctx echo.Context) error {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, "Bad Request")
+	}
+
+	// The provider comes from the search results, as flight ids are only
+	// unique within a provider. The name is resolved so that the cache key is
+	// the one a booking invalidates.
+	providerName, err := services.ProviderName(f.Provider, ctx.QueryParam("provider"))
+	if errors.Is(err, services.ErrProviderRequired) {
+		return ctx.JSON(http.StatusBadRequest, "Provider is required")
+	}
+
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, "Unknown provider")
+	}
+
+	provider, err := services.ProviderFor(f.Provider, providerName)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, "Unknown provider")
+	}
+
+	cacheKey := services.FlightDetailCacheKey(providerName, int32(id))
+	cacheResult, err := f.Redis.Get(cacheKey).Bytes()
+	if err != nil && err != redis.Nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	if err == nil {
+		var detail FlightDetailResponse
+		if err := json.Unmarshal(cacheResult, &detail); err == nil {
+			return ctx.JSON(http.StatusOK, withJalaliTimes(ctx, detail))
+		}
+	}
+
+	flightInfo, err := provider.GetFlightInfo(int32(id))
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	cancellation, err := f.cancellation(flightInfo.CxlSitID)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	flightInfo.DepAirport = f.Airports.Resolve(flightInfo.DepCity.Name, flightInfo.DepAirport)
+	flightInfo.ArrAirport = f.Airports.Resolve(flightInfo.ArrCity.Name, flightInfo.ArrAirport)
+	flightInfo.DepTime = flightInfo.DepTime.UTC()
+	flightInfo.ArrTime = flightInfo.ArrTime.UTC()
+	detail := FlightDetailResponse{
+		FlightInfoResponse: flightInfo,
+		DepTimeLocal:       flightInfo.DepTime.In(f.Timezones.Location(flightInfo.DepCity.Name)),
+		ArrTimeLocal:       flightInfo.ArrTime.In(f.Timezones.Location(flightInfo.ArrCity.Name)),
+		Cancellation:       cancellation,
+	}
+
+	jsonData, err := json.Marshal(detail)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	if err := f.Redis.Set(cacheKey, jsonData, f.Config.Redis.DetailTTL).Err(); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	return ctx.JSON(http.StatusOK, withJalaliTimes(ctx, detail))
+}
+
+// withJalaliTimes adds Solar Hijri times for clients preferring Persian. They
+// are left out of the cache, which is shared by all clients.
+func withJalaliTimes(ctx echo.Context, detail FlightDetailResponse) FlightDetailResponse {
+	if utils.PrefersJalali(ctx.Request().Header.Get("Accept-Language")) {
+		detail.DepTimeJalali = utils.FormatJalaliDateTime(detail.DepTimeLocal)
+		detail.ArrTimeJalali = utils.FormatJalaliDateTime(detail.ArrTimeLocal)
+	}
+
+	return detail
+}
+
+// cancellation describes the canceling situation of a flight. Situations that
+// are missing locally or have malformed data are reported without rules.
+func (f *Flight) cancellation(cxlSitID int32) (CancellationResponse, error) {
+	resp := CancellationResponse{
+		Rules:   []services.CancellationRule{},
+		Summary: []string{},
+	}
+
+	var situation models.CancelingSituation
+	err := f.DB.Model(&models.CancelingSituation{}).Where("id = ?", cxlSitID).First(&situation).Error
+	if err == gorm.ErrRecordNotFound {
+		resp.Description = "Cancellation rules are not available for this flight"
+		return resp, nil
+	}
+
+	if err != nil {
+		return CancellationResponse{}, err
+	}
+
+	resp.Description = situation.Description
+	if rules, err := services.ParseCancellationRules(situation.Data); err == nil {
+		resp.Rules = rules
+		resp.Summary = services.DescribeCancellationRules(rules)
+	}
+
+	return resp, nil
+}
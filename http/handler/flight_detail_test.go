@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"aliagha/config"
+	"aliagha/database"
+	"aliagha/services"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"bou.ke/monkey"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+type FlightDetailTestSuite struct {
+	suite.Suite
+	flight      *Flight
+	flightInfo  services.FlightInfoResponse
+	sqlMock     sqlmock.Sqlmock
+	redisServer *miniredis.Miniredis
+	e           *echo.Echo
+}
+
+func (suite *FlightDetailTestSuite) SetupSuite() {
+	mockDB, sqlMock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      mockDB,
+		SkipInitializeWithVersion: true,
+	}))
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	server, client := database.NewRedisMock()
+
+	suite.sqlMock = sqlMock
+	suite.redisServer = server
+	suite.e = echo.New()
+	suite.flight = &Flight{
+		DB:       db,
+		Redis:    client,
+		Config:   &config.Config{Redis: config.Redis{DetailTTL: time.Minute}},
+		Provider: &services.APIMockClient{},
+	}
+	suite.flightInfo = services.FlightInfoResponse{
+		ID: 1, DepCity: services.City{ID: 1, Name: "CityA"}, ArrCity: services.City{ID: 2, Name: "CityB"},
+		DepTime: time.Date(2023, 6, 28, 10, 0, 0, 0, time.UTC), ArrTime: time.Date(2023, 6, 28, 13, 0, 0, 0, time.UTC),
+		Airplane: services.Airplane{ID: 1, Name: "Boeing737"}, Airline: "AirlineX", Price: 200, CxlSitID: 3, RemainingSeats: 50,
+		FlightClass: "economy", BaggageAllowance: "20kg", MealService: "snack", Gate: "A4",
+	}
+}
+
+func (suite *FlightDetailTestSuite) SetupTest() {
+	suite.redisServer.FlushAll()
+}
+
+func (suite *FlightDetailTestSuite) TearDownSuite() {
+	suite.redisServer.Close()
+}
+
+func (suite *FlightDetailTestSuite) CallHandler(id string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/flights/"+id, nil)
+	res := httptest.NewRecorder()
+	c := suite.e.NewContext(req, res)
+	c.SetParamNames("id")
+	c.SetParamValues(id)
+
+	suite.Require().NoError(suite.flight.Detail(c))
+
+	return res
+}
+
+func (suite *FlightDetailTestSuite) TestFlightDetail_NoCache_Success() {
+	require := suite.Require()
+	expectedResponse := `{"id":1,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T10:00:00Z","arr_time":"2023-06-28T13:00:00Z","airplane":{"id":1,"name":"Boeing737"},"airline":"AirlineX","price":200,"cxl_sit_id":3,"remaining_seats":50,"flight_class":"economy","baggage_allowance":"20kg","meal_service":"snack","gate":"A4",` +
+		`"dep_time_local":"2023-06-28T10:00:00Z","arr_time_local":"2023-06-28T13:00:00Z",` +
+		`"cancellation":{"description":"Standard","rules":[{"hours_before":72,"penalty_percent":0},{"hours_before":3,"penalty_percent":50}],` +
+		`"summary":["At least 3 days before departure: free cancellation","Between 3 hours and 3 days before departure: 50% of the fare is charged","Less than 3 hours before departure: the ticket is not refundable"]}}`
+
+	var a services.APIMockClient
+	monkey.PatchInstanceMethod(reflect.TypeOf(&a), "GetFlightInfo", func(_ *services.APIMockClient, _ int32) (services.FlightInfoResponse, error) {
+		return suite.flightInfo, nil
+	})
+	defer monkey.UnpatchInstanceMethod(reflect.TypeOf(&a), "GetFlightInfo")
+
+	suite.sqlMock.ExpectQuery("^SELECT \\* FROM `canceling_situations` WHERE id = \\?").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "description", "data"}).AddRow(3, "Standard", "3:50,72:0"))
+
+	res := suite.CallHandler("1")
+	require.Equal(http.StatusOK, res.Code)
+	require.JSONEq(expectedResponse, res.Body.String())
+
+	cache, err := suite.redisServer.Get("flight-detail-1")
+	require.NoError(err)
+	require.JSONEq(expectedResponse, cache)
+}
+
+func (suite *FlightDetailTestSuite) TestFlightDetail_SingleProvider_Success() {
+	require := suite.Require()
+
+	var a services.APIMockClient
+	monkey.PatchInstanceMethod(reflect.TypeOf(&a), "GetFlightInfo", func(_ *services.APIMockClient, _ int32) (services.FlightInfoResponse, error) {
+		return suite.flightInfo, nil
+	})
+	defer monkey.UnpatchInstanceMethod(reflect.TypeOf(&a), "GetFlightInfo")
+
+	provider := suite.flight.Provider
+	suite.flight.Provider = services.NewAggregator(services.NamedProvider{Name: "mock", Provider: &a})
+	defer func() { suite.flight.Provider = provider }()
+
+	suite.sqlMock.ExpectQuery("^SELECT \\* FROM `canceling_situations` WHERE id = \\?").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "description", "data"}).AddRow(3, "Standard", "3:50,72:0"))
+
+	// Without a provider the detail is cached under the name of the only
+	// provider, which a booking invalidates.
+	res := suite.CallHandler("1")
+	require.Equal(http.StatusOK, res.Code)
+	require.True(suite.redisServer.Exists("flight-detail-mock-1"))
+	require.False(suite.redisServer.Exists("flight-detail-1"))
+}
+
+func (suite *FlightDetailTestSuite) TestFlightDetail_WithCache_Success() {
+	require := suite.Require()
+	cached := `{"id":1,"airline":"AirlineX","gate":"A4","cancellation":{"description":"Standard","rules":[],"summary":[]}}`
+
+	require.NoError(suite.redisServer.Set("flight-detail-1", cached))
+
+	res := suite.CallHandler("1")
+	require.Equal(http.StatusOK, res.Code)
+	require.Contains(res.Body.String(), `"gate":"A4"`)
+	require.Contains(res.Body.String(), `"description":"Standard"`)
+}
+
+func (suite *FlightDetailTestSuite) TestFlightDetail_UnknownCancelingSituation_Success() {
+	require := suite.Require()
+
+	var a services.APIMockClient
+	monkey.PatchInstanceMethod(reflect.TypeOf(&a), "GetFlightInfo", func(_ *services.APIMockClient, _ int32) (services.FlightInfoResponse, error) {
+		return suite.flightInfo, nil
+	})
+	defer monkey.UnpatchInstanceMethod(reflect.TypeOf(&a), "GetFlightInfo")
+
+	suite.sqlMock.ExpectQuery("^SELECT \\* FROM `canceling_situations` WHERE id = \\?").
+		WithArgs(3).
+		WillReturnError(gorm.ErrRecordNotFound)
+
+	res := suite.CallHandler("1")
+	require.Equal(http.StatusOK, res.Code)
+	require.Contains(res.Body.String(), `"cancellation":{"description":"Cancellation rules are not available for this flight","rules":[],"summary":[]}`)
+}
+
+func (suite *FlightDetailTestSuite) TestFlightDetail_APIMockErr_Failure() {
+	require := suite.Require()
+
+	var a services.APIMockClient
+	monkey.PatchInstanceMethod(reflect.TypeOf(&a), "GetFlightInfo", func(_ *services.APIMockClient, _ int32) (services.FlightInfoResponse, error) {
+		return services.FlightInfoResponse{}, errors.New("error")
+	})
+	defer monkey.UnpatchInstanceMethod(reflect.TypeOf(&a), "GetFlightInfo")
+
+	res := suite.CallHandler("9")
+	require.Equal(http.StatusInternalServerError, res.Code)
+}
+
+func (suite *FlightDetailTestSuite) TestFlightDetail_BadID_Failure() {
+	require := suite.Require()
+
+	res := suite.CallHandler("abc")
+	require.Equal(http.StatusBadRequest, res.Code)
+}
+
+func TestFlightDetail(t *testing.T) {
+	suite.Run(t, new(FlightDetailTestSuite))
+}
@@ -16,11 +16,14 @@ import (
 	"github.com/alicebob/miniredis/v2"
 
 	"bou.ke/monkey"
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/eapache/go-resiliency/breaker"
 	"github.com/go-playground/validator/v10"
 	"github.com/go-redis/redis"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
 )
 
 type GetFlightTestSuite struct {
@@ -40,7 +43,7 @@ func (suite *GetFlightTestSuite) SetupSuite() {
 		Redis:     &redis.Client{},
 		Validator: vldt,
 		Config:    &config.Config{Redis: config.Redis{TTL: 10 * time.Second}, MockAPI: config.MockAPI{Timeout: 30 * time.Second}},
-		APIMock: services.APIMockClient{
+		Provider: &services.APIMockClient{
 			Client:  &http.Client{},
 			Breaker: &breaker.Breaker{},
 			Timeout: 30 * time.Second,
@@ -88,7 +91,8 @@ func (suite *GetFlightTestSuite) CallHandler(query string) (*httptest.ResponseRe
 func (suite *GetFlightTestSuite) TestGetFlight_NoCache_Success() {
 	require := suite.Require()
 	expectedStatusCode := http.StatusOK
-	expectedResponse := `[{"id":1,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T10:00:00Z","arr_time":"2023-06-28T13:00:00Z","airplane":{"id":1,"name":"Boeing737"},"airline":"AirlineX","price":200,"cxl_sit_id":123,"remaining_seats":50},{"id":2,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T14:00:00Z","arr_time":"2023-06-28T17:00:00Z","airplane":{"id":2,"name":"AirbusA320"},"airline":"AirlineY","price":250,"cxl_sit_id":456,"remaining_seats":30}]`
+	expectedCache := `[{"id":1,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T10:00:00Z","arr_time":"2023-06-28T13:00:00Z","airplane":{"id":1,"name":"Boeing737"},"airline":"AirlineX","price":200,"cxl_sit_id":123,"remaining_seats":50},{"id":2,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T14:00:00Z","arr_time":"2023-06-28T17:00:00Z","airplane":{"id":2,"name":"AirbusA320"},"airline":"AirlineY","price":250,"cxl_sit_id":456,"remaining_seats":30}]`
+	expectedResponse := `[{"id":1,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T10:00:00Z","arr_time":"2023-06-28T13:00:00Z","airplane":{"id":1,"name":"Boeing737"},"airline":"AirlineX","price":200,"cxl_sit_id":123,"remaining_seats":50,"dep_time_local":"2023-06-28T10:00:00Z","arr_time_local":"2023-06-28T13:00:00Z"},{"id":2,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T14:00:00Z","arr_time":"2023-06-28T17:00:00Z","airplane":{"id":2,"name":"AirbusA320"},"airline":"AirlineY","price":250,"cxl_sit_id":456,"remaining_seats":30,"dep_time_local":"2023-06-28T14:00:00Z","arr_time_local":"2023-06-28T17:00:00Z"}]`
 
 	var a services.APIMockClient
 	monkey.PatchInstanceMethod(reflect.TypeOf(&a), "GetFlights", func(_ *services.APIMockClient, _, _, _ string) ([]services.FlightResponse, error) {
@@ -103,13 +107,13 @@ func (suite *GetFlightTestSuite) TestGetFlight_NoCache_Success() {
 
 	cache, err := suite.redisServer.Get("flights-CityA-CityB-2023-06-28")
 	require.NoError(err)
-	require.Equal(cache, expectedResponse)
+	require.Equal(cache, expectedCache)
 }
 
 func (suite *GetFlightTestSuite) TestGetFlight_WithCache_Success() {
 	require := suite.Require()
 	expectedStatusCode := http.StatusOK
-	expectedResponse := `[{"id":1,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T10:00:00Z","arr_time":"2023-06-28T13:00:00Z","airplane":{"id":1,"name":"Boeing737"},"airline":"AirlineX","price":200,"cxl_sit_id":123,"remaining_seats":50},{"id":2,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T14:00:00Z","arr_time":"2023-06-28T17:00:00Z","airplane":{"id":2,"name":"AirbusA320"},"airline":"AirlineY","price":250,"cxl_sit_id":456,"remaining_seats":30}]`
+	expectedResponse := `[{"id":1,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T10:00:00Z","arr_time":"2023-06-28T13:00:00Z","airplane":{"id":1,"name":"Boeing737"},"airline":"AirlineX","price":200,"cxl_sit_id":123,"remaining_seats":50,"dep_time_local":"2023-06-28T10:00:00Z","arr_time_local":"2023-06-28T13:00:00Z"},{"id":2,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T14:00:00Z","arr_time":"2023-06-28T17:00:00Z","airplane":{"id":2,"name":"AirbusA320"},"airline":"AirlineY","price":250,"cxl_sit_id":456,"remaining_seats":30,"dep_time_local":"2023-06-28T14:00:00Z","arr_time_local":"2023-06-28T17:00:00Z"}]`
 
 	monkey.Patch(suite.flight.Validator.Struct, func(_ interface{}) error {
 		return nil
@@ -139,10 +143,10 @@ func (suite *GetFlightTestSuite) TestGetFlight_WithSortAndFilter_Success() {
 		statusCode int
 		response   string
 	}{
-		{`?departure_city=CityA&arrival_city=CityB&date=2023-06-28&sort_by=price&sort_order=desc`, http.StatusOK, `[{"id":2,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T14:00:00Z","arr_time":"2023-06-28T17:00:00Z","airplane":{"id":2,"name":"AirbusA320"},"airline":"AirlineY","price":250,"cxl_sit_id":456,"remaining_seats":30},{"id":1,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T10:00:00Z","arr_time":"2023-06-28T13:00:00Z","airplane":{"id":1,"name":"Boeing737"},"airline":"AirlineX","price":200,"cxl_sit_id":123,"remaining_seats":50}]`},
-		{`?departure_city=CityA&arrival_city=CityB&date=2023-06-28&sort_by=dep_time&sort_order=asc`, http.StatusOK, `[{"id":1,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T10:00:00Z","arr_time":"2023-06-28T13:00:00Z","airplane":{"id":1,"name":"Boeing737"},"airline":"AirlineX","price":200,"cxl_sit_id":123,"remaining_seats":50},{"id":2,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T14:00:00Z","arr_time":"2023-06-28T17:00:00Z","airplane":{"id":2,"name":"AirbusA320"},"airline":"AirlineY","price":250,"cxl_sit_id":456,"remaining_seats":30}]`},
-		{`?departure_city=CityA&arrival_city=CityB&date=2023-06-28&sort_by=duration`, http.StatusOK, `[{"id":1,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T10:00:00Z","arr_time":"2023-06-28T13:00:00Z","airplane":{"id":1,"name":"Boeing737"},"airline":"AirlineX","price":200,"cxl_sit_id":123,"remaining_seats":50},{"id":2,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T14:00:00Z","arr_time":"2023-06-28T17:00:00Z","airplane":{"id":2,"name":"AirbusA320"},"airline":"AirlineY","price":250,"cxl_sit_id":456,"remaining_seats":30}]`},
-		{`?departure_city=CityA&arrival_city=CityB&date=2023-06-28&remaining_seats=40`, http.StatusOK, `[{"id":1,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T10:00:00Z","arr_time":"2023-06-28T13:00:00Z","airplane":{"id":1,"name":"Boeing737"},"airline":"AirlineX","price":200,"cxl_sit_id":123,"remaining_seats":50}]`},
+		{`?departure_city=CityA&arrival_city=CityB&date=2023-06-28&sort_by=price&sort_order=desc`, http.StatusOK, `[{"id":2,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T14:00:00Z","arr_time":"2023-06-28T17:00:00Z","airplane":{"id":2,"name":"AirbusA320"},"airline":"AirlineY","price":250,"cxl_sit_id":456,"remaining_seats":30,"dep_time_local":"2023-06-28T14:00:00Z","arr_time_local":"2023-06-28T17:00:00Z"},{"id":1,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T10:00:00Z","arr_time":"2023-06-28T13:00:00Z","airplane":{"id":1,"name":"Boeing737"},"airline":"AirlineX","price":200,"cxl_sit_id":123,"remaining_seats":50,"dep_time_local":"2023-06-28T10:00:00Z","arr_time_local":"2023-06-28T13:00:00Z"}]`},
+		{`?departure_city=CityA&arrival_city=CityB&date=2023-06-28&sort_by=dep_time&sort_order=asc`, http.StatusOK, `[{"id":1,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T10:00:00Z","arr_time":"2023-06-28T13:00:00Z","airplane":{"id":1,"name":"Boeing737"},"airline":"AirlineX","price":200,"cxl_sit_id":123,"remaining_seats":50,"dep_time_local":"2023-06-28T10:00:00Z","arr_time_local":"2023-06-28T13:00:00Z"},{"id":2,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T14:00:00Z","arr_time":"2023-06-28T17:00:00Z","airplane":{"id":2,"name":"AirbusA320"},"airline":"AirlineY","price":250,"cxl_sit_id":456,"remaining_seats":30,"dep_time_local":"2023-06-28T14:00:00Z","arr_time_local":"2023-06-28T17:00:00Z"}]`},
+		{`?departure_city=CityA&arrival_city=CityB&date=2023-06-28&sort_by=duration`, http.StatusOK, `[{"id":1,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T10:00:00Z","arr_time":"2023-06-28T13:00:00Z","airplane":{"id":1,"name":"Boeing737"},"airline":"AirlineX","price":200,"cxl_sit_id":123,"remaining_seats":50,"dep_time_local":"2023-06-28T10:00:00Z","arr_time_local":"2023-06-28T13:00:00Z"},{"id":2,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T14:00:00Z","arr_time":"2023-06-28T17:00:00Z","airplane":{"id":2,"name":"AirbusA320"},"airline":"AirlineY","price":250,"cxl_sit_id":456,"remaining_seats":30,"dep_time_local":"2023-06-28T14:00:00Z","arr_time_local":"2023-06-28T17:00:00Z"}]`},
+		{`?departure_city=CityA&arrival_city=CityB&date=2023-06-28&remaining_seats=40`, http.StatusOK, `[{"id":1,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T10:00:00Z","arr_time":"2023-06-28T13:00:00Z","airplane":{"id":1,"name":"Boeing737"},"airline":"AirlineX","price":200,"cxl_sit_id":123,"remaining_seats":50,"dep_time_local":"2023-06-28T10:00:00Z","arr_time_local":"2023-06-28T13:00:00Z"}]`},
 	}
 
 	for _, t := range tests {
@@ -224,8 +228,8 @@ func (suite *GetFlightTestSuite) TestGetFlight_FilterByAirline_Success() {
 		statusCode int
 		response   string
 	}{
-		{`?departure_city=CityA&arrival_city=CityB&date=2023-06-28&airline=AirlineY`, http.StatusOK, `[{"id":2,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T14:00:00Z","arr_time":"2023-06-28T17:00:00Z","airplane":{"id":2,"name":"AirbusA320"},"airline":"AirlineY","price":250,"cxl_sit_id":456,"remaining_seats":30}]`},
-		{`?departure_city=CityA&arrival_city=CityB&date=2023-06-28&airline=AirlineX`, http.StatusOK, `[{"id":1,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T10:00:00Z","arr_time":"2023-06-28T13:00:00Z","airplane":{"id":1,"name":"Boeing737"},"airline":"AirlineX","price":200,"cxl_sit_id":123,"remaining_seats":50}]`},
+		{`?departure_city=CityA&arrival_city=CityB&date=2023-06-28&airline=AirlineY`, http.StatusOK, `[{"id":2,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T14:00:00Z","arr_time":"2023-06-28T17:00:00Z","airplane":{"id":2,"name":"AirbusA320"},"airline":"AirlineY","price":250,"cxl_sit_id":456,"remaining_seats":30,"dep_time_local":"2023-06-28T14:00:00Z","arr_time_local":"2023-06-28T17:00:00Z"}]`},
+		{`?departure_city=CityA&arrival_city=CityB&date=2023-06-28&airline=AirlineX`, http.StatusOK, `[{"id":1,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T10:00:00Z","arr_time":"2023-06-28T13:00:00Z","airplane":{"id":1,"name":"Boeing737"},"airline":"AirlineX","price":200,"cxl_sit_id":123,"remaining_seats":50,"dep_time_local":"2023-06-28T10:00:00Z","arr_time_local":"2023-06-28T13:00:00Z"}]`},
 	}
 
 	for _, t := range tests {
@@ -249,8 +253,8 @@ func (suite *GetFlightTestSuite) TestGetFlight_FilterByAirplaneName_Success() {
 		statusCode int
 		response   string
 	}{
-		{`?departure_city=CityA&arrival_city=CityB&date=2023-06-28&airplane_name=AirbusA320`, http.StatusOK, `[{"id":2,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T14:00:00Z","arr_time":"2023-06-28T17:00:00Z","airplane":{"id":2,"name":"AirbusA320"},"airline":"AirlineY","price":250,"cxl_sit_id":456,"remaining_seats":30}]`},
-		{`?departure_city=CityA&arrival_city=CityB&date=2023-06-28&airplane_name=Boeing737`, http.StatusOK, `[{"id":1,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T10:00:00Z","arr_time":"2023-06-28T13:00:00Z","airplane":{"id":1,"name":"Boeing737"},"airline":"AirlineX","price":200,"cxl_sit_id":123,"remaining_seats":50}]`},
+		{`?departure_city=CityA&arrival_city=CityB&date=2023-06-28&airplane_name=AirbusA320`, http.StatusOK, `[{"id":2,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T14:00:00Z","arr_time":"2023-06-28T17:00:00Z","airplane":{"id":2,"name":"AirbusA320"},"airline":"AirlineY","price":250,"cxl_sit_id":456,"remaining_seats":30,"dep_time_local":"2023-06-28T14:00:00Z","arr_time_local":"2023-06-28T17:00:00Z"}]`},
+		{`?departure_city=CityA&arrival_city=CityB&date=2023-06-28&airplane_name=Boeing737`, http.StatusOK, `[{"id":1,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T10:00:00Z","arr_time":"2023-06-28T13:00:00Z","airplane":{"id":1,"name":"Boeing737"},"airline":"AirlineX","price":200,"cxl_sit_id":123,"remaining_seats":50,"dep_time_local":"2023-06-28T10:00:00Z","arr_time_local":"2023-06-28T13:00:00Z"}]`},
 	}
 
 	for _, t := range tests {
@@ -274,8 +278,8 @@ func (suite *GetFlightTestSuite) TestGetFlight_FilterByDeptime_Success() {
 		statusCode int
 		response   string
 	}{
-		{`?departure_city=CityA&arrival_city=CityB&date=2023-06-28&departure_time_from=09:00&departure_time_to=15:00`, http.StatusOK, `[{"id":1,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T10:00:00Z","arr_time":"2023-06-28T13:00:00Z","airplane":{"id":1,"name":"Boeing737"},"airline":"AirlineX","price":200,"cxl_sit_id":123,"remaining_seats":50},{"id":2,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T14:00:00Z","arr_time":"2023-06-28T17:00:00Z","airplane":{"id":2,"name":"AirbusA320"},"airline":"AirlineY","price":250,"cxl_sit_id":456,"remaining_seats":30}]`},
-		{`?departure_city=CityA&arrival_city=CityB&date=2023-06-28&departure_time_from=13:00&departure_time_to=15:00`, http.StatusOK, `[{"id":2,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T14:00:00Z","arr_time":"2023-06-28T17:00:00Z","airplane":{"id":2,"name":"AirbusA320"},"airline":"AirlineY","price":250,"cxl_sit_id":456,"remaining_seats":30}]`},
+		{`?departure_city=CityA&arrival_city=CityB&date=2023-06-28&departure_time_from=09:00&departure_time_to=15:00`, http.StatusOK, `[{"id":1,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T10:00:00Z","arr_time":"2023-06-28T13:00:00Z","airplane":{"id":1,"name":"Boeing737"},"airline":"AirlineX","price":200,"cxl_sit_id":123,"remaining_seats":50,"dep_time_local":"2023-06-28T10:00:00Z","arr_time_local":"2023-06-28T13:00:00Z"},{"id":2,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T14:00:00Z","arr_time":"2023-06-28T17:00:00Z","airplane":{"id":2,"name":"AirbusA320"},"airline":"AirlineY","price":250,"cxl_sit_id":456,"remaining_seats":30,"dep_time_local":"2023-06-28T14:00:00Z","arr_time_local":"2023-06-28T17:00:00Z"}]`},
+		{`?departure_city=CityA&arrival_city=CityB&date=2023-06-28&departure_time_from=13:00&departure_time_to=15:00`, http.StatusOK, `[{"id":2,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T14:00:00Z","arr_time":"2023-06-28T17:00:00Z","airplane":{"id":2,"name":"AirbusA320"},"airline":"AirlineY","price":250,"cxl_sit_id":456,"remaining_seats":30,"dep_time_local":"2023-06-28T14:00:00Z","arr_time_local":"2023-06-28T17:00:00Z"}]`},
 	}
 
 	for _, t := range tests {
@@ -299,8 +303,8 @@ func (suite *GetFlightTestSuite) TestGetFlight_FilterByRemainingSeats_Success()
 		statusCode int
 		response   string
 	}{
-		{`?departure_city=CityA&arrival_city=CityB&date=2023-06-28&remaining_seats=30`, http.StatusOK, `[{"id":1,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T10:00:00Z","arr_time":"2023-06-28T13:00:00Z","airplane":{"id":1,"name":"Boeing737"},"airline":"AirlineX","price":200,"cxl_sit_id":123,"remaining_seats":50},{"id":2,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T14:00:00Z","arr_time":"2023-06-28T17:00:00Z","airplane":{"id":2,"name":"AirbusA320"},"airline":"AirlineY","price":250,"cxl_sit_id":456,"remaining_seats":30}]`},
-		{`?departure_city=CityA&arrival_city=CityB&date=2023-06-28&remaining_seats=40`, http.StatusOK, `[{"id":1,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T10:00:00Z","arr_time":"2023-06-28T13:00:00Z","airplane":{"id":1,"name":"Boeing737"},"airline":"AirlineX","price":200,"cxl_sit_id":123,"remaining_seats":50}]`},
+		{`?departure_city=CityA&arrival_city=CityB&date=2023-06-28&remaining_seats=30`, http.StatusOK, `[{"id":1,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T10:00:00Z","arr_time":"2023-06-28T13:00:00Z","airplane":{"id":1,"name":"Boeing737"},"airline":"AirlineX","price":200,"cxl_sit_id":123,"remaining_seats":50,"dep_time_local":"2023-06-28T10:00:00Z","arr_time_local":"2023-06-28T13:00:00Z"},{"id":2,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T14:00:00Z","arr_time":"2023-06-28T17:00:00Z","airplane":{"id":2,"name":"AirbusA320"},"airline":"AirlineY","price":250,"cxl_sit_id":456,"remaining_seats":30,"dep_time_local":"2023-06-28T14:00:00Z","arr_time_local":"2023-06-28T17:00:00Z"}]`},
+		{`?departure_city=CityA&arrival_city=CityB&date=2023-06-28&remaining_seats=40`, http.StatusOK, `[{"id":1,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T10:00:00Z","arr_time":"2023-06-28T13:00:00Z","airplane":{"id":1,"name":"Boeing737"},"airline":"AirlineX","price":200,"cxl_sit_id":123,"remaining_seats":50,"dep_time_local":"2023-06-28T10:00:00Z","arr_time_local":"2023-06-28T13:00:00Z"}]`},
 	}
 
 	for _, t := range tests {
@@ -310,6 +314,111 @@ func (suite *GetFlightTestSuite) TestGetFlight_FilterByRemainingSeats_Success()
 		require.Equal(t.response, strings.TrimSpace(res.Body.String()))
 	}
 }
+func (suite *GetFlightTestSuite) TestGetFlight_JalaliDate_Success() {
+	require := suite.Require()
+
+	var a services.APIMockClient
+	monkey.PatchInstanceMethod(reflect.TypeOf(&a), "GetFlights", func(a *services.APIMockClient, _, _, date string) ([]services.FlightResponse, error) {
+		require.Equal("2023-06-28", date)
+		return suite.flights[:1], nil
+	})
+	defer monkey.UnpatchInstanceMethod(reflect.TypeOf(&a), "GetFlights")
+
+	req := httptest.NewRequest(http.MethodGet, "/flights?departure_city=CityA&arrival_city=CityB&date=1402-04-07", nil)
+	req.Header.Set("Accept-Language", "fa-IR,fa;q=0.9,en;q=0.8")
+	res := httptest.NewRecorder()
+	require.NoError(suite.flight.Get(suite.e.NewContext(req, res)))
+
+	require.Equal(http.StatusOK, res.Code)
+	require.Contains(res.Body.String(), `"dep_time_jalali":"1402-04-07 10:00","arr_time_jalali":"1402-04-07 13:00"`)
+
+	_, err := suite.redisServer.Get("flights-CityA-CityB-2023-06-28")
+	require.NoError(err)
+}
+
+func (suite *GetFlightTestSuite) TestGetFlight_FilterByAirport_Success() {
+	require := suite.Require()
+
+	mockDB, sqlMock, err := sqlmock.New()
+	require.NoError(err)
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: mockDB, SkipInitializeWithVersion: true}))
+	require.NoError(err)
+
+	sqlMock.ExpectQuery("^SELECT \\* FROM `airports`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "city_id", "iata", "icao", "name"}).
+			AddRow(1, 1, "AAA", "OAAA", "CityA International").
+			AddRow(2, 1, "AAB", nil, "CityA Downtown").
+			AddRow(3, 2, "BBB", "OBBB", "CityB Airport"))
+	sqlMock.ExpectQuery("^SELECT \\* FROM `cities`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "CityA").AddRow(2, "CityB"))
+
+	suite.flight.Airports = &services.AirportDirectory{DB: db, TTL: time.Hour}
+	defer func() { suite.flight.Airports = nil }()
+
+	flights := make([]services.FlightResponse, len(suite.flights))
+	copy(flights, suite.flights)
+	flights[0].DepAirport = &services.Airport{IATA: "AAA"}
+	flights[1].DepAirport = &services.Airport{IATA: "AAB"}
+
+	var a services.APIMockClient
+	monkey.PatchInstanceMethod(reflect.TypeOf(&a), "GetFlights", func(a *services.APIMockClient, depCity, _, _ string) ([]services.FlightResponse, error) {
+		require.Equal("CityA", depCity)
+		return flights, nil
+	})
+	defer monkey.UnpatchInstanceMethod(reflect.TypeOf(&a), "GetFlights")
+
+	res, err := suite.CallHandler(`?departure_airport=oaaa&arrival_city=CityB&date=2023-06-28`)
+	require.NoError(err)
+	require.Equal(http.StatusOK, res.Code)
+	require.Contains(res.Body.String(), `"dep_airport":{"iata":"AAA","icao":"OAAA","name":"CityA International"},"arr_airport":{"iata":"BBB","icao":"OBBB","name":"CityB Airport"}`)
+	require.NotContains(res.Body.String(), `"AAB"`)
+
+	res, err = suite.CallHandler(`?departure_city=CityA&departure_airport=AAB&arrival_airport=BBB&date=2023-06-28`)
+	require.NoError(err)
+	require.Equal(http.StatusOK, res.Code)
+	require.Contains(res.Body.String(), `"dep_airport":{"iata":"AAB","name":"CityA Downtown"}`)
+	require.NotContains(res.Body.String(), `"AAA"`)
+
+	res, err = suite.CallHandler(`?departure_city=CityB&departure_airport=AAA&arrival_city=CityB&date=2023-06-28`)
+	require.NoError(err)
+	require.Equal(http.StatusBadRequest, res.Code)
+
+	res, err = suite.CallHandler(`?departure_airport=ZZZ&arrival_city=CityB&date=2023-06-28`)
+	require.NoError(err)
+	require.Equal(http.StatusBadRequest, res.Code)
+}
+
+func (suite *GetFlightTestSuite) TestGetFlight_FilterByLocalDeptime_Success() {
+	require := suite.Require()
+
+	mockDB, sqlMock, err := sqlmock.New()
+	require.NoError(err)
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: mockDB, SkipInitializeWithVersion: true}))
+	require.NoError(err)
+
+	sqlMock.ExpectQuery("^SELECT (.+) FROM `cities`").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "timezone"}).
+			AddRow("CityA", "Asia/Tehran").
+			AddRow("CityB", "Asia/Dubai"))
+
+	timezones, err := services.NewCityTimezones(db, "UTC", time.Hour)
+	require.NoError(err)
+	suite.flight.Timezones = timezones
+	defer func() { suite.flight.Timezones = nil }()
+
+	var a services.APIMockClient
+	monkey.PatchInstanceMethod(reflect.TypeOf(&a), "GetFlights", func(a *services.APIMockClient, _, _, _ string) ([]services.FlightResponse, error) {
+		return suite.flights, nil
+	})
+	defer monkey.UnpatchInstanceMethod(reflect.TypeOf(&a), "GetFlights")
+
+	res, err := suite.CallHandler(`?departure_city=CityA&arrival_city=CityB&date=2023-06-28&departure_time_from=13:00&departure_time_to=15:00`)
+	require.NoError(err)
+	require.Equal(http.StatusOK, res.Code)
+	require.Equal(`[{"id":1,"dep_city":{"id":1,"name":"CityA"},"arr_city":{"id":2,"name":"CityB"},"dep_time":"2023-06-28T10:00:00Z","arr_time":"2023-06-28T13:00:00Z","airplane":{"id":1,"name":"Boeing737"},"airline":"AirlineX","price":200,"cxl_sit_id":123,"remaining_seats":50,"dep_time_local":"2023-06-28T13:30:00+03:30","arr_time_local":"2023-06-28T17:00:00+04:00"}]`, strings.TrimSpace(res.Body.String()))
+	require.NoError(sqlMock.ExpectationsWereMet())
+}
+
 func TestFlight(t *testing.T) {
 	suite.Run(t, new(GetFlightTestSuite))
 }
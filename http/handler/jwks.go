@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"aliagha/helpers"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// JWKS publishes the public keys access tokens are signed with, so other
+// services can verify them.
+type JWKS struct {
+	Keys *helpers.KeySet
+}
+
+func (j *JWKS) Get(ctx echo.Context) error {
+	// Caches pick up a rotated key within the hour; keep the previous key
+	// configured at least that long.
+	ctx.Response().Header().Set("Cache-Control", "public, max-age=3600")
+	return ctx.JSON(http.StatusOK, j.Keys.JWKS())
+}
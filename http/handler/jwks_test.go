@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"aliagha/config"
+	"aliagha/helpers"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWKS_Get(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	privatePEM := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+
+	keys, err := helpers.NewKeySet(&config.JWT{
+		SecretKey: "secretkey",
+		Keys:      []config.JWTKey{{ID: "ed-1", Algorithm: helpers.AlgorithmEdDSA, PrivateKey: privatePEM}},
+	})
+	require.NoError(t, err)
+
+	call := func(keys *helpers.KeySet) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		ctx := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil), res)
+		require.NoError(t, (&JWKS{Keys: keys}).Get(ctx))
+		return res
+	}
+
+	res := call(keys)
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Equal(t, "public, max-age=3600", res.Header().Get("Cache-Control"))
+
+	// Only public keys are published, never the secret.
+	var jwks helpers.JWKS
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &jwks))
+	require.Equal(t, []helpers.JWK{{KeyType: "OKP", KeyID: "ed-1", Use: "sig", Algorithm: "EdDSA", Curve: "Ed25519", X: jwt.EncodeSegment(public)}}, jwks.Keys)
+	require.NotContains(t, res.Body.String(), "secretkey")
+
+	res = call(helpers.NewHMACKeySet("secretkey"))
+	require.Equal(t, http.StatusOK, res.Code)
+	require.JSONEq(t, `{"keys":[]}`, res.Body.String())
+}
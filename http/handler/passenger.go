@@ -21,6 +21,7 @@ type CreatePassengerRequest struct {
 	Name         string `json:"name" validate:"required,min=3,max=100"`
 	NationalCode string `json:"national_code" validate:"required,len=10,numeric"`
 	Birthdate    string `json:"birth_date" validate:"required"`
+	Calendar     string `json:"calendar" validate:"omitempty,oneof=gregorian jalali"`
 }
 
 type CreatePassengerResponse struct {
@@ -42,7 +43,7 @@ func (p *Passenger) CreatePassenger(ctx echo.Context) error {
 		return ctx.JSON(http.StatusBadRequest, err.Error())
 	}
 
-	birthDate, err := utils.ParseDate(req.Birthdate)
+	birthDate, err := utils.ParseDateIn(req.Birthdate, utils.Calendar(req.Calendar))
 	if err != nil {
 		return ctx.JSON(http.StatusUnprocessableEntity, "Wrong date format")
 	}
@@ -81,11 +82,12 @@ type GetPassengersResponse struct {
 }
 
 type PassengerResponse struct {
-	ID           int32  `json:"id"`
-	UID          int32  `json:"u_id"`
-	NationalCode string `json:"national_code"`
-	Name         string `json:"name"`
-	Birthdate    string `json:"birth_date"`
+	ID              int32  `json:"id"`
+	UID             int32  `json:"u_id"`
+	NationalCode    string `json:"national_code"`
+	Name            string `json:"name"`
+	Birthdate       string `json:"birth_date"`
+	BirthdateJalali string `json:"birth_date_jalali,omitempty"`
 }
 
 func (p *Passenger) GetPassengers(ctx echo.Context) error {
@@ -101,15 +103,22 @@ func (p *Passenger) GetPassengers(ctx echo.Context) error {
 	}
 
 	resp := make([]PassengerResponse, 0, len(passengers))
+	jalali := utils.PrefersJalali(ctx.Request().Header.Get("Accept-Language"))
 
 	for _, passenger := range passengers {
-		resp = append(resp, PassengerResponse{
+		passengerResponse := PassengerResponse{
 			ID:           passenger.ID,
 			UID:          passenger.UID,
 			NationalCode: passenger.NationalCode,
 			Name:         passenger.Name,
 			Birthdate:    passenger.Birthdate.Format("2006-01-02"),
-		})
+		}
+
+		if jalali {
+			passengerResponse.BirthdateJalali = utils.FormatJalali(passenger.Birthdate)
+		}
+
+		resp = append(resp, passengerResponse)
 	}
 	return ctx.JSON(http.StatusOK, GetPassengersResponse{
 		Passengers: resp,
@@ -5,25 +5,33 @@ import (
 	"aliagha/models"
 	"aliagha/services"
 	"aliagha/utils/gateways"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/go-redis/redis"
 	"github.com/labstack/echo/v4"
 	"gorm.io/gorm"
 )
 
 type FlightReservation struct {
 	DB             *gorm.DB
+	Redis          *redis.Client
 	ZarinpalConfig *config.Zarinpal
 	Validator      *validator.Validate
-	APIMock        services.APIMockClient
+	Provider       services.FlightProvider
+	Airports       *services.AirportDirectory
 }
 
 type FlightReservationRequest struct {
 	UserId       int32
 	FlightId     int32   `json:"flight_id" validate:"required"`
 	PassengerIds []int32 `json:"passenger_ids" validate:"required"`
+	// Provider is the provider of the flight in the search results, as
+	// flight ids are only unique within a provider.
+	Provider string `json:"provider"`
 }
 
 type FlightReservationResponse struct {
@@ -68,11 +76,31 @@ func (f *FlightReservation) Reserve(ctx echo.Context) error {
 		}
 	}
 
-	if err := f.APIMock.Reserve(req.FlightId, (int32)(len(req.PassengerIds))); err != nil {
+	// The name is resolved so that the detail cached for the flight is found
+	// however it was requested.
+	providerName, err := services.ProviderName(f.Provider, req.Provider)
+	if errors.Is(err, services.ErrProviderRequired) {
+		return ctx.JSON(http.StatusBadRequest, "Provider is required")
+	}
+
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, "Unknown provider")
+	}
+
+	provider, err := services.ProviderFor(f.Provider, providerName)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, "Unknown provider")
+	}
+
+	if err := provider.Reserve(req.FlightId, (int32)(len(req.PassengerIds))); err != nil {
 		return ctx.JSON(http.StatusBadRequest, err.Error())
 	}
 
-	flightInfo, err := f.APIMock.GetFlightInfo(req.FlightId)
+	if err := f.Redis.Del(services.FlightDetailCacheKey(providerName, req.FlightId)).Err(); err != nil {
+		fmt.Println(err)
+	}
+
+	flightInfo, err := provider.GetFlightInfo(req.FlightId)
 	if err != nil {
 		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
 	}
@@ -88,6 +116,7 @@ func (f *FlightReservation) Reserve(ctx echo.Context) error {
 	var payment models.Payment
 	err = f.DB.Debug().Transaction(func(tx *gorm.DB) error {
 		flight := models.Flight{
+			Provider:         flightInfo.Provider,
 			ID:               flightInfo.ID,
 			DepCity:          models.City{ID: flightInfo.DepCity.ID, Name: flightInfo.DepCity.Name},
 			ArrCity:          models.City{ID: flightInfo.ArrCity.ID, Name: flightInfo.ArrCity.Name},
@@ -101,16 +130,29 @@ func (f *FlightReservation) Reserve(ctx echo.Context) error {
 			MealService:      flightInfo.MealService,
 			Gate:             flightInfo.Gate,
 		}
-		if err := tx.Debug().Model(&models.Flight{}).Create(&flight).Error; err != nil && err != gorm.ErrDuplicatedKey {
+		flight.DepAirportID = f.airportID(flightInfo.DepCity.Name, flightInfo.DepAirport)
+		flight.ArrAirportID = f.airportID(flightInfo.ArrCity.Name, flightInfo.ArrAirport)
+		var booked int64
+		err := tx.Debug().Model(&models.Flight{}).
+			Where("provider = ? AND id = ?", flight.Provider, flight.ID).
+			Count(&booked).Error
+		if err != nil {
 			return err
 		}
 
+		if booked == 0 {
+			if err := tx.Debug().Model(&models.Flight{}).Create(&flight).Error; err != nil {
+				return err
+			}
+		}
+
 		ticket := models.Ticket{
-			UID:    req.UserId,
-			PIDs:   passengerIdsStr,
-			FID:    req.FlightId,
-			Status: "payment pending",
-			Price:  flightInfo.Price,
+			UID:      req.UserId,
+			PIDs:     passengerIdsStr,
+			FID:      req.FlightId,
+			Provider: flightInfo.Provider,
+			Status:   "payment pending",
+			Price:    flightInfo.Price,
 		}
 
 		if err := tx.Debug().Model(&models.Ticket{}).Create(&ticket).Error; err != nil {
@@ -189,3 +231,18 @@ func (f *FlightReservation) VerifyPayment(ctx echo.Context) error {
 	}
 	return nil
 }
+
+// airportID returns the local id of the airport a flight uses, if known.
+func (f *FlightReservation) airportID(city string, airport *services.Airport) *int32 {
+	airport = f.Airports.Resolve(city, airport)
+	if airport == nil {
+		return nil
+	}
+
+	known, ok := f.Airports.Lookup(airport.IATA)
+	if !ok {
+		return nil
+	}
+
+	return &known.ID
+}
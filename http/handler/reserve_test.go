@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"aliagha/config"
+	"aliagha/database"
+	"aliagha/models"
+	"aliagha/services"
+	"aliagha/utils/gateways"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"bou.ke/monkey"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/suite"
+)
+
+// stubProvider serves a single flight.
+type stubProvider struct {
+	flight services.FlightInfoResponse
+}
+
+func (p *stubProvider) GetFlights(_, _, _ string) ([]services.FlightResponse, error) {
+	return nil, nil
+}
+
+func (p *stubProvider) GetFlightInfo(flightId int32) (services.FlightInfoResponse, error) {
+	if flightId != p.flight.ID {
+		return services.FlightInfoResponse{}, errors.New("not found")
+	}
+
+	return p.flight, nil
+}
+
+func (p *stubProvider) Reserve(_, _ int32) error {
+	return nil
+}
+
+func (p *stubProvider) Cancel(_, _ int32) error {
+	return nil
+}
+
+type ReserveTestSuite struct {
+	suite.Suite
+	redisServer *miniredis.Miniredis
+	e           *echo.Echo
+	reservation *FlightReservation
+	user        models.User
+	passenger   models.Passenger
+}
+
+func (suite *ReserveTestSuite) SetupTest() {
+	require := suite.Require()
+
+	server, redis := database.NewRedisMock()
+	db := database.NewSQLiteMock()
+	suite.redisServer = server
+	suite.e = echo.New()
+
+	require.NoError(db.Create(&models.CancelingSituation{ID: 1, Description: "Economy", Data: "0:100"}).Error)
+	suite.user = models.User{Name: "Traveler", Cellphone: "09121234567", Email: "traveler@example.com"}
+	require.NoError(db.Create(&suite.user).Error)
+	suite.passenger = models.Passenger{UID: suite.user.ID, Name: "Traveler", NationalCode: "0012345678", Birthdate: time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)}
+	require.NoError(db.Create(&suite.passenger).Error)
+
+	suite.reservation = &FlightReservation{
+		DB:             db,
+		Redis:          redis,
+		ZarinpalConfig: &config.Zarinpal{MerchantId: strings.Repeat("x", 36), CallbackUrl: "https://aliagha.test/verify", SandBox: true},
+		Validator:      validator.New(),
+		// Both providers use the id 7 for different flights.
+		Provider: services.NewAggregator(
+			services.NamedProvider{Name: "alpha", Provider: &stubProvider{flight: suite.flight("Alpha Air")}},
+			services.NamedProvider{Name: "beta", Provider: &stubProvider{flight: suite.flight("Beta Air")}},
+		),
+		// The in-memory database has a single connection, which the booking
+		// transaction holds while airports are looked up.
+		Airports: &services.AirportDirectory{DB: database.NewSQLiteMock()},
+	}
+
+	var zarinpal *gateways.Zarinpal
+	monkey.PatchInstanceMethod(reflect.TypeOf(zarinpal), "NewPaymentRequest", func(_ *gateways.Zarinpal, _ int, _, _, _, _ string) (string, string, error) {
+		return "https://sandbox.zarinpal.com/pg/StartPay/A1", "A1", nil
+	})
+}
+
+func (suite *ReserveTestSuite) TearDownTest() {
+	monkey.UnpatchAll()
+	suite.redisServer.Close()
+}
+
+func (suite *ReserveTestSuite) flight(airline string) services.FlightInfoResponse {
+	depTime := time.Date(2023, 6, 28, 10, 0, 0, 0, time.UTC)
+	return services.FlightInfoResponse{
+		ID: 7, DepCity: services.City{ID: 1, Name: "CityA"}, ArrCity: services.City{ID: 2, Name: "CityB"},
+		DepTime: depTime, ArrTime: depTime.Add(3 * time.Hour), Airplane: services.Airplane{ID: 1, Name: "Boeing737"},
+		Airline: airline, Price: 200, CxlSitID: 1, FlightClass: "economy", BaggageAllowance: "20kg", MealService: "snack", Gate: "A4",
+	}
+}
+
+func (suite *ReserveTestSuite) reserve(provider string) *httptest.ResponseRecorder {
+	body := `{"flight_id":7,"passenger_ids":[` + strconv.Itoa(int(suite.passenger.ID)) + `],"provider":"` + provider + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/flights/reserve", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	res := httptest.NewRecorder()
+	ctx := suite.e.NewContext(req, res)
+	ctx.Set("user_id", strconv.Itoa(int(suite.user.ID)))
+
+	suite.Require().NoError(suite.reservation.Reserve(ctx))
+	return res
+}
+
+func (suite *ReserveTestSuite) TestReserve_SameFlightIdOtherProvider_Success() {
+	require := suite.Require()
+
+	require.Equal(http.StatusOK, suite.reserve("alpha").Code)
+	require.Equal(http.StatusOK, suite.reserve("beta").Code)
+	require.Equal(http.StatusOK, suite.reserve("alpha").Code)
+
+	var flights []models.Flight
+	require.NoError(suite.reservation.DB.Order("provider").Find(&flights).Error)
+	require.Len(flights, 2)
+	require.Equal("alpha", flights[0].Provider)
+	require.Equal("Alpha Air", flights[0].Airline)
+	require.Equal("beta", flights[1].Provider)
+	require.Equal("Beta Air", flights[1].Airline)
+
+	var tickets []models.Ticket
+	require.NoError(suite.reservation.DB.Preload("Flight").Order("id").Find(&tickets).Error)
+	require.Len(tickets, 3)
+	require.Equal("Alpha Air", tickets[0].Flight.Airline)
+	require.Equal("Beta Air", tickets[1].Flight.Airline)
+	require.Equal("Alpha Air", tickets[2].Flight.Airline)
+}
+
+func (suite *ReserveTestSuite) TestReserve_InvalidatesDetailCache_Success() {
+	require := suite.Require()
+
+	suite.reservation.Provider = services.NewAggregator(services.NamedProvider{Name: "mock", Provider: &stubProvider{flight: suite.flight("Mock Air")}})
+
+	// The detail was looked up naming the provider, the booking does not.
+	require.NoError(suite.redisServer.Set("flight-detail-mock-7", `{"id":7}`))
+
+	require.Equal(http.StatusOK, suite.reserve("").Code)
+	require.False(suite.redisServer.Exists("flight-detail-mock-7"))
+}
+
+func (suite *ReserveTestSuite) TestReserve_ProviderRequired_Failure() {
+	require := suite.Require()
+
+	res := suite.reserve("")
+	require.Equal(http.StatusBadRequest, res.Code)
+	require.Equal(`"Provider is required"`, strings.TrimSpace(res.Body.String()))
+
+	res = suite.reserve("gamma")
+	require.Equal(http.StatusBadRequest, res.Code)
+	require.Equal(`"Unknown provider"`, strings.TrimSpace(res.Body.String()))
+}
+
+func TestReserve(t *testing.T) {
+	suite.Run(t, new(ReserveTestSuite))
+}
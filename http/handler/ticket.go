@@ -9,6 +9,7 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Ticket struct {
@@ -24,21 +25,31 @@ type CityResponse struct {
 	Name string `json:"name"`
 }
 
+type AirportResponse struct {
+	IATA string `json:"iata"`
+	ICAO string `json:"icao,omitempty"`
+	Name string `json:"name"`
+}
+
 type AirplaneResponse struct {
 	ID   int32  `json:"id"`
 	Name string `json:"name"`
 }
 
 type FlightResponse struct {
-	ID       int32            `json:"id"`
-	DepCity  CityResponse     `json:"dep_city"`
-	ArrCity  CityResponse     `json:"arr_city"`
-	DepTime  time.Time        `json:"dep_time"`
-	ArrTime  time.Time        `json:"arr_time"`
-	Airplane AirplaneResponse `json:"airplane"`
-	Airline  string           `json:"airline"`
-	Price    int32            `json:"price"`
-	CxlSitID int32            `json:"cxl_sit_id"`
+	ID           int32            `json:"id"`
+	DepCity      CityResponse     `json:"dep_city"`
+	ArrCity      CityResponse     `json:"arr_city"`
+	DepAirport   *AirportResponse `json:"dep_airport,omitempty"`
+	ArrAirport   *AirportResponse `json:"arr_airport,omitempty"`
+	DepTime      time.Time        `json:"dep_time"`
+	ArrTime      time.Time        `json:"arr_time"`
+	DepTimeLocal time.Time        `json:"dep_time_local"`
+	ArrTimeLocal time.Time        `json:"arr_time_local"`
+	Airplane     AirplaneResponse `json:"airplane"`
+	Airline      string           `json:"airline"`
+	Price        int32            `json:"price"`
+	CxlSitID     int32            `json:"cxl_sit_id"`
 }
 
 type TicketResponse struct {
@@ -92,24 +103,30 @@ func (t *Ticket) GetTickets(ctx echo.Context) error {
 
 		err = t.DB.Debug().Model(&models.Flight{}).
 			Joins("Airplane").
-			Joins("DepCity").Where("DepCity.id = ? ", ticket.Flight.DepCityID).
-			Joins("ArrCity").Where("ArrCity.id = ? ", ticket.Flight.ArrCityID).
-			Where("flights.id = ?", ticket.FID).
+			Joins("DepCity").Where(clause.Eq{Column: clause.Column{Table: "DepCity", Name: "id"}, Value: ticket.Flight.DepCityID}).
+			Joins("ArrCity").Where(clause.Eq{Column: clause.Column{Table: "ArrCity", Name: "id"}, Value: ticket.Flight.ArrCityID}).
+			Preload("DepAirport").
+			Preload("ArrAirport").
+			Where("flights.provider = ? AND flights.id = ?", ticket.Provider, ticket.FID).
 			First(&flight).Error
 		if err != nil {
 			return ctx.JSON(http.StatusInternalServerError, "Failed to Retrieve Flights")
 		}
 
 		flightResponse := FlightResponse{
-			ID:       flight.ID,
-			DepCity:  CityResponse{ID: flight.DepCity.ID, Name: flight.DepCity.Name},
-			ArrCity:  CityResponse{ID: flight.ArrCity.ID, Name: flight.ArrCity.Name},
-			DepTime:  flight.DepTime,
-			ArrTime:  flight.ArrTime,
-			Airplane: AirplaneResponse{ID: flight.Airplane.ID, Name: flight.Airplane.Name},
-			Airline:  flight.Airline,
-			Price:    flight.Price,
-			CxlSitID: flight.CxlSitID,
+			ID:           flight.ID,
+			DepCity:      CityResponse{ID: flight.DepCity.ID, Name: flight.DepCity.Name},
+			ArrCity:      CityResponse{ID: flight.ArrCity.ID, Name: flight.ArrCity.Name},
+			DepAirport:   newAirportResponse(flight.DepAirport),
+			ArrAirport:   newAirportResponse(flight.ArrAirport),
+			DepTime:      flight.DepTime.UTC(),
+			ArrTime:      flight.ArrTime.UTC(),
+			DepTimeLocal: flight.DepTime.In(cityLocation(flight.DepCity)),
+			ArrTimeLocal: flight.ArrTime.In(cityLocation(flight.ArrCity)),
+			Airplane:     AirplaneResponse{ID: flight.Airplane.ID, Name: flight.Airplane.Name},
+			Airline:      flight.Airline,
+			Price:        flight.Price,
+			CxlSitID:     flight.CxlSitID,
 		}
 
 		resp = append(resp, TicketResponse{
@@ -123,3 +140,31 @@ func (t *Ticket) GetTickets(ctx echo.Context) error {
 		Tickets: resp,
 	})
 }
+
+func newAirportResponse(airport *models.Airport) *AirportResponse {
+	if airport == nil {
+		return nil
+	}
+
+	resp := &AirportResponse{IATA: airport.IATA, Name: airport.Name}
+	if airport.ICAO != nil {
+		resp.ICAO = *airport.ICAO
+	}
+
+	return resp
+}
+
+// cityLocation returns the zone of a city, or UTC when it has none or an
+// unknown one.
+func cityLocation(city models.City) *time.Location {
+	if city.Timezone == "" {
+		return time.UTC
+	}
+
+	loc, err := time.LoadLocation(city.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
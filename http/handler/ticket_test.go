@@ -8,12 +8,14 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/suite"
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
@@ -140,3 +142,57 @@ func (suite *TicketTestSuite) TestTicket_Database_Failure() {
 	require.Equal(expectedStatusCode, res.Code)
 	require.Equal(expectedResponse, strings.TrimSpace(res.Body.String()))
 }
+
+// TicketPostgresTestSuite runs the ticket queries against the PostgreSQL
+// dialect, which folds unquoted identifiers such as join aliases to lower case.
+type TicketPostgresTestSuite struct {
+	suite.Suite
+	ticket  *Ticket
+	sqlMock sqlmock.Sqlmock
+	e       *echo.Echo
+}
+
+func (suite *TicketPostgresTestSuite) SetupTest() {
+	mockDB, sqlMock, err := sqlmock.New()
+	suite.Require().NoError(err)
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: mockDB}))
+	suite.Require().NoError(err)
+
+	suite.sqlMock = sqlMock
+	suite.e = echo.New()
+	suite.ticket = &Ticket{DB: db}
+}
+
+func (suite *TicketPostgresTestSuite) TestGetTickets_Success() {
+	require := suite.Require()
+
+	suite.sqlMock.ExpectQuery(`^SELECT \* FROM "tickets" WHERE u_id = \$1$`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "u_id", "p_ids", "f_id", "provider", "status", "price"}).
+			AddRow(100, 1, "1", 235, "mock", "paid", 120))
+	suite.sqlMock.ExpectQuery(`^SELECT \* FROM "flights" WHERE \("flights"\."provider","flights"\."id"\) IN \(\(\$1,\$2\)\)$`).
+		WithArgs("mock", 235).
+		WillReturnRows(sqlmock.NewRows([]string{"provider", "id", "dep_city_id", "arr_city_id"}).AddRow("mock", 235, 1, 10))
+	suite.sqlMock.ExpectQuery(`^SELECT \* FROM "users"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	suite.sqlMock.ExpectQuery(`^SELECT \* FROM "passengers"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "u_id", "name"}).AddRow(1, 1, "John Smith"))
+	suite.sqlMock.ExpectQuery(`FROM "flights" .* WHERE "DepCity"\."id" = \$1 AND "ArrCity"\."id" = \$2 AND \(flights\.provider = \$3 AND flights\.id = \$4\)`).
+		WithArgs(1, 10, "mock", 235).
+		WillReturnRows(sqlmock.NewRows([]string{"provider", "id", "airline", "DepCity__id", "DepCity__name", "ArrCity__id", "ArrCity__name"}).
+			AddRow("mock", 235, "QatarAirways", 1, "Tehran", 10, "Doha"))
+
+	res := httptest.NewRecorder()
+	ctx := suite.e.NewContext(httptest.NewRequest(http.MethodGet, "/tickets", nil), res)
+	ctx.Set("user_id", "1")
+
+	require.NoError(suite.ticket.GetTickets(ctx))
+	require.Equal(http.StatusOK, res.Code)
+	require.Contains(res.Body.String(), `"dep_city":{"id":1,"name":"Tehran"}`)
+	require.NoError(suite.sqlMock.ExpectationsWereMet())
+}
+
+func TestTicketPostgres(t *testing.T) {
+	suite.Run(t, new(TicketPostgresTestSuite))
+}
@@ -4,7 +4,14 @@ import (
 	"aliagha/config"
 	"aliagha/helpers"
 	"aliagha/models"
+	"aliagha/services"
+	"aliagha/utils"
 	"database/sql"
+	"errors"
+	"log"
+	"math"
+	"strconv"
+	"sync"
 	"time"
 
 	"net/http"
@@ -20,16 +27,26 @@ import (
 type User struct {
 	DB        *gorm.DB
 	JWT       *config.JWT
+	Keys      *helpers.KeySet
 	Validator *validator.Validate
+	Tokens    *services.TokenStore
+	Sessions  *services.Sessions
+	OTP       *services.OTPStore
+	Limiter   *services.LoginLimiter
+
+	PasswordResets     *services.PasswordResets
+	EmailVerifications *services.EmailVerifications
 }
 
 type LoginRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
+	Device   string `json:"device" validate:"max=100"`
 }
 
 type LoginResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
 }
 
 func (u *User) Login(ctx echo.Context) error {
@@ -42,11 +59,19 @@ func (u *User) Login(ctx echo.Context) error {
 		return ctx.JSON(http.StatusBadRequest, err.Error())
 	}
 
+	ip := ctx.RealIP()
+	if wait, err := u.Limiter.Check(req.Email, ip); err != nil {
+		return tooManyAttempts(ctx, wait, err)
+	}
+
 	var user models.User
 	err := u.DB.Where("email = ?", req.Email).First(&user).Error
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return ctx.JSON(http.StatusUnauthorized, "Invalid Credentials")
+		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, sql.ErrNoRows) {
+			// Compare against a dummy hash so unknown emails take as long
+			// as wrong passwords.
+			bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(req.Password))
+			return u.loginFailed(ctx, req.Email, ip)
 		} else {
 			return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
 		}
@@ -54,16 +79,26 @@ func (u *User) Login(ctx echo.Context) error {
 
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password))
 	if err != nil {
-		return ctx.JSON(http.StatusUnauthorized, "Invalid Credentials")
+		return u.loginFailed(ctx, req.Email, ip)
 	}
 
-	token, err := helpers.GenerateJwtToken(user.ID, user.Cellphone, u.JWT)
+	if err := u.Limiter.Success(req.Email); err != nil {
+		log.Printf("user: resetting login failures of user %d failed, error: %s", user.ID, err)
+	}
+
+	session, refreshToken, err := u.startSession(ctx, user.ID, req.Device)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	token, err := u.accessToken(&user, session.Family)
 	if err != nil {
 		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
 	}
 
 	return ctx.JSON(http.StatusOK, LoginResponse{
-		Token: token,
+		Token:        token,
+		RefreshToken: refreshToken,
 	})
 }
 
@@ -74,11 +109,16 @@ type RegisterRequest struct {
 	Password  string `json:"password" validate:"required,min=6,max=20"`
 }
 
+const registerMessage = "Check your email to continue, then log in"
+
 type RegisterResponse struct {
 	Message string `json:"message"`
-	Token   string `json:"token"`
 }
 
+// Register creates an account and mails a link to verify its email. It answers
+// the same whether or not the email is registered; the owner of a registered
+// email is told about the attempt by email instead. New users log in
+// afterwards.
 func (u *User) Register(ctx echo.Context) error {
 	var req RegisterRequest
 	if err := ctx.Bind(&req); err != nil {
@@ -89,51 +129,193 @@ func (u *User) Register(ctx echo.Context) error {
 		return ctx.JSON(http.StatusBadRequest, err.Error())
 	}
 
-	var user models.User
-	err := u.DB.Model(&models.User{}).Where("email = ?", req.Email).First(&user).Error
+	if wait, err := u.Limiter.Registration(ctx.RealIP()); err != nil {
+		return tooManyAttempts(ctx, wait, err)
+	}
 
-	if err == nil {
-		return ctx.JSON(http.StatusBadRequest, "Email already exists")
+	cellphone, err := utils.NormalizeCellphone(req.Cellphone)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, "Invalid cellphone")
 	}
 
+	var user models.User
+	err = u.DB.Model(&models.User{}).Where("email = ?", req.Email).First(&user).Error
 	if err != nil && err != gorm.ErrRecordNotFound {
 		return ctx.JSON(http.StatusInternalServerError, "Internal server error")
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
-	if err != nil {
+	// The password is hashed either way so registered emails take as long.
+	hashedPassword, hashErr := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if hashErr != nil {
 		return ctx.JSON(http.StatusInternalServerError, "Internal server error")
 	}
 
-	var token string
+	if err == nil {
+		// A failed send is only logged, as an error response would tell that
+		// the email is registered.
+		if err := u.EmailVerifications.SendRegistered(user.Email); err != nil {
+			log.Printf("user: sending registration notice to user %d failed, error: %s", user.ID, err)
+		}
+
+		return ctx.JSON(http.StatusCreated, RegisterResponse{Message: registerMessage})
+	}
+
 	err = u.DB.Transaction(func(tx *gorm.DB) error {
 		user = models.User{
 			Name:      req.Name,
-			Cellphone: req.Cellphone,
+			Cellphone: cellphone,
 			Email:     req.Email,
 			Password:  string(hashedPassword),
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 		}
 
-		if err := tx.Create(&user).Error; err != nil {
-			return err
+		return tx.Create(&user).Error
+	})
+
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	// The account works without a verified email, so a failed send is only
+	// logged; the user can ask for another link.
+	if err := u.EmailVerifications.Send(user.ID, user.Email); err != nil {
+		log.Printf("user: sending email verification to user %d failed, error: %s", user.ID, err)
+	}
+
+	return ctx.JSON(http.StatusCreated, RegisterResponse{Message: registerMessage})
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// Refresh exchanges a refresh token for a new access token and refresh token.
+// The presented refresh token can not be used again.
+func (u *User) Refresh(ctx echo.Context) error {
+	var req RefreshRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, "Bad Request")
+	}
+
+	if err := u.Validator.Struct(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	userID, family, refreshToken, err := u.Tokens.RotateRefreshToken(req.RefreshToken)
+	if errors.Is(err, services.ErrInvalidRefreshToken) || errors.Is(err, services.ErrRefreshTokenReused) {
+		return ctx.JSON(http.StatusUnauthorized, "Invalid refresh token")
+	}
+
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	var user models.User
+	err = u.DB.Where("id = ?", userID).First(&user).Error
+	if err == gorm.ErrRecordNotFound {
+		return ctx.JSON(http.StatusUnauthorized, "Invalid refresh token")
+	}
+
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	// Last seen is only informational, so a failed update is only logged.
+	if err := u.Sessions.Seen(family, ctx.RealIP(), time.Now()); err != nil {
+		log.Printf("user: updating the session of user %d failed, error: %s", user.ID, err)
+	}
+
+	token, err := u.accessToken(&user, family)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	return ctx.JSON(http.StatusOK, LoginResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+	})
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Logout revokes the access token and the session of the request and, when
+// given, the family of the refresh token.
+func (u *User) Logout(ctx echo.Context) error {
+	var req LogoutRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, "Bad Request")
+	}
+
+	tokenID, _ := ctx.Get("token_id").(string)
+	expiresAt, _ := ctx.Get("token_expires_at").(time.Time)
+	if err := u.Tokens.RevokeAccessToken(tokenID, expiresAt); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	if sessionID, _ := ctx.Get("session_id").(string); sessionID != "" {
+		if err := u.Sessions.RevokeFamily(sessionID, time.Now()); err != nil {
+			return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
 		}
+	}
 
-		token, err = helpers.GenerateJwtToken(user.ID, user.Cellphone, u.JWT)
-		if err != nil {
-			return err
+	if req.RefreshToken != "" {
+		if err := u.Tokens.RevokeRefreshToken(req.RefreshToken); err != nil {
+			return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
 		}
+	}
 
-		return nil
-	})
+	return ctx.NoContent(http.StatusNoContent)
+}
 
+// accessToken returns an access token of the session carrying the roles and
+// permissions of the user.
+func (u *User) accessToken(user *models.User, sessionID string) (string, error) {
+	roles, permissions, err := services.UserRoles(u.DB, user.ID)
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+		return "", err
 	}
 
-	return ctx.JSON(http.StatusCreated, RegisterResponse{
-		Message: "User created successfully",
-		Token:   token,
+	return helpers.GenerateJwtToken(user.ID, user.Cellphone, sessionID, roles, permissions, u.Keys, u.JWT.ExpiresIn)
+}
+
+// startSession records the session of a login from the request and returns
+// it along with its refresh token.
+func (u *User) startSession(ctx echo.Context, userID int32, device string) (*models.Session, string, error) {
+	info := services.SessionInfo{Device: device, IP: ctx.RealIP(), UserAgent: ctx.Request().UserAgent()}
+	return u.Sessions.Start(userID, info, time.Now())
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+func dummyPasswordHash() []byte {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
 	})
+
+	return dummyHash
+}
+
+// loginFailed records the failure and answers the same whether or not the
+// email belongs to a user.
+func (u *User) loginFailed(ctx echo.Context, email, ip string) error {
+	if err := u.Limiter.Failure(email, ip); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	return ctx.JSON(http.StatusUnauthorized, "Invalid Credentials")
+}
+
+func tooManyAttempts(ctx echo.Context, wait time.Duration, err error) error {
+	if !errors.Is(err, services.ErrTooManyAttempts) {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	ctx.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	return ctx.JSON(http.StatusTooManyRequests, "Too many attempts, try again later")
 }
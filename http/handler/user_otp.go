@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"aliagha/models"
+	"aliagha/services"
+	"aliagha/utils"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+type OTPRequest struct {
+	Cellphone string `json:"cellphone" validate:"required"`
+}
+
+type OTPRequestResponse struct {
+	Message   string `json:"message"`
+	ExpiresIn int    `json:"expires_in"`
+}
+
+// RequestOTP sends a login code to the cellphone. It answers the same whether
+// or not the cellphone belongs to a user.
+func (u *User) RequestOTP(ctx echo.Context) error {
+	var req OTPRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, "Bad Request")
+	}
+
+	if err := u.Validator.Struct(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	cellphone, err := utils.NormalizeCellphone(req.Cellphone)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, "Invalid cellphone")
+	}
+
+	err = u.OTP.Request(cellphone)
+	if errors.Is(err, services.ErrOTPResendTooSoon) || errors.Is(err, services.ErrOTPSendLimit) {
+		return ctx.JSON(http.StatusTooManyRequests, "Too many codes requested, try again later")
+	}
+
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	return ctx.JSON(http.StatusOK, OTPRequestResponse{
+		Message:   "Code sent",
+		ExpiresIn: int(u.OTP.TTL / time.Second),
+	})
+}
+
+type OTPVerifyRequest struct {
+	Cellphone string `json:"cellphone" validate:"required"`
+	Code      string `json:"code" validate:"required,numeric"`
+	// Name is used when the cellphone has no account yet and one is created.
+	Name   string `json:"name" validate:"omitempty,min=3,max=100"`
+	Device string `json:"device" validate:"max=100"`
+}
+
+type OTPSignupResponse struct {
+	Message      string `json:"message"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// VerifyOTP logs in the user who verified the cellphone, or signs them up when
+// no account verified it yet.
+func (u *User) VerifyOTP(ctx echo.Context) error {
+	var req OTPVerifyRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, "Bad Request")
+	}
+
+	if err := u.Validator.Struct(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	cellphone, err := utils.NormalizeCellphone(req.Cellphone)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, "Invalid cellphone")
+	}
+
+	err = u.OTP.Verify(cellphone, req.Code)
+	if errors.Is(err, services.ErrInvalidOTP) {
+		return ctx.JSON(http.StatusUnauthorized, "Invalid code")
+	}
+
+	if errors.Is(err, services.ErrOTPAttemptsExceeded) {
+		return ctx.JSON(http.StatusTooManyRequests, "Too many wrong codes, request a new one")
+	}
+
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	// Only accounts that verified the cellphone can be logged into with it;
+	// any account can claim a cellphone, so others get a new account instead.
+	// Cellphones are not unique, so the oldest account wins.
+	now := time.Now()
+	var user models.User
+	err = u.DB.Where("cellphone = ? AND cellphone_verified_at IS NOT NULL AND deleted_at IS NULL", cellphone).
+		Order("id").First(&user).Error
+	created := false
+	if err == gorm.ErrRecordNotFound {
+		// The account has no email or password until the user sets them.
+		user = models.User{
+			Name:                req.Name,
+			Cellphone:           cellphone,
+			CellphoneVerifiedAt: &now,
+			VerifiedAt:          &now,
+			CreatedAt:           now,
+			UpdatedAt:           now,
+		}
+		err = u.DB.Omit("Email").Create(&user).Error
+		created = true
+	}
+
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	session, refreshToken, err := u.startSession(ctx, user.ID, req.Device)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	token, err := u.accessToken(&user, session.Family)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	if created {
+		return ctx.JSON(http.StatusCreated, OTPSignupResponse{
+			Message:      "User created successfully",
+			Token:        token,
+			RefreshToken: refreshToken,
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, LoginResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+	})
+}
@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"aliagha/config"
+	"aliagha/database"
+	"aliagha/helpers"
+	"aliagha/models"
+	"aliagha/services"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type recordingSMSSender struct {
+	messages []string
+}
+
+func (s *recordingSMSSender) Send(to, message string) error {
+	s.messages = append(s.messages, to+": "+message)
+	return nil
+}
+
+type UserOTPTestSuite struct {
+	suite.Suite
+	redisServer *miniredis.Miniredis
+	db          *gorm.DB
+	sender      *recordingSMSSender
+	e           *echo.Echo
+	user        *User
+}
+
+func (suite *UserOTPTestSuite) SetupTest() {
+	server, redis := database.NewRedisMock()
+	suite.redisServer = server
+	suite.db = database.NewSQLiteMock()
+	suite.sender = &recordingSMSSender{}
+
+	tokens := &services.TokenStore{Redis: redis, AccessTTL: time.Hour, RefreshTTL: time.Hour}
+	suite.user = &User{
+		DB:        suite.db,
+		JWT:       &config.JWT{SecretKey: "secretkey", ExpiresIn: time.Hour},
+		Keys:      helpers.NewHMACKeySet("secretkey"),
+		Validator: validator.New(),
+		Tokens:    tokens,
+		Sessions:  &services.Sessions{DB: suite.db, Tokens: tokens},
+		OTP: &services.OTPStore{
+			Redis:          redis,
+			Sender:         suite.sender,
+			Secret:         "secret",
+			Length:         6,
+			TTL:            2 * time.Minute,
+			MaxAttempts:    3,
+			ResendInterval: time.Minute,
+			MaxSends:       5,
+			SendWindow:     time.Hour,
+		},
+	}
+	suite.e = echo.New()
+}
+
+func (suite *UserOTPTestSuite) TearDownTest() {
+	suite.redisServer.Close()
+}
+
+func (suite *UserOTPTestSuite) call(handler echo.HandlerFunc, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	res := httptest.NewRecorder()
+
+	suite.Require().NoError(handler(suite.e.NewContext(req, res)))
+	return res
+}
+
+// login requests a code for the cellphone and verifies it.
+func (suite *UserOTPTestSuite) login(cellphone string) *httptest.ResponseRecorder {
+	require := suite.Require()
+
+	res := suite.call(suite.user.RequestOTP, `{"cellphone":"`+cellphone+`"}`)
+	require.Equal(http.StatusOK, res.Code)
+	require.NotEmpty(suite.sender.messages)
+	code := regexp.MustCompile(`\d{6}$`).FindString(suite.sender.messages[len(suite.sender.messages)-1])
+
+	return suite.call(suite.user.VerifyOTP, `{"cellphone":"`+cellphone+`","code":"`+code+`","name":"Traveler"}`)
+}
+
+func (suite *UserOTPTestSuite) tokenUser(body []byte) int32 {
+	var response LoginResponse
+	suite.Require().NoError(json.Unmarshal(body, &response))
+	claims, err := helpers.ParseJWTClaims(response.Token, suite.user.Keys)
+	suite.Require().NoError(err)
+	return claims.UserID
+}
+
+func (suite *UserOTPTestSuite) TestRequestOTP_ResendTooSoon_Failure() {
+	require := suite.Require()
+
+	res := suite.call(suite.user.RequestOTP, `{"cellphone":"+989121234567"}`)
+	require.Equal(http.StatusOK, res.Code)
+	require.Len(suite.sender.messages, 1)
+	require.True(strings.HasPrefix(suite.sender.messages[0], "09121234567: "))
+
+	res = suite.call(suite.user.RequestOTP, `{"cellphone":"09121234567"}`)
+	require.Equal(http.StatusTooManyRequests, res.Code)
+	require.Len(suite.sender.messages, 1)
+
+	res = suite.call(suite.user.RequestOTP, `{"cellphone":"12345"}`)
+	require.Equal(http.StatusBadRequest, res.Code)
+}
+
+func (suite *UserOTPTestSuite) TestVerifyOTP_InvalidCode_Failure() {
+	require := suite.Require()
+
+	res := suite.call(suite.user.RequestOTP, `{"cellphone":"09121234567"}`)
+	require.Equal(http.StatusOK, res.Code)
+
+	res = suite.call(suite.user.VerifyOTP, `{"cellphone":"09121234567","code":"000000"}`)
+	require.Equal(http.StatusUnauthorized, res.Code)
+}
+
+func (suite *UserOTPTestSuite) TestVerifyOTP_VerifiedUser_Success() {
+	require := suite.Require()
+
+	verifiedAt := time.Now().Add(-time.Hour)
+	user := models.User{Name: "Traveler", Cellphone: "09121234567", Email: "traveler@example.com", CellphoneVerifiedAt: &verifiedAt}
+	require.NoError(suite.db.Create(&user).Error)
+
+	res := suite.login("09121234567")
+	require.Equal(http.StatusOK, res.Code)
+	require.Equal(user.ID, suite.tokenUser(res.Body.Bytes()))
+}
+
+func (suite *UserOTPTestSuite) TestVerifyOTP_SignUp_Success() {
+	require := suite.Require()
+
+	res := suite.login("09121234567")
+	require.Equal(http.StatusCreated, res.Code)
+
+	var user models.User
+	require.NoError(suite.db.First(&user, suite.tokenUser(res.Body.Bytes())).Error)
+	require.Equal("Traveler", user.Name)
+	require.Equal("09121234567", user.Cellphone)
+	require.NotNil(user.CellphoneVerifiedAt)
+}
+
+func (suite *UserOTPTestSuite) TestVerifyOTP_UnverifiedUser_Success() {
+	require := suite.Require()
+
+	// Anyone can register with a cellphone they do not own; the owner of the
+	// cellphone must not get into that account.
+	claimed := models.User{Name: "Someone else", Cellphone: "09121234567", Email: "someone@example.com"}
+	require.NoError(suite.db.Create(&claimed).Error)
+
+	deletedAt := time.Now().Add(-time.Hour)
+	deleted := models.User{Name: "Deleted", Cellphone: "09121234567", Email: "deleted@example.com", CellphoneVerifiedAt: &deletedAt, DeletedAt: &deletedAt}
+	require.NoError(suite.db.Create(&deleted).Error)
+
+	res := suite.login("09121234567")
+	require.Equal(http.StatusCreated, res.Code)
+
+	userID := suite.tokenUser(res.Body.Bytes())
+	require.NotEqual(claimed.ID, userID)
+	require.NotEqual(deleted.ID, userID)
+
+	require.NoError(suite.db.First(&claimed, claimed.ID).Error)
+	require.Nil(claimed.CellphoneVerifiedAt)
+}
+
+func TestUserOTP(t *testing.T) {
+	suite.Run(t, new(UserOTPTestSuite))
+}
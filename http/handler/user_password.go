@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"aliagha/models"
+	"aliagha/services"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ForgotPassword mails a password reset link. It answers the same whether or
+// not the email belongs to a user.
+func (u *User) ForgotPassword(ctx echo.Context) error {
+	var req ForgotPasswordRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, "Bad Request")
+	}
+
+	if err := u.Validator.Struct(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	var user models.User
+	err := u.DB.Where("email = ?", req.Email).First(&user).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	if err == nil {
+		// A failed send is only logged, as an error response would tell that
+		// the email is registered.
+		if err := u.PasswordResets.Send(user.ID, user.Email); err != nil {
+			log.Printf("user: sending password reset to user %d failed, error: %s", user.ID, err)
+		}
+	}
+
+	return ctx.JSON(http.StatusOK, "If the email is registered, a reset link was sent to it")
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=6,max=20"`
+}
+
+// ResetPassword sets a new password with a reset token and logs the user out
+// everywhere.
+func (u *User) ResetPassword(ctx echo.Context) error {
+	var req ResetPasswordRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, "Bad Request")
+	}
+
+	if err := u.Validator.Struct(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	userID, err := u.PasswordResets.Consume(req.Token)
+	if errors.Is(err, services.ErrInvalidResetToken) {
+		return ctx.JSON(http.StatusBadRequest, "Invalid or expired token")
+	}
+
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	if err := u.setPassword(userID, req.Password); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	return ctx.JSON(http.StatusOK, "Password changed successfully")
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=6,max=20"`
+	// Device names the new session, as every session is ended.
+	Device string `json:"device" validate:"max=100"`
+}
+
+// ChangePassword replaces the password of the logged in user. Every other
+// session is logged out; the response carries new tokens for this one.
+func (u *User) ChangePassword(ctx echo.Context) error {
+	UID, err := strconv.Atoi(ctx.Get("user_id").(string))
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	var req ChangePasswordRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, "Bad Request")
+	}
+
+	if err := u.Validator.Struct(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	var user models.User
+	if err := u.DB.Where("id = ?", UID).First(&user).Error; err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.CurrentPassword)); err != nil {
+		return ctx.JSON(http.StatusUnauthorized, "Invalid Credentials")
+	}
+
+	if err := u.setPassword(user.ID, req.NewPassword); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	session, refreshToken, err := u.startSession(ctx, user.ID, req.Device)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	token, err := u.accessToken(&user, session.Family)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	return ctx.JSON(http.StatusOK, LoginResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+	})
+}
+
+// setPassword stores the new password and revokes every token of the user,
+// including a reset link mailed earlier.
+func (u *User) setPassword(userID int32, password string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	err = u.DB.Model(&models.User{}).Where("id = ?", userID).
+		Updates(map[string]interface{}{"password": string(hashedPassword), "updated_at": time.Now()}).Error
+	if err != nil {
+		return err
+	}
+
+	if err := u.PasswordResets.Revoke(userID); err != nil {
+		return err
+	}
+
+	return u.Tokens.RevokeUserTokens(userID)
+}
@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"aliagha/config"
+	"aliagha/database"
+	"aliagha/helpers"
+	"aliagha/services"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+type sentMail struct {
+	to, body string
+}
+
+type recordingMailer struct {
+	sent []sentMail
+}
+
+func (m *recordingMailer) Send(to, subject, body string) error {
+	m.sent = append(m.sent, sentMail{to: to, body: body})
+	return nil
+}
+
+type UserPasswordTestSuite struct {
+	suite.Suite
+	sqlMock     sqlmock.Sqlmock
+	redisServer *miniredis.Miniredis
+	mailer      *recordingMailer
+	e           *echo.Echo
+	user        *User
+}
+
+func (suite *UserPasswordTestSuite) SetupSuite() {
+	require := suite.Require()
+
+	mockDB, sqlMock, err := sqlmock.New()
+	require.NoError(err)
+
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: mockDB, SkipInitializeWithVersion: true}))
+	require.NoError(err)
+
+	server, redis := database.NewRedisMock()
+	tokens := &services.TokenStore{Redis: redis, AccessTTL: time.Hour, RefreshTTL: time.Hour}
+	suite.sqlMock = sqlMock
+	suite.redisServer = server
+	suite.mailer = &recordingMailer{}
+	suite.user = &User{
+		DB:        db,
+		JWT:       &config.JWT{SecretKey: "secretkey", ExpiresIn: time.Hour},
+		Keys:      helpers.NewHMACKeySet("secretkey"),
+		Validator: validator.New(),
+		Tokens:    tokens,
+		Sessions:  &services.Sessions{DB: db, Tokens: tokens},
+		PasswordResets: &services.PasswordResets{
+			Redis:  redis,
+			Mailer: suite.mailer,
+			TTL:    time.Hour,
+			URL:    "https://aliagha.test/reset-password",
+		},
+	}
+	suite.e = echo.New()
+}
+
+func (suite *UserPasswordTestSuite) SetupTest() {
+	suite.redisServer.FlushAll()
+	suite.mailer.sent = nil
+}
+
+func (suite *UserPasswordTestSuite) TearDownSuite() {
+	suite.redisServer.Close()
+}
+
+func (suite *UserPasswordTestSuite) call(handler echo.HandlerFunc, body string, userID string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	res := httptest.NewRecorder()
+	c := suite.e.NewContext(req, res)
+	if userID != "" {
+		c.Set("user_id", userID)
+	}
+
+	suite.Require().NoError(handler(c))
+	return res
+}
+
+func (suite *UserPasswordTestSuite) TestForgotPassword_UnknownEmail_Success() {
+	require := suite.Require()
+
+	suite.sqlMock.ExpectQuery("^SELECT (.+) FROM `users` WHERE email = (.+)").
+		WithArgs("nobody@example.com").
+		WillReturnError(gorm.ErrRecordNotFound)
+
+	res := suite.call(suite.user.ForgotPassword, `{"email":"nobody@example.com"}`, "")
+	require.Equal(http.StatusOK, res.Code)
+	require.Empty(suite.mailer.sent)
+	require.NoError(suite.sqlMock.ExpectationsWereMet())
+}
+
+func (suite *UserPasswordTestSuite) TestResetPassword_Success() {
+	require := suite.Require()
+
+	suite.sqlMock.ExpectQuery("^SELECT (.+) FROM `users` WHERE email = (.+)").
+		WithArgs("user@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(7, "user@example.com"))
+
+	res := suite.call(suite.user.ForgotPassword, `{"email":"user@example.com"}`, "")
+	require.Equal(http.StatusOK, res.Code)
+	require.Len(suite.mailer.sent, 1)
+	require.Equal("user@example.com", suite.mailer.sent[0].to)
+
+	token := strings.SplitN(suite.mailer.sent[0].body, "?token=", 2)[1]
+	token = strings.Fields(token)[0]
+
+	_, refreshToken, err := suite.user.Tokens.StartRefreshFamily(7)
+	require.NoError(err)
+
+	suite.sqlMock.ExpectBegin()
+	suite.sqlMock.ExpectExec("UPDATE `users` SET (.+) WHERE id = (.+)").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.sqlMock.ExpectCommit()
+
+	body := `{"token":"` + token + `","password":"new-password"}`
+	res = suite.call(suite.user.ResetPassword, body, "")
+	require.Equal(http.StatusOK, res.Code)
+	require.NoError(suite.sqlMock.ExpectationsWereMet())
+
+	_, _, _, err = suite.user.Tokens.RotateRefreshToken(refreshToken)
+	require.ErrorIs(err, services.ErrInvalidRefreshToken)
+
+	res = suite.call(suite.user.ResetPassword, body, "")
+	require.Equal(http.StatusBadRequest, res.Code)
+	require.Equal(`"Invalid or expired token"`, strings.TrimSpace(res.Body.String()))
+}
+
+func (suite *UserPasswordTestSuite) TestChangePassword_WrongPassword_Failure() {
+	require := suite.Require()
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte("current-password"), bcrypt.MinCost)
+	require.NoError(err)
+
+	suite.sqlMock.ExpectQuery("^SELECT (.+) FROM `users` WHERE id = (.+)").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "password"}).AddRow(7, string(hashed)))
+
+	res := suite.call(suite.user.ChangePassword, `{"current_password":"wrong","new_password":"new-password"}`, "7")
+	require.Equal(http.StatusUnauthorized, res.Code)
+	require.NoError(suite.sqlMock.ExpectationsWereMet())
+}
+
+func (suite *UserPasswordTestSuite) TestChangePassword_Success() {
+	require := suite.Require()
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte("current-password"), bcrypt.MinCost)
+	require.NoError(err)
+
+	// A reset link mailed before the change stops working.
+	require.NoError(suite.user.PasswordResets.Send(7, "user@example.com"))
+	resetToken := strings.Fields(strings.SplitN(suite.mailer.sent[0].body, "?token=", 2)[1])[0]
+
+	suite.sqlMock.ExpectQuery("^SELECT (.+) FROM `users` WHERE id = (.+)").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "password", "cellphone"}).AddRow(7, string(hashed), "09121234567"))
+	suite.sqlMock.ExpectBegin()
+	suite.sqlMock.ExpectExec("UPDATE `users` SET (.+) WHERE id = (.+)").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.sqlMock.ExpectCommit()
+
+	suite.sqlMock.ExpectBegin()
+	suite.sqlMock.ExpectExec("DELETE FROM `sessions` WHERE u_id = (.+)").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.sqlMock.ExpectCommit()
+	suite.sqlMock.ExpectBegin()
+	suite.sqlMock.ExpectExec("INSERT INTO `sessions`").
+		WithArgs(7, sqlmock.AnyArg(), "Pixel 7", "192.0.2.1", "", nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	suite.sqlMock.ExpectCommit()
+
+	suite.sqlMock.ExpectQuery("^SELECT roles.name, role_permissions.permission FROM `user_roles`").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "permission"}).AddRow("agent", "users:read"))
+
+	res := suite.call(suite.user.ChangePassword, `{"current_password":"current-password","new_password":"new-password","device":"Pixel 7"}`, "7")
+	require.Equal(http.StatusOK, res.Code)
+	require.Contains(res.Body.String(), `"refresh_token":"`)
+	require.NoError(suite.sqlMock.ExpectationsWereMet())
+
+	var response LoginResponse
+	require.NoError(json.Unmarshal(res.Body.Bytes(), &response))
+	claims, err := helpers.ParseJWTClaims(response.Token, suite.user.Keys)
+	require.NoError(err)
+	require.Equal([]string{"agent"}, claims.Roles)
+	require.Equal([]string{"users:read"}, claims.Permissions)
+
+	family, err := suite.user.Tokens.RefreshTokenFamily(response.RefreshToken)
+	require.NoError(err)
+	require.Equal(family, claims.SessionID)
+
+	revoked, err := suite.user.Tokens.IsAccessTokenRevoked(7, "jti", time.Now().Add(-time.Second))
+	require.NoError(err)
+	require.True(revoked)
+
+	// The token returned with the new password is issued after the revocation.
+	revoked, err = suite.user.Tokens.IsAccessTokenRevoked(7, claims.Id, claims.IssueTime())
+	require.NoError(err)
+	require.False(revoked)
+
+	_, err = suite.user.PasswordResets.Consume(resetToken)
+	require.ErrorIs(err, services.ErrInvalidResetToken)
+}
+
+func TestUserPassword(t *testing.T) {
+	suite.Run(t, new(UserPasswordTestSuite))
+}
@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"aliagha/models"
+	"aliagha/services"
+	"aliagha/utils"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+type ProfileResponse struct {
+	ID                int32     `json:"id"`
+	Name              string    `json:"name"`
+	Cellphone         string    `json:"cellphone"`
+	Email             string    `json:"email"`
+	EmailVerified     bool      `json:"email_verified"`
+	CellphoneVerified bool      `json:"cellphone_verified"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+func newProfileResponse(user *models.User) ProfileResponse {
+	return ProfileResponse{
+		ID:                user.ID,
+		Name:              user.Name,
+		Cellphone:         user.Cellphone,
+		Email:             user.Email,
+		EmailVerified:     user.EmailVerifiedAt != nil,
+		CellphoneVerified: user.CellphoneVerifiedAt != nil,
+		CreatedAt:         user.CreatedAt,
+	}
+}
+
+type UpdateProfileRequest struct {
+	Name      *string `json:"name" validate:"omitempty,min=3,max=100"`
+	Cellphone *string `json:"cellphone"`
+	Email     *string `json:"email" validate:"omitempty,email"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+}
+
+// currentUser loads the logged in user.
+func (u *User) currentUser(ctx echo.Context) (*models.User, error) {
+	UID, err := strconv.Atoi(ctx.Get("user_id").(string))
+	if err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if err := u.DB.Where("id = ? AND deleted_at IS NULL", UID).First(&user).Error; err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// currentUserFailed answers a request whose user could not be loaded. A user
+// that was deleted makes its token invalid.
+func currentUserFailed(ctx echo.Context, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ctx.JSON(http.StatusUnauthorized, "Invalid token")
+	}
+
+	return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+}
+
+// GetProfile returns the profile of the logged in user.
+func (u *User) GetProfile(ctx echo.Context) error {
+	user, err := u.currentUser(ctx)
+	if err != nil {
+		return currentUserFailed(ctx, err)
+	}
+
+	return ctx.JSON(http.StatusOK, newProfileResponse(user))
+}
+
+// UpdateProfile changes the name, cellphone or email of the logged in user.
+// A changed cellphone or email has to be verified again; a verification link
+// is mailed to a new email right away. An email of another account is not
+// taken, but answers the same and its owner is told by email instead.
+func (u *User) UpdateProfile(ctx echo.Context) error {
+	user, err := u.currentUser(ctx)
+	if err != nil {
+		return currentUserFailed(ctx, err)
+	}
+
+	var req UpdateProfileRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, "Bad Request")
+	}
+
+	// The email is what users log in with, so it can be changed but not
+	// cleared.
+	if req.Email != nil && *req.Email == "" {
+		return ctx.JSON(http.StatusBadRequest, "Email is required")
+	}
+
+	if err := u.Validator.Struct(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{}
+	if req.Name != nil && *req.Name != user.Name {
+		updates["name"] = *req.Name
+		user.Name = *req.Name
+	}
+
+	if req.Cellphone != nil {
+		cellphone, err := utils.NormalizeCellphone(*req.Cellphone)
+		if err != nil {
+			return ctx.JSON(http.StatusBadRequest, "Invalid cellphone")
+		}
+
+		if cellphone != user.Cellphone {
+			updates["cellphone"] = cellphone
+			updates["cellphone_verified_at"] = nil
+			user.Cellphone = cellphone
+			user.CellphoneVerifiedAt = nil
+		}
+	}
+
+	emailChanged := req.Email != nil && *req.Email != user.Email
+	if emailChanged {
+		var owner models.User
+		err := u.DB.Where("email = ? AND id <> ?", *req.Email, user.ID).First(&owner).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+		}
+
+		if err == nil {
+			emailChanged = false
+			if err := u.EmailVerifications.SendRegistered(owner.Email); err != nil {
+				log.Printf("user: sending registration notice to user %d failed, error: %s", owner.ID, err)
+			}
+		} else {
+			updates["email"] = *req.Email
+			updates["email_verified_at"] = nil
+		}
+
+		user.Email = *req.Email
+		user.EmailVerifiedAt = nil
+	}
+
+	if len(updates) == 0 {
+		return ctx.JSON(http.StatusOK, newProfileResponse(user))
+	}
+
+	updates["updated_at"] = now
+	if err := u.DB.Model(&models.User{}).Where("id = ?", user.ID).Updates(updates).Error; err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	if emailChanged {
+		if err := u.EmailVerifications.Send(user.ID, user.Email); err != nil {
+			log.Printf("user: sending verification email to user %d failed, error: %s", user.ID, err)
+		}
+	}
+
+	return ctx.JSON(http.StatusOK, newProfileResponse(user))
+}
+
+// DeleteAccount anonymizes the logged in user and logs them out everywhere.
+// Accounts with a password have to confirm it.
+func (u *User) DeleteAccount(ctx echo.Context) error {
+	user, err := u.currentUser(ctx)
+	if err != nil {
+		return currentUserFailed(ctx, err)
+	}
+
+	var req DeleteAccountRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, "Bad Request")
+	}
+
+	if user.Password != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+			return ctx.JSON(http.StatusUnauthorized, "Invalid Credentials")
+		}
+	}
+
+	if err := u.Tokens.RevokeUserTokens(user.ID); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	if err := services.AnonymizeUser(u.DB, user.ID, time.Now()); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"aliagha/config"
+	"aliagha/database"
+	"aliagha/services"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+type UserProfileTestSuite struct {
+	suite.Suite
+	sqlMock     sqlmock.Sqlmock
+	redisServer *miniredis.Miniredis
+	mailer      *recordingMailer
+	e           *echo.Echo
+	user        *User
+}
+
+func (suite *UserProfileTestSuite) SetupSuite() {
+	require := suite.Require()
+
+	mockDB, sqlMock, err := sqlmock.New()
+	require.NoError(err)
+
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: mockDB, SkipInitializeWithVersion: true}))
+	require.NoError(err)
+
+	server, redis := database.NewRedisMock()
+	suite.sqlMock = sqlMock
+	suite.redisServer = server
+	suite.mailer = &recordingMailer{}
+	suite.user = &User{
+		DB:        db,
+		JWT:       &config.JWT{SecretKey: "secretkey", ExpiresIn: time.Hour},
+		Validator: validator.New(),
+		Tokens:    &services.TokenStore{Redis: redis, AccessTTL: time.Hour, RefreshTTL: time.Hour},
+		EmailVerifications: &services.EmailVerifications{
+			Redis:  redis,
+			Mailer: suite.mailer,
+			TTL:    time.Hour,
+			URL:    "https://aliagha.test/verify-email",
+		},
+	}
+	suite.e = echo.New()
+}
+
+func (suite *UserProfileTestSuite) SetupTest() {
+	suite.redisServer.FlushAll()
+	suite.mailer.sent = nil
+}
+
+func (suite *UserProfileTestSuite) TearDownSuite() {
+	suite.redisServer.Close()
+}
+
+func (suite *UserProfileTestSuite) call(handler echo.HandlerFunc, method, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/user/me", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	res := httptest.NewRecorder()
+	c := suite.e.NewContext(req, res)
+	c.Set("user_id", "7")
+
+	suite.Require().NoError(handler(c))
+	return res
+}
+
+func (suite *UserProfileTestSuite) expectUser(password string) {
+	verifiedAt := time.Now()
+	suite.sqlMock.ExpectQuery("^SELECT (.+) FROM `users` WHERE id = (.+) AND deleted_at IS NULL").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "password", "cellphone", "email", "email_verified_at", "cellphone_verified_at"}).
+			AddRow(7, "John Doe", password, "09121234567", "john@example.com", verifiedAt, verifiedAt))
+}
+
+func (suite *UserProfileTestSuite) TestGetProfile_Success() {
+	require := suite.Require()
+
+	suite.expectUser("")
+
+	res := suite.call(suite.user.GetProfile, http.MethodGet, "")
+	require.Equal(http.StatusOK, res.Code)
+
+	var profile ProfileResponse
+	require.NoError(json.Unmarshal(res.Body.Bytes(), &profile))
+	require.Equal(int32(7), profile.ID)
+	require.Equal("john@example.com", profile.Email)
+	require.True(profile.EmailVerified)
+	require.True(profile.CellphoneVerified)
+	require.NotContains(res.Body.String(), "password")
+	require.NoError(suite.sqlMock.ExpectationsWereMet())
+}
+
+func (suite *UserProfileTestSuite) TestProfile_DeletedUser_Failure() {
+	require := suite.Require()
+
+	handlers := []struct {
+		handler echo.HandlerFunc
+		method  string
+	}{
+		{suite.user.GetProfile, http.MethodGet},
+		{suite.user.UpdateProfile, http.MethodPatch},
+		{suite.user.DeleteAccount, http.MethodDelete},
+	}
+	for _, h := range handlers {
+		suite.sqlMock.ExpectQuery("^SELECT (.+) FROM `users` WHERE id = (.+) AND deleted_at IS NULL").
+			WithArgs(7).
+			WillReturnError(gorm.ErrRecordNotFound)
+
+		res := suite.call(h.handler, h.method, `{}`)
+		require.Equal(http.StatusUnauthorized, res.Code)
+		require.Equal(`"Invalid token"`, strings.TrimSpace(res.Body.String()))
+	}
+	require.NoError(suite.sqlMock.ExpectationsWereMet())
+}
+
+func (suite *UserProfileTestSuite) TestUpdateProfile_ChangeEmail_Success() {
+	require := suite.Require()
+
+	suite.expectUser("")
+	suite.sqlMock.ExpectQuery("^SELECT (.+) FROM `users` WHERE email = (.+) AND id <> (.+)").
+		WithArgs("new@example.com", 7).
+		WillReturnError(gorm.ErrRecordNotFound)
+	suite.sqlMock.ExpectBegin()
+	suite.sqlMock.ExpectExec("UPDATE `users` SET (.+) WHERE id = (.+)").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.sqlMock.ExpectCommit()
+
+	res := suite.call(suite.user.UpdateProfile, http.MethodPatch, `{"email":"new@example.com","cellphone":"+989121234567"}`)
+	require.Equal(http.StatusOK, res.Code)
+	require.NoError(suite.sqlMock.ExpectationsWereMet())
+
+	var profile ProfileResponse
+	require.NoError(json.Unmarshal(res.Body.Bytes(), &profile))
+	require.Equal("new@example.com", profile.Email)
+	require.False(profile.EmailVerified)
+	require.Equal("09121234567", profile.Cellphone)
+	require.True(profile.CellphoneVerified)
+
+	require.Len(suite.mailer.sent, 1)
+	require.Equal("new@example.com", suite.mailer.sent[0].to)
+}
+
+func (suite *UserProfileTestSuite) TestUpdateProfile_EmailExists_Success() {
+	require := suite.Require()
+
+	// The email is not taken, but the answer matches a change.
+	suite.expectUser("")
+	suite.sqlMock.ExpectQuery("^SELECT (.+) FROM `users` WHERE email = (.+) AND id <> (.+)").
+		WithArgs("taken@example.com", 7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(8, "taken@example.com"))
+
+	res := suite.call(suite.user.UpdateProfile, http.MethodPatch, `{"email":"taken@example.com"}`)
+	require.Equal(http.StatusOK, res.Code)
+	require.NoError(suite.sqlMock.ExpectationsWereMet())
+
+	var profile ProfileResponse
+	require.NoError(json.Unmarshal(res.Body.Bytes(), &profile))
+	require.Equal("taken@example.com", profile.Email)
+	require.False(profile.EmailVerified)
+
+	// The owner is told instead of getting a verification link.
+	require.Len(suite.mailer.sent, 1)
+	require.Equal("taken@example.com", suite.mailer.sent[0].to)
+	require.NotContains(suite.mailer.sent[0].body, "?token=")
+}
+
+func (suite *UserProfileTestSuite) TestUpdateProfile_Validation_Failure() {
+	require := suite.Require()
+
+	for _, body := range []string{`{"email":"john"}`, `{"name":"ab"}`, `{"cellphone":"123"}`} {
+		suite.expectUser("")
+
+		res := suite.call(suite.user.UpdateProfile, http.MethodPatch, body)
+		require.Equal(http.StatusBadRequest, res.Code, body)
+	}
+
+	require.NoError(suite.sqlMock.ExpectationsWereMet())
+}
+
+func (suite *UserProfileTestSuite) TestUpdateProfile_EmptyEmail_Failure() {
+	require := suite.Require()
+
+	// No update is expected; an empty email would clear the login email.
+	suite.expectUser("")
+	res := suite.call(suite.user.UpdateProfile, http.MethodPatch, `{"name":"Jane Doe","email":""}`)
+	require.Equal(http.StatusBadRequest, res.Code)
+	require.Equal(`"Email is required"`, strings.TrimSpace(res.Body.String()))
+	require.NoError(suite.sqlMock.ExpectationsWereMet())
+	require.Empty(suite.mailer.sent)
+}
+
+func (suite *UserProfileTestSuite) TestDeleteAccount_WrongPassword_Failure() {
+	require := suite.Require()
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte("current-password"), bcrypt.MinCost)
+	require.NoError(err)
+	suite.expectUser(string(hashed))
+
+	res := suite.call(suite.user.DeleteAccount, http.MethodDelete, `{"password":"wrong"}`)
+	require.Equal(http.StatusUnauthorized, res.Code)
+	require.NoError(suite.sqlMock.ExpectationsWereMet())
+}
+
+func TestUserProfile(t *testing.T) {
+	suite.Run(t, new(UserProfileTestSuite))
+}
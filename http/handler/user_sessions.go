@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"aliagha/services"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type SessionResponse struct {
+	ID         int32     `json:"id"`
+	Device     string    `json:"device"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	// Current marks the session of the request.
+	Current bool `json:"current"`
+}
+
+type RevokeSessionsResponse struct {
+	Revoked int `json:"revoked"`
+}
+
+// GetSessions lists the active sessions of the logged in user, the most
+// recently seen first. Last seen is updated whenever the session refreshes
+// its tokens.
+func (u *User) GetSessions(ctx echo.Context) error {
+	UID, err := strconv.Atoi(ctx.Get("user_id").(string))
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	sessions, err := u.Sessions.Active(int32(UID), time.Now())
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	current, _ := ctx.Get("session_id").(string)
+	response := make([]SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, SessionResponse{
+			ID:         session.ID,
+			Device:     session.Device,
+			IP:         session.IP,
+			UserAgent:  session.UserAgent,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			Current:    session.Family == current,
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// RevokeSession logs a session of the logged in user out: its refresh token
+// and access tokens stop working right away.
+func (u *User) RevokeSession(ctx echo.Context) error {
+	UID, err := strconv.Atoi(ctx.Get("user_id").(string))
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, "Bad Request")
+	}
+
+	err = u.Sessions.Revoke(int32(UID), int32(id), time.Now())
+	if errors.Is(err, services.ErrSessionNotFound) {
+		return ctx.JSON(http.StatusNotFound, "Session not found")
+	}
+
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// RevokeOtherSessions logs every session of the logged in user out but the
+// one of the request.
+func (u *User) RevokeOtherSessions(ctx echo.Context) error {
+	UID, err := strconv.Atoi(ctx.Get("user_id").(string))
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	current, _ := ctx.Get("session_id").(string)
+	revoked, err := u.Sessions.RevokeOthers(int32(UID), current, time.Now())
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	return ctx.JSON(http.StatusOK, RevokeSessionsResponse{Revoked: revoked})
+}
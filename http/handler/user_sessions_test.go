@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"aliagha/database"
+	"aliagha/models"
+	"aliagha/services"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/suite"
+)
+
+type UserSessionsTestSuite struct {
+	suite.Suite
+	redisServer *miniredis.Miniredis
+	e           *echo.Echo
+	user        *User
+	owner       models.User
+}
+
+func (suite *UserSessionsTestSuite) SetupTest() {
+	server, redis := database.NewRedisMock()
+	db := database.NewSQLiteMock()
+	tokens := &services.TokenStore{Redis: redis, AccessTTL: time.Hour, RefreshTTL: 24 * time.Hour}
+	suite.redisServer = server
+	suite.e = echo.New()
+	suite.user = &User{DB: db, Tokens: tokens, Sessions: &services.Sessions{DB: db, Tokens: tokens}}
+
+	suite.owner = models.User{Name: "Traveler", Cellphone: "09121234567", Email: "traveler@example.com"}
+	suite.Require().NoError(db.Create(&suite.owner).Error)
+}
+
+func (suite *UserSessionsTestSuite) TearDownTest() {
+	suite.redisServer.Close()
+}
+
+func (suite *UserSessionsTestSuite) start(userID int32, device string, now time.Time) *models.Session {
+	session, _, err := suite.user.Sessions.Start(userID, services.SessionInfo{Device: device, IP: "192.0.2.1"}, now)
+	suite.Require().NoError(err)
+	return session
+}
+
+// call runs the handler as the owner logged in with the session.
+func (suite *UserSessionsTestSuite) call(handler echo.HandlerFunc, method string, session *models.Session, id string) *httptest.ResponseRecorder {
+	res := httptest.NewRecorder()
+	ctx := suite.e.NewContext(httptest.NewRequest(method, "/user/sessions", nil), res)
+	ctx.Set("user_id", strconv.Itoa(int(suite.owner.ID)))
+	ctx.Set("session_id", session.Family)
+	if id != "" {
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(id)
+	}
+
+	suite.Require().NoError(handler(ctx))
+	return res
+}
+
+func (suite *UserSessionsTestSuite) sessions(current *models.Session) []SessionResponse {
+	res := suite.call(suite.user.GetSessions, http.MethodGet, current, "")
+	suite.Require().Equal(http.StatusOK, res.Code)
+
+	var sessions []SessionResponse
+	suite.Require().NoError(json.Unmarshal(res.Body.Bytes(), &sessions))
+	return sessions
+}
+
+func (suite *UserSessionsTestSuite) TestGetSessions_Success() {
+	require := suite.Require()
+
+	now := time.Now()
+	laptop := suite.start(suite.owner.ID, "Laptop", now.Add(-time.Hour))
+	current := suite.start(suite.owner.ID, "Pixel 7", now)
+
+	other := models.User{Name: "Other", Cellphone: "09127654321", Email: "other@example.com"}
+	require.NoError(suite.user.DB.Create(&other).Error)
+	suite.start(other.ID, "Other phone", now)
+
+	sessions := suite.sessions(current)
+	require.Len(sessions, 2)
+	require.Equal(current.ID, sessions[0].ID)
+	require.Equal("Pixel 7", sessions[0].Device)
+	require.Equal("192.0.2.1", sessions[0].IP)
+	require.True(sessions[0].Current)
+	require.Equal(laptop.ID, sessions[1].ID)
+	require.False(sessions[1].Current)
+
+	res := suite.call(suite.user.GetSessions, http.MethodGet, current, "")
+	require.NotContains(res.Body.String(), current.Family)
+}
+
+func (suite *UserSessionsTestSuite) TestRevokeSession_Success() {
+	require := suite.Require()
+
+	now := time.Now()
+	current := suite.start(suite.owner.ID, "Pixel 7", now)
+	shared := suite.start(suite.owner.ID, "Shared phone", now)
+
+	res := suite.call(suite.user.RevokeSession, http.MethodDelete, current, strconv.Itoa(int(shared.ID)))
+	require.Equal(http.StatusNoContent, res.Code)
+
+	sessions := suite.sessions(current)
+	require.Len(sessions, 1)
+	require.Equal(current.ID, sessions[0].ID)
+
+	// Revoked sessions and sessions of other users are not found.
+	res = suite.call(suite.user.RevokeSession, http.MethodDelete, current, strconv.Itoa(int(shared.ID)))
+	require.Equal(http.StatusNotFound, res.Code)
+
+	other := models.User{Name: "Other", Cellphone: "09127654321", Email: "other@example.com"}
+	require.NoError(suite.user.DB.Create(&other).Error)
+	foreign := suite.start(other.ID, "Other phone", now)
+	res = suite.call(suite.user.RevokeSession, http.MethodDelete, current, strconv.Itoa(int(foreign.ID)))
+	require.Equal(http.StatusNotFound, res.Code)
+
+	active, err := suite.user.Tokens.IsRefreshFamilyActive(foreign.Family)
+	require.NoError(err)
+	require.True(active)
+
+	res = suite.call(suite.user.RevokeSession, http.MethodDelete, current, "abc")
+	require.Equal(http.StatusBadRequest, res.Code)
+}
+
+func (suite *UserSessionsTestSuite) TestRevokeOtherSessions_Success() {
+	require := suite.Require()
+
+	now := time.Now()
+	current := suite.start(suite.owner.ID, "Pixel 7", now)
+	suite.start(suite.owner.ID, "Shared phone", now)
+	suite.start(suite.owner.ID, "Laptop", now)
+
+	res := suite.call(suite.user.RevokeOtherSessions, http.MethodDelete, current, "")
+	require.Equal(http.StatusOK, res.Code)
+	require.JSONEq(`{"revoked":2}`, res.Body.String())
+
+	sessions := suite.sessions(current)
+	require.Len(sessions, 1)
+	require.True(sessions[0].Current)
+
+	res = suite.call(suite.user.RevokeOtherSessions, http.MethodDelete, current, "")
+	require.JSONEq(`{"revoked":0}`, res.Body.String())
+}
+
+func TestUserSessions(t *testing.T) {
+	suite.Run(t, new(UserSessionsTestSuite))
+}
@@ -2,12 +2,16 @@ package handler
 
 import (
 	"aliagha/config"
+	"aliagha/database"
 	"aliagha/helpers"
+	"aliagha/models"
+	"aliagha/services"
 	"database/sql"
 	"errors"
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -25,10 +29,12 @@ import (
 
 type UserTestSuite struct {
 	suite.Suite
-	sqlMock   sqlmock.Sqlmock
-	e         *echo.Echo
-	user      *User
-	mockToken string
+	sqlMock          sqlmock.Sqlmock
+	e                *echo.Echo
+	user             *User
+	mailer           *recordingMailer
+	mockToken        string
+	mockRefreshToken string
 }
 
 func (suite *UserTestSuite) SetupSuite() {
@@ -47,15 +53,31 @@ func (suite *UserTestSuite) SetupSuite() {
 	}
 
 	suite.sqlMock = sqlMock
+	suite.mailer = &recordingMailer{}
 	vldt := validator.New()
+	_, redis := database.NewRedisMock()
+	tokens := &services.TokenStore{Redis: redis, RefreshTTL: time.Hour}
 	suite.user = &User{DB: db, JWT: &config.JWT{
 		SecretKey: "secretkey",
 		ExpiresIn: 3600,
-	}, Validator: vldt}
+	}, Keys: helpers.NewHMACKeySet("secretkey"), Validator: vldt, Tokens: tokens, Sessions: &services.Sessions{DB: db, Tokens: tokens},
+		Limiter:            &services.LoginLimiter{Redis: redis, Window: time.Hour, MaxAccountFailures: 3, LockoutDuration: time.Minute},
+		EmailVerifications: &services.EmailVerifications{Redis: redis, Mailer: suite.mailer, TTL: time.Hour}}
 	suite.mockToken = "testToken"
+	suite.mockRefreshToken = "testRefreshToken"
 	suite.e = echo.New()
 }
 
+func (suite *UserTestSuite) SetupTest() {
+	suite.mailer.sent = nil
+}
+
+func (suite *UserTestSuite) patchSession() {
+	monkey.PatchInstanceMethod(reflect.TypeOf(suite.user.Sessions), "Start", func(_ *services.Sessions, userID int32, _ services.SessionInfo, _ time.Time) (*models.Session, string, error) {
+		return &models.Session{ID: 1, UID: userID, Family: "family"}, suite.mockRefreshToken, nil
+	})
+}
+
 func (suite *UserTestSuite) CallHandler(requestBody string, endPoint string) (*httptest.ResponseRecorder, error) {
 	req := httptest.NewRequest(http.MethodPost, endPoint, strings.NewReader(requestBody))
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
@@ -91,7 +113,7 @@ func (suite *UserTestSuite) TestUserLogin_ParseReq_Failure() {
 func (suite *UserTestSuite) TestUserLogin_Success() {
 	require := suite.Require()
 	expectedStatusCode := http.StatusOK
-	expectedResponse := `{"token":"` + suite.mockToken + `"}`
+	expectedResponse := `{"token":"` + suite.mockToken + `","refresh_token":"` + suite.mockRefreshToken + `"}`
 
 	email := "test@example.com"
 	suite.sqlMock.ExpectQuery("^SELECT (.+) FROM `users` WHERE email = (.+) ORDER BY `users`.`id` LIMIT 1").
@@ -104,10 +126,16 @@ func (suite *UserTestSuite) TestUserLogin_Success() {
 	})
 	defer monkey.Unpatch(bcrypt.CompareHashAndPassword)
 
-	monkey.Patch(helpers.GenerateJwtToken, func(_ int32, _ string, _ *config.JWT) (string, error) {
+	suite.sqlMock.ExpectQuery("^SELECT roles.name, role_permissions.permission FROM `user_roles`").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "permission"}))
+
+	monkey.Patch(helpers.GenerateJwtToken, func(_ int32, _, _ string, _, _ []string, _ *helpers.KeySet, _ time.Duration) (string, error) {
 		return suite.mockToken, nil
 	})
 	defer monkey.Unpatch(helpers.GenerateJwtToken)
+	suite.patchSession()
+	defer monkey.UnpatchInstanceMethod(reflect.TypeOf(suite.user.Sessions), "Start")
 
 	res, err := suite.CallHandler(`{"email":"test@example.com","password":"1234567"}`, "/user/login")
 	require.NoError(err)
@@ -147,6 +175,28 @@ func (suite *UserTestSuite) TestUserLogin_FindUser_Failure() {
 	require.Equal(expectedStatusCode, res.Code)
 }
 
+func (suite *UserTestSuite) TestUserLogin_Lockout_Failure() {
+	require := suite.Require()
+	email := "locked@example.com"
+
+	for i := 0; i < 3; i++ {
+		suite.sqlMock.ExpectQuery("^SELECT (.+) FROM `users` WHERE email = (.+) ORDER BY `users`.`id` LIMIT 1").
+			WithArgs(email).
+			WillReturnError(gorm.ErrRecordNotFound)
+
+		res, err := suite.CallHandler(`{"email":"`+email+`","password":"1234567"}`, "/user/login")
+		require.NoError(err)
+		require.Equal(http.StatusUnauthorized, res.Code)
+		require.Equal(`"Invalid Credentials"`, strings.TrimSpace(res.Body.String()))
+	}
+
+	res, err := suite.CallHandler(`{"email":"`+email+`","password":"1234567"}`, "/user/login")
+	require.NoError(err)
+	require.Equal(http.StatusTooManyRequests, res.Code)
+	require.Equal("60", res.Header().Get("Retry-After"))
+	require.NoError(suite.sqlMock.ExpectationsWereMet())
+}
+
 func (suite *UserTestSuite) TestUserLogin_CompareHash_Failure() {
 	require := suite.Require()
 	expectedResponse := `"Invalid Credentials"`
@@ -193,10 +243,16 @@ func (suite *UserTestSuite) TestUserLogin_GenerateJWTToken_Failure() {
 	})
 	defer monkey.Unpatch(bcrypt.CompareHashAndPassword)
 
-	monkey.Patch(helpers.GenerateJwtToken, func(userID int32, cellphone string, jwt *config.JWT) (string, error) {
+	suite.sqlMock.ExpectQuery("^SELECT roles.name, role_permissions.permission FROM `user_roles`").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "permission"}))
+
+	monkey.Patch(helpers.GenerateJwtToken, func(userID int32, cellphone, sessionID string, roles, permissions []string, keys *helpers.KeySet, expiresIn time.Duration) (string, error) {
 		return "", errors.New("error")
 	})
 	defer monkey.Unpatch(helpers.GenerateJwtToken)
+	suite.patchSession()
+	defer monkey.UnpatchInstanceMethod(reflect.TypeOf(suite.user.Sessions), "Start")
 
 	res, err := suite.CallHandler(`{"email":"test@yahoo.com","password":"1234567"}`, "/user/login")
 	require.NoError(err)
@@ -219,11 +275,7 @@ func (suite *UserTestSuite) TestUserRegister_ParseReq_Failure() {
 func (suite *UserTestSuite) TestUserRegister_Success() {
 	require := suite.Require()
 	expectedStatusCode := http.StatusCreated
-
-	expectedResponse := `{
-		"message": "User created successfully",
-		"token": "` + suite.mockToken + `"
-	}`
+	expectedResponse := `{"message":"Check your email to continue, then log in"}`
 
 	monkey.Patch(suite.user.Validator.Struct, func(s interface{}) error {
 		return nil
@@ -236,7 +288,7 @@ func (suite *UserTestSuite) TestUserRegister_Success() {
 
 	suite.sqlMock.ExpectBegin()
 	suite.sqlMock.ExpectExec("INSERT INTO `users`").
-		WithArgs("matin khalili", "hashedPassword", "09123456789", "test@yahoo.com", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WithArgs("matin khalili", "hashedPassword", "09123456789", "test@yahoo.com", nil, nil, nil, nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 	suite.sqlMock.ExpectCommit()
 
@@ -245,17 +297,17 @@ func (suite *UserTestSuite) TestUserRegister_Success() {
 	})
 	defer monkey.Unpatch(bcrypt.GenerateFromPassword)
 
-	monkey.Patch(helpers.GenerateJwtToken, func(userID int32, cellphone string, jwt *config.JWT) (string, error) {
-		return suite.mockToken, nil
-	})
-	defer monkey.Unpatch(helpers.GenerateJwtToken)
-
 	res, err := suite.CallHandler(
 		`{"email":"test@yahoo.com","cellphone":"09123456789","name":"matin khalili", "password":"1234567"}`,
 		"/user/register")
 	require.NoError(err)
 	require.Equal(expectedStatusCode, res.Code)
-	require.JSONEq(expectedResponse, res.Body.String())
+	require.Equal(expectedResponse, strings.TrimSpace(res.Body.String()))
+	require.NoError(suite.sqlMock.ExpectationsWereMet())
+
+	require.Len(suite.mailer.sent, 1)
+	require.Equal("test@yahoo.com", suite.mailer.sent[0].to)
+	require.Contains(suite.mailer.sent[0].body, "?token=")
 }
 
 func (suite *UserTestSuite) TestUserRegister_Validation_Failure() {
@@ -274,10 +326,10 @@ func (suite *UserTestSuite) TestUserRegister_Validation_Failure() {
 	require.Equal(expectedStatusCode, res.Code)
 }
 
-func (suite *UserTestSuite) TestUserRegister_UserExist_Failure() {
+func (suite *UserTestSuite) TestUserRegister_UserExist_Success() {
 	require := suite.Require()
-	expectedStatusCode := http.StatusUnprocessableEntity
-	expectedResponse := `"Email already exists"`
+	expectedStatusCode := http.StatusCreated
+	expectedResponse := `{"message":"Check your email to continue, then log in"}`
 
 	monkey.Patch(suite.user.Validator.Struct, func(s interface{}) error {
 		return nil
@@ -286,16 +338,23 @@ func (suite *UserTestSuite) TestUserRegister_UserExist_Failure() {
 
 	suite.sqlMock.ExpectQuery("^SELECT (.+) FROM `users` WHERE email = (.+) ORDER BY `users`.`id` LIMIT 1").
 		WithArgs("test@yahoo.com").
-		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
-			AddRow(1, "matin khalili"))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email"}).
+			AddRow(1, "matin khalili", "test@yahoo.com"))
 
 	res, err := suite.CallHandler(
 		`{"email":"test@yahoo.com","cellphone":"09123456789","name":"matin khalili", "password":"1234567"}`,
 		"/user/register")
 
+	// The answer does not tell that the email is registered; its owner is
+	// told by email instead.
 	require.NoError(err)
 	require.Equal(expectedStatusCode, res.Code)
 	require.Equal(expectedResponse, strings.TrimSpace(res.Body.String()))
+	require.NoError(suite.sqlMock.ExpectationsWereMet())
+
+	require.Len(suite.mailer.sent, 1)
+	require.Equal("test@yahoo.com", suite.mailer.sent[0].to)
+	require.NotContains(suite.mailer.sent[0].body, "?token=")
 }
 
 func TestUser(t *testing.T) {
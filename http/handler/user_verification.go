@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"aliagha/models"
+	"aliagha/services"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// SendEmailVerification mails a verification link to the email address of the
+// logged in user.
+func (u *User) SendEmailVerification(ctx echo.Context) error {
+	UID, err := strconv.Atoi(ctx.Get("user_id").(string))
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	var user models.User
+	if err := u.DB.Where("id = ?", UID).First(&user).Error; err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	if user.Email == "" {
+		return ctx.JSON(http.StatusBadRequest, "No email address to verify")
+	}
+
+	if user.EmailVerifiedAt != nil {
+		return ctx.JSON(http.StatusBadRequest, "Email already verified")
+	}
+
+	if err := u.EmailVerifications.Send(user.ID, user.Email); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	return ctx.JSON(http.StatusOK, "Verification link sent")
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// VerifyEmail verifies the email address a verification link was sent to. It
+// does not need a login, as the link may be opened on another device.
+func (u *User) VerifyEmail(ctx echo.Context) error {
+	var req VerifyEmailRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, "Bad Request")
+	}
+
+	if err := u.Validator.Struct(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	userID, email, err := u.EmailVerifications.Consume(req.Token)
+	if errors.Is(err, services.ErrInvalidVerificationToken) {
+		return ctx.JSON(http.StatusBadRequest, "Invalid or expired token")
+	}
+
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	// The address may have changed since the link was sent.
+	now := time.Now()
+	result := u.DB.Model(&models.User{}).Where("id = ? AND email = ?", userID, email).
+		Updates(map[string]interface{}{"email_verified_at": now, "verified_at": now, "updated_at": now})
+	if result.Error != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	if result.RowsAffected == 0 {
+		return ctx.JSON(http.StatusBadRequest, "Invalid or expired token")
+	}
+
+	return ctx.JSON(http.StatusOK, "Email verified successfully")
+}
+
+// SendCellphoneVerification sends a code to the cellphone of the logged in
+// user.
+func (u *User) SendCellphoneVerification(ctx echo.Context) error {
+	UID, err := strconv.Atoi(ctx.Get("user_id").(string))
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	var user models.User
+	if err := u.DB.Where("id = ?", UID).First(&user).Error; err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	if user.CellphoneVerifiedAt != nil {
+		return ctx.JSON(http.StatusBadRequest, "Cellphone already verified")
+	}
+
+	err = u.OTP.Request(user.Cellphone)
+	if errors.Is(err, services.ErrOTPResendTooSoon) || errors.Is(err, services.ErrOTPSendLimit) {
+		return ctx.JSON(http.StatusTooManyRequests, "Too many codes requested, try again later")
+	}
+
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	return ctx.JSON(http.StatusOK, OTPRequestResponse{
+		Message:   "Code sent",
+		ExpiresIn: int(u.OTP.TTL / time.Second),
+	})
+}
+
+type VerifyCellphoneRequest struct {
+	Code string `json:"code" validate:"required,numeric"`
+}
+
+// VerifyCellphone verifies the cellphone of the logged in user with the code
+// sent to it.
+func (u *User) VerifyCellphone(ctx echo.Context) error {
+	UID, err := strconv.Atoi(ctx.Get("user_id").(string))
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	var req VerifyCellphoneRequest
+	if err := ctx.Bind(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, "Bad Request")
+	}
+
+	if err := u.Validator.Struct(&req); err != nil {
+		return ctx.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	var user models.User
+	if err := u.DB.Where("id = ?", UID).First(&user).Error; err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	err = u.OTP.Verify(user.Cellphone, req.Code)
+	if errors.Is(err, services.ErrInvalidOTP) {
+		return ctx.JSON(http.StatusBadRequest, "Invalid code")
+	}
+
+	if errors.Is(err, services.ErrOTPAttemptsExceeded) {
+		return ctx.JSON(http.StatusTooManyRequests, "Too many wrong codes, request a new one")
+	}
+
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	now := time.Now()
+	err = u.DB.Model(&models.User{}).Where("id = ?", user.ID).
+		Updates(map[string]interface{}{"cellphone_verified_at": now, "verified_at": now, "updated_at": now}).Error
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	return ctx.JSON(http.StatusOK, "Cellphone verified successfully")
+}
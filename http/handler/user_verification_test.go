@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"aliagha/database"
+	"aliagha/models"
+	"aliagha/services"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/suite"
+)
+
+type UserVerificationTestSuite struct {
+	suite.Suite
+	redisServer *miniredis.Miniredis
+	sender      *recordingSMSSender
+	mailer      *recordingMailer
+	e           *echo.Echo
+	user        *User
+	account     models.User
+}
+
+func (suite *UserVerificationTestSuite) SetupTest() {
+	server, redis := database.NewRedisMock()
+	db := database.NewSQLiteMock()
+	suite.redisServer = server
+	suite.sender = &recordingSMSSender{}
+	suite.mailer = &recordingMailer{}
+	suite.e = echo.New()
+	suite.user = &User{
+		DB:        db,
+		Validator: validator.New(),
+		OTP: &services.OTPStore{
+			Redis:          redis,
+			Sender:         suite.sender,
+			Secret:         "secret",
+			Length:         6,
+			TTL:            2 * time.Minute,
+			MaxAttempts:    3,
+			ResendInterval: time.Minute,
+			MaxSends:       5,
+			SendWindow:     time.Hour,
+		},
+		EmailVerifications: &services.EmailVerifications{
+			Redis:  redis,
+			Mailer: suite.mailer,
+			TTL:    time.Hour,
+			URL:    "https://aliagha.test/verify-email",
+		},
+	}
+
+	suite.account = models.User{Name: "Traveler", Cellphone: "09121234567", Email: "traveler@example.com"}
+	suite.Require().NoError(db.Create(&suite.account).Error)
+}
+
+func (suite *UserVerificationTestSuite) TearDownTest() {
+	suite.redisServer.Close()
+}
+
+func (suite *UserVerificationTestSuite) call(handler echo.HandlerFunc, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	res := httptest.NewRecorder()
+	ctx := suite.e.NewContext(req, res)
+	ctx.Set("user_id", strconv.Itoa(int(suite.account.ID)))
+
+	suite.Require().NoError(handler(ctx))
+	return res
+}
+
+func (suite *UserVerificationTestSuite) stored() models.User {
+	var user models.User
+	suite.Require().NoError(suite.user.DB.First(&user, suite.account.ID).Error)
+	return user
+}
+
+func (suite *UserVerificationTestSuite) TestVerifyCellphone_Success() {
+	require := suite.Require()
+
+	res := suite.call(suite.user.SendCellphoneVerification, "")
+	require.Equal(http.StatusOK, res.Code)
+	require.Len(suite.sender.messages, 1)
+	code := regexp.MustCompile(`\d{6}$`).FindString(suite.sender.messages[0])
+
+	res = suite.call(suite.user.VerifyCellphone, `{"code":"`+code+`"}`)
+	require.Equal(http.StatusOK, res.Code)
+
+	// verified_at keeps the account from being cleaned up as unverified.
+	user := suite.stored()
+	require.NotNil(user.CellphoneVerifiedAt)
+	require.NotNil(user.VerifiedAt)
+	require.Nil(user.EmailVerifiedAt)
+}
+
+func (suite *UserVerificationTestSuite) TestVerifyEmail_Success() {
+	require := suite.Require()
+
+	res := suite.call(suite.user.SendEmailVerification, "")
+	require.Equal(http.StatusOK, res.Code)
+	require.Len(suite.mailer.sent, 1)
+	token := strings.Fields(strings.SplitN(suite.mailer.sent[0].body, "?token=", 2)[1])[0]
+
+	res = suite.call(suite.user.VerifyEmail, `{"token":"`+token+`"}`)
+	require.Equal(http.StatusOK, res.Code)
+
+	user := suite.stored()
+	require.NotNil(user.EmailVerifiedAt)
+	require.NotNil(user.VerifiedAt)
+	require.Nil(user.CellphoneVerifiedAt)
+}
+
+func TestUserVerification(t *testing.T) {
+	suite.Run(t, new(UserVerificationTestSuite))
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"aliagha/helpers"
+	"aliagha/services"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+// AuthOrAPIKeyMiddleware accepts either an access token, like AuthMiddleware,
+// or an API key in the X-API-Key header granted scope. A key acts for its
+// owner: it sets user_id and api_key_id on the context.
+func AuthOrAPIKeyMiddleware(keys *helpers.KeySet, tokens *services.TokenStore, apiKeys *services.APIKeys, scope string) echo.MiddlewareFunc {
+	auth := AuthMiddleware(keys, tokens)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		withToken := auth(next)
+		withAPIKey := apiKeyAuth(apiKeys, scope, next)
+		return func(ctx echo.Context) error {
+			if ctx.Request().Header.Get(APIKeyHeader) != "" {
+				return withAPIKey(ctx)
+			}
+
+			return withToken(ctx)
+		}
+	}
+}
+
+// OptionalAPIKey lets every request through, but checks, counts and rate
+// limits the API key of the requests that have one.
+func OptionalAPIKey(apiKeys *services.APIKeys, scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		withAPIKey := apiKeyAuth(apiKeys, scope, next)
+		return func(ctx echo.Context) error {
+			if ctx.Request().Header.Get(APIKeyHeader) != "" {
+				return withAPIKey(ctx)
+			}
+
+			return next(ctx)
+		}
+	}
+}
+
+func apiKeyAuth(apiKeys *services.APIKeys, scope string, next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		now := time.Now()
+		apiKey, err := apiKeys.Authenticate(ctx.Request().Header.Get(APIKeyHeader), now)
+		if errors.Is(err, services.ErrInvalidAPIKey) {
+			return ctx.JSON(http.StatusUnauthorized, "Invalid API key")
+		}
+
+		if err != nil {
+			return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+		}
+
+		if !services.APIKeyHasScope(apiKey, scope) {
+			return ctx.JSON(http.StatusForbidden, "Forbidden")
+		}
+
+		wait, err := apiKeys.Allow(apiKey, now)
+		if errors.Is(err, services.ErrAPIKeyRateLimited) {
+			ctx.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			return ctx.JSON(http.StatusTooManyRequests, "Rate limit exceeded")
+		}
+
+		if err != nil {
+			return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+		}
+
+		ctx.Set("user_id", strconv.Itoa(int(apiKey.UID)))
+		ctx.Set("api_key_id", apiKey.ID)
+		return next(ctx)
+	}
+}
@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"aliagha/database"
+	"aliagha/helpers"
+	"aliagha/models"
+	"aliagha/services"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/suite"
+)
+
+type APIKeyMiddlewareTestSuite struct {
+	suite.Suite
+	redisServer *miniredis.Miniredis
+	e           *echo.Echo
+	keys        *helpers.KeySet
+	tokens      *services.TokenStore
+	apiKeys     *services.APIKeys
+	user        models.User
+}
+
+func (suite *APIKeyMiddlewareTestSuite) SetupTest() {
+	server, redis := database.NewRedisMock()
+	db := database.NewSQLiteMock()
+	suite.redisServer = server
+	suite.e = echo.New()
+	suite.keys = helpers.NewHMACKeySet("secretkey")
+	suite.tokens = &services.TokenStore{Redis: redis, AccessTTL: time.Hour, RefreshTTL: time.Hour}
+	suite.apiKeys = &services.APIKeys{DB: db, Redis: redis, DefaultRateLimit: 100}
+
+	suite.user = models.User{Name: "Partner", Cellphone: "09121234567", Email: "partner@example.com"}
+	suite.Require().NoError(db.Create(&suite.user).Error)
+}
+
+func (suite *APIKeyMiddlewareTestSuite) TearDownTest() {
+	suite.redisServer.Close()
+}
+
+func (suite *APIKeyMiddlewareTestSuite) createKey(rateLimit int32, scopes ...string) string {
+	key, _, err := suite.apiKeys.Create(services.NewAPIKey{UserID: suite.user.ID, Name: "agency", Scopes: scopes, RateLimit: rateLimit})
+	suite.Require().NoError(err)
+	return key
+}
+
+// call runs the middleware with the API key and token, either may be empty,
+// and returns the response and the context the next handler saw, nil when it
+// was not called.
+func (suite *APIKeyMiddlewareTestSuite) call(middleware echo.MiddlewareFunc, apiKey, token string) (*httptest.ResponseRecorder, echo.Context) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if apiKey != "" {
+		req.Header.Set(APIKeyHeader, apiKey)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res := httptest.NewRecorder()
+	var reached echo.Context
+	handler := middleware(func(ctx echo.Context) error {
+		reached = ctx
+		return ctx.NoContent(http.StatusOK)
+	})
+
+	suite.Require().NoError(handler(suite.e.NewContext(req, res)))
+	return res, reached
+}
+
+func (suite *APIKeyMiddlewareTestSuite) auth() echo.MiddlewareFunc {
+	return AuthOrAPIKeyMiddleware(suite.keys, suite.tokens, suite.apiKeys, services.ScopePassengers)
+}
+
+func (suite *APIKeyMiddlewareTestSuite) TestAuthOrAPIKey_APIKey_Success() {
+	require := suite.Require()
+
+	res, ctx := suite.call(suite.auth(), suite.createKey(0, services.ScopePassengers), "")
+	require.Equal(http.StatusOK, res.Code)
+	require.NotNil(ctx)
+	require.Equal(strconv.Itoa(int(suite.user.ID)), ctx.Get("user_id"))
+	require.NotNil(ctx.Get("api_key_id"))
+}
+
+func (suite *APIKeyMiddlewareTestSuite) TestAuthOrAPIKey_Token_Success() {
+	require := suite.Require()
+
+	token, err := helpers.GenerateJwtToken(suite.user.ID, suite.user.Cellphone, "", nil, nil, suite.keys, time.Hour)
+	require.NoError(err)
+
+	res, ctx := suite.call(suite.auth(), "", token)
+	require.Equal(http.StatusOK, res.Code)
+	require.NotNil(ctx)
+	require.Equal(strconv.Itoa(int(suite.user.ID)), ctx.Get("user_id"))
+	require.Nil(ctx.Get("api_key_id"))
+
+	res, ctx = suite.call(suite.auth(), "", "")
+	require.Equal(http.StatusUnauthorized, res.Code)
+	require.Nil(ctx)
+}
+
+func (suite *APIKeyMiddlewareTestSuite) TestAuthOrAPIKey_InvalidKey_Failure() {
+	require := suite.Require()
+
+	res, ctx := suite.call(suite.auth(), "ak_unknown", "")
+	require.Equal(http.StatusUnauthorized, res.Code)
+	require.Nil(ctx)
+}
+
+func (suite *APIKeyMiddlewareTestSuite) TestAuthOrAPIKey_Scope_Failure() {
+	require := suite.Require()
+
+	res, ctx := suite.call(suite.auth(), suite.createKey(0, services.ScopeSearch), "")
+	require.Equal(http.StatusForbidden, res.Code)
+	require.Nil(ctx)
+}
+
+func (suite *APIKeyMiddlewareTestSuite) TestAuthOrAPIKey_RateLimited_Failure() {
+	require := suite.Require()
+
+	key := suite.createKey(1, services.ScopePassengers)
+
+	// Requests are limited per minute; of three quick requests at least two
+	// fall in the same minute.
+	var res *httptest.ResponseRecorder
+	var ctx echo.Context
+	for i := 0; i < 3; i++ {
+		res, ctx = suite.call(suite.auth(), key, "")
+		if res.Code != http.StatusOK {
+			break
+		}
+	}
+
+	require.Equal(http.StatusTooManyRequests, res.Code)
+	require.Nil(ctx)
+	retryAfter, err := strconv.Atoi(res.Header().Get("Retry-After"))
+	require.NoError(err)
+	require.True(retryAfter > 0 && retryAfter <= 60)
+}
+
+func (suite *APIKeyMiddlewareTestSuite) TestOptionalAPIKey_Success() {
+	require := suite.Require()
+
+	optional := OptionalAPIKey(suite.apiKeys, services.ScopeSearch)
+
+	res, ctx := suite.call(optional, "", "")
+	require.Equal(http.StatusOK, res.Code)
+	require.NotNil(ctx)
+	require.Nil(ctx.Get("user_id"))
+
+	res, ctx = suite.call(optional, suite.createKey(0, services.ScopeSearch), "")
+	require.Equal(http.StatusOK, res.Code)
+	require.NotNil(ctx)
+	require.NotNil(ctx.Get("api_key_id"))
+
+	// A key that is sent is checked.
+	res, ctx = suite.call(optional, "ak_unknown", "")
+	require.Equal(http.StatusUnauthorized, res.Code)
+	require.Nil(ctx)
+
+	res, ctx = suite.call(optional, suite.createKey(0, services.ScopeBookings), "")
+	require.Equal(http.StatusForbidden, res.Code)
+	require.Nil(ctx)
+}
+
+func TestAPIKeyMiddleware(t *testing.T) {
+	suite.Run(t, new(APIKeyMiddlewareTestSuite))
+}
@@ -2,13 +2,19 @@ package middleware
 
 import (
 	"aliagha/helpers"
+	"aliagha/services"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 )
 
-func AuthMiddleware(secretKey string) echo.MiddlewareFunc {
+// AuthMiddleware accepts requests with a valid access token that has not been
+// revoked and whose session is still active. It sets user_id, roles,
+// permissions, session_id, token_id and token_expires_at on the context.
+func AuthMiddleware(keys *helpers.KeySet, tokens *services.TokenStore) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(ctx echo.Context) error {
 			authHeader := ctx.Request().Header.Get("Authorization")
@@ -17,13 +23,38 @@ func AuthMiddleware(secretKey string) echo.MiddlewareFunc {
 			}
 
 			tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
-			userID, err := helpers.ParseJWTToken(tokenString, secretKey)
+			claims, err := helpers.ParseJWTClaims(tokenString, keys)
+			if err != nil || claims.Id == "" {
+				return ctx.JSON(http.StatusUnauthorized, "Invalid token")
+			}
 
+			revoked, err := tokens.IsAccessTokenRevoked(claims.UserID, claims.Id, claims.IssueTime())
 			if err != nil {
+				return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+			}
+
+			if revoked {
 				return ctx.JSON(http.StatusUnauthorized, "Invalid token")
 			}
 
-			ctx.Set("user_id", userID)
+			// Tokens of a session stop working once it is revoked or expires.
+			if claims.SessionID != "" {
+				active, err := tokens.IsRefreshFamilyActive(claims.SessionID)
+				if err != nil {
+					return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+				}
+
+				if !active {
+					return ctx.JSON(http.StatusUnauthorized, "Invalid token")
+				}
+			}
+
+			ctx.Set("user_id", strconv.Itoa(int(claims.UserID)))
+			ctx.Set("roles", claims.Roles)
+			ctx.Set("permissions", claims.Permissions)
+			ctx.Set("session_id", claims.SessionID)
+			ctx.Set("token_id", claims.Id)
+			ctx.Set("token_expires_at", time.Unix(claims.ExpiresAt, 0))
 			return next(ctx)
 		}
 	}
@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"aliagha/database"
+	"aliagha/helpers"
+	"aliagha/services"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/golang-jwt/jwt"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/suite"
+)
+
+type AuthMiddlewareTestSuite struct {
+	suite.Suite
+	redisServer *miniredis.Miniredis
+	e           *echo.Echo
+	keys        *helpers.KeySet
+	tokens      *services.TokenStore
+}
+
+func (suite *AuthMiddlewareTestSuite) SetupTest() {
+	server, redis := database.NewRedisMock()
+	suite.redisServer = server
+	suite.e = echo.New()
+	suite.keys = helpers.NewHMACKeySet("secretkey")
+	suite.tokens = &services.TokenStore{Redis: redis, AccessTTL: time.Hour, RefreshTTL: time.Hour}
+}
+
+func (suite *AuthMiddlewareTestSuite) TearDownTest() {
+	suite.redisServer.Close()
+}
+
+// call runs the middleware with the token and returns the response and the
+// context the next handler saw, nil when it was not called.
+func (suite *AuthMiddlewareTestSuite) call(token string) (*httptest.ResponseRecorder, echo.Context) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res := httptest.NewRecorder()
+	var reached echo.Context
+	handler := AuthMiddleware(suite.keys, suite.tokens)(func(ctx echo.Context) error {
+		reached = ctx
+		return ctx.NoContent(http.StatusOK)
+	})
+
+	suite.Require().NoError(handler(suite.e.NewContext(req, res)))
+	return res, reached
+}
+
+func (suite *AuthMiddlewareTestSuite) token(sessionID string) string {
+	token, err := helpers.GenerateJwtToken(7, "09121234567", sessionID, []string{"agent"}, []string{"users:read"}, suite.keys, time.Hour)
+	suite.Require().NoError(err)
+	return token
+}
+
+func (suite *AuthMiddlewareTestSuite) TestAuth_Success() {
+	require := suite.Require()
+
+	family, _, err := suite.tokens.StartRefreshFamily(7)
+	require.NoError(err)
+
+	res, ctx := suite.call(suite.token(family))
+	require.Equal(http.StatusOK, res.Code)
+	require.NotNil(ctx)
+	require.Equal("7", ctx.Get("user_id"))
+	require.Equal([]string{"agent"}, ctx.Get("roles"))
+	require.Equal([]string{"users:read"}, ctx.Get("permissions"))
+	require.Equal(family, ctx.Get("session_id"))
+	require.NotEmpty(ctx.Get("token_id"))
+}
+
+func (suite *AuthMiddlewareTestSuite) TestAuth_InvalidToken_Failure() {
+	require := suite.Require()
+
+	res, ctx := suite.call("")
+	require.Equal(http.StatusUnauthorized, res.Code)
+	require.Nil(ctx)
+
+	res, ctx = suite.call("not-a-token")
+	require.Equal(http.StatusUnauthorized, res.Code)
+	require.Nil(ctx)
+
+	other, err := helpers.GenerateJwtToken(7, "09121234567", "", nil, nil, helpers.NewHMACKeySet("otherkey"), time.Hour)
+	require.NoError(err)
+	res, ctx = suite.call(other)
+	require.Equal(http.StatusUnauthorized, res.Code)
+	require.Nil(ctx)
+}
+
+func (suite *AuthMiddlewareTestSuite) TestAuth_RevokedToken_Failure() {
+	require := suite.Require()
+
+	token := suite.token("")
+	claims, err := helpers.ParseJWTClaims(token, suite.keys)
+	require.NoError(err)
+	require.NoError(suite.tokens.RevokeAccessToken(claims.Id, time.Unix(claims.ExpiresAt, 0)))
+
+	res, ctx := suite.call(token)
+	require.Equal(http.StatusUnauthorized, res.Code)
+	require.Nil(ctx)
+}
+
+func (suite *AuthMiddlewareTestSuite) TestAuth_RevokedUserTokens_Failure() {
+	require := suite.Require()
+
+	// The token is issued in the second of the revocation.
+	token, err := suite.keys.Sign(&helpers.CustomClaims{UserID: 7, StandardClaims: jwt.StandardClaims{
+		Id:        "jti",
+		IssuedAt:  time.Now().Unix(),
+		ExpiresAt: time.Now().Add(time.Hour).Unix(),
+	}})
+	require.NoError(err)
+
+	res, _ := suite.call(token)
+	require.Equal(http.StatusOK, res.Code)
+
+	require.NoError(suite.tokens.RevokeUserTokens(7))
+	res, ctx := suite.call(token)
+	require.Equal(http.StatusUnauthorized, res.Code)
+	require.Nil(ctx)
+
+	// A token issued right after the revocation is valid.
+	family, _, err := suite.tokens.StartRefreshFamily(7)
+	require.NoError(err)
+	res, _ = suite.call(suite.token(family))
+	require.Equal(http.StatusOK, res.Code)
+}
+
+func (suite *AuthMiddlewareTestSuite) TestAuth_RevokedSession_Failure() {
+	require := suite.Require()
+
+	family, _, err := suite.tokens.StartRefreshFamily(7)
+	require.NoError(err)
+	token := suite.token(family)
+	require.NoError(suite.tokens.RevokeRefreshFamily(family))
+
+	res, ctx := suite.call(token)
+	require.Equal(http.StatusUnauthorized, res.Code)
+	require.Nil(ctx)
+
+	// A session that never existed is no better.
+	res, ctx = suite.call(suite.token("unknown"))
+	require.Equal(http.StatusUnauthorized, res.Code)
+	require.Nil(ctx)
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	suite.Run(t, new(AuthMiddlewareTestSuite))
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"aliagha/services"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RequirePermission only lets through users granted every one of permissions.
+// It runs after AuthMiddleware, which reads the permissions from the token.
+func RequirePermission(permissions ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			granted, _ := ctx.Get("permissions").([]string)
+			for _, permission := range permissions {
+				if !services.HasPermission(granted, permission) {
+					return ctx.JSON(http.StatusForbidden, "Forbidden")
+				}
+			}
+
+			return next(ctx)
+		}
+	}
+}
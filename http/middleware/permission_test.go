@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"aliagha/services"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequirePermission(t *testing.T) {
+	e := echo.New()
+
+	// call runs the middleware with the permissions AuthMiddleware would have
+	// set and reports whether the next handler was called.
+	call := func(granted interface{}, required ...string) (*httptest.ResponseRecorder, bool) {
+		res := httptest.NewRecorder()
+		ctx := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), res)
+		if granted != nil {
+			ctx.Set("permissions", granted)
+		}
+
+		reached := false
+		handler := RequirePermission(required...)(func(ctx echo.Context) error {
+			reached = true
+			return ctx.NoContent(http.StatusOK)
+		})
+
+		require.NoError(t, handler(ctx))
+		return res, reached
+	}
+
+	res, reached := call([]string{services.PermissionTicketsRead, services.PermissionUsersRead}, services.PermissionUsersRead, services.PermissionTicketsRead)
+	require.Equal(t, http.StatusOK, res.Code)
+	require.True(t, reached)
+
+	res, reached = call([]string{services.PermissionAll}, services.PermissionUsersUnlock)
+	require.Equal(t, http.StatusOK, res.Code)
+	require.True(t, reached)
+
+	// Every permission is needed, not just one of them.
+	res, reached = call([]string{services.PermissionUsersRead}, services.PermissionUsersRead, services.PermissionTicketsRead)
+	require.Equal(t, http.StatusForbidden, res.Code)
+	require.False(t, reached)
+
+	// Requests without permissions get nothing.
+	res, reached = call(nil, services.PermissionUsersRead)
+	require.Equal(t, http.StatusForbidden, res.Code)
+	require.False(t, reached)
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"aliagha/models"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// RequireVerifiedContact only lets through users who verified their email or
+// cellphone. It runs after AuthMiddleware.
+func RequireVerifiedContact(db *gorm.DB) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			var user models.User
+			err := db.Select("id", "email_verified_at", "cellphone_verified_at").
+				Where("id = ?", ctx.Get("user_id")).First(&user).Error
+			if err == gorm.ErrRecordNotFound {
+				return ctx.JSON(http.StatusUnauthorized, "Invalid token")
+			}
+
+			if err != nil {
+				return ctx.JSON(http.StatusInternalServerError, "Internal Server Error")
+			}
+
+			if user.EmailVerifiedAt == nil && user.CellphoneVerifiedAt == nil {
+				return ctx.JSON(http.StatusForbidden, "Verify your email or cellphone first")
+			}
+
+			return next(ctx)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+type VerifiedMiddlewareTestSuite struct {
+	suite.Suite
+	sqlMock sqlmock.Sqlmock
+	db      *gorm.DB
+	e       *echo.Echo
+}
+
+func (suite *VerifiedMiddlewareTestSuite) SetupTest() {
+	require := suite.Require()
+
+	mockDB, sqlMock, err := sqlmock.New()
+	require.NoError(err)
+
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: mockDB, SkipInitializeWithVersion: true}))
+	require.NoError(err)
+
+	suite.sqlMock = sqlMock
+	suite.db = db
+	suite.e = echo.New()
+}
+
+// call runs the middleware for user 7 and reports whether the next handler
+// was called.
+func (suite *VerifiedMiddlewareTestSuite) call() (*httptest.ResponseRecorder, bool) {
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	res := httptest.NewRecorder()
+	ctx := suite.e.NewContext(req, res)
+	ctx.Set("user_id", "7")
+
+	reached := false
+	handler := RequireVerifiedContact(suite.db)(func(ctx echo.Context) error {
+		reached = true
+		return ctx.NoContent(http.StatusOK)
+	})
+
+	suite.Require().NoError(handler(ctx))
+	return res, reached
+}
+
+func (suite *VerifiedMiddlewareTestSuite) expectUser(emailVerifiedAt, cellphoneVerifiedAt interface{}) {
+	suite.sqlMock.ExpectQuery("^SELECT `id`,`email_verified_at`,`cellphone_verified_at` FROM `users` WHERE id = (.+)").
+		WithArgs("7").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email_verified_at", "cellphone_verified_at"}).
+			AddRow(7, emailVerifiedAt, cellphoneVerifiedAt))
+}
+
+func (suite *VerifiedMiddlewareTestSuite) TestRequireVerifiedContact_Success() {
+	require := suite.Require()
+
+	suite.expectUser(nil, time.Now())
+	res, reached := suite.call()
+	require.Equal(http.StatusOK, res.Code)
+	require.True(reached)
+
+	suite.expectUser(time.Now(), nil)
+	res, reached = suite.call()
+	require.Equal(http.StatusOK, res.Code)
+	require.True(reached)
+	require.NoError(suite.sqlMock.ExpectationsWereMet())
+}
+
+func (suite *VerifiedMiddlewareTestSuite) TestRequireVerifiedContact_Unverified_Failure() {
+	require := suite.Require()
+
+	suite.expectUser(nil, nil)
+	res, reached := suite.call()
+	require.Equal(http.StatusForbidden, res.Code)
+	require.False(reached)
+	require.NoError(suite.sqlMock.ExpectationsWereMet())
+}
+
+func (suite *VerifiedMiddlewareTestSuite) TestRequireVerifiedContact_UserNotFound_Failure() {
+	require := suite.Require()
+
+	suite.sqlMock.ExpectQuery("^SELECT (.+) FROM `users` WHERE id = (.+)").
+		WithArgs("7").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email_verified_at", "cellphone_verified_at"}))
+	res, reached := suite.call()
+	require.Equal(http.StatusUnauthorized, res.Code)
+	require.False(reached)
+	require.NoError(suite.sqlMock.ExpectationsWereMet())
+}
+
+func TestVerifiedMiddleware(t *testing.T) {
+	suite.Run(t, new(VerifiedMiddlewareTestSuite))
+}
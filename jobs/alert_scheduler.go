@@ -0,0 +1,165 @@
+package jobs
+
+import (
+	"aliagha/models"
+	"aliagha/services"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AlertScheduler struct {
+	DB       *gorm.DB
+	Provider services.FlightProvider
+	Notifier services.Notifier
+	Interval time.Duration
+}
+
+type alertRoute struct {
+	DepCity string
+	ArrCity string
+	Date    string
+}
+
+type alertCheck struct {
+	Notify   bool
+	Message  string
+	MinPrice *int32
+	Seats    int32
+}
+
+// Check deactivates alerts for past dates and evaluates the remaining ones.
+// Alerts on the same route and date share a single airline API call.
+func (s *AlertScheduler) Check() error {
+	today := time.Now().Format("2006-01-02")
+
+	err := s.DB.Model(&models.Alert{}).
+		Where("active = ? AND flight_date < ?", true, today).
+		Update("active", false).
+		Error
+	if err != nil {
+		return err
+	}
+
+	var alerts []models.Alert
+	err = s.DB.Model(&models.Alert{}).
+		Preload("User").
+		Where("active = ? AND flight_date >= ?", true, today).
+		Find(&alerts).
+		Error
+	if err != nil {
+		return err
+	}
+
+	groups := make(map[alertRoute][]models.Alert)
+	for _, alert := range alerts {
+		route := alertRoute{DepCity: alert.DepCity, ArrCity: alert.ArrCity, Date: alert.FlightDate.Format("2006-01-02")}
+		groups[route] = append(groups[route], alert)
+	}
+
+	for route, group := range groups {
+		flights, err := s.Provider.GetFlights(route.DepCity, route.ArrCity, route.Date)
+		if err != nil {
+			log.Printf("alert_scheduler: fetching %s -> %s on %s failed, error: %v", route.DepCity, route.ArrCity, route.Date, err)
+			continue
+		}
+
+		for _, alert := range group {
+			if err := s.apply(alert, evaluateAlert(alert, flights)); err != nil {
+				log.Printf("alert_scheduler: alert %d failed, error: %v", alert.ID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *AlertScheduler) apply(alert models.Alert, check alertCheck) error {
+	updates := map[string]interface{}{
+		"last_price": check.MinPrice,
+		"last_seats": check.Seats,
+	}
+
+	if check.Notify {
+		err := s.Notifier.Notify(services.Notification{
+			UserID:  alert.UID,
+			Email:   alert.User.Email,
+			Subject: fmt.Sprintf("Flights from %s to %s on %s", alert.DepCity, alert.ArrCity, alert.FlightDate.Format("2006-01-02")),
+			Message: check.Message,
+		})
+		if err != nil {
+			return err
+		}
+
+		updates["last_notified_at"] = time.Now()
+	}
+
+	return s.DB.Model(&models.Alert{}).Where("id = ?", alert.ID).Updates(updates).Error
+}
+
+// evaluateAlert compares the flights on an alert's route with what was seen on
+// the previous check. Users are notified when seats open up on a sold out route
+// or the cheapest available fare drops, as long as it is within their threshold.
+func evaluateAlert(alert models.Alert, flights []services.FlightResponse) alertCheck {
+	var check alertCheck
+	for _, flight := range flights {
+		if flight.RemainingSeats <= 0 {
+			continue
+		}
+
+		check.Seats += flight.RemainingSeats
+		if check.MinPrice == nil || flight.Price < *check.MinPrice {
+			price := flight.Price
+			check.MinPrice = &price
+		}
+	}
+
+	if check.MinPrice == nil {
+		return check
+	}
+
+	minPrice := *check.MinPrice
+	if alert.MaxPrice != nil && minPrice > *alert.MaxPrice {
+		return check
+	}
+
+	switch {
+	case alert.LastSeats != nil && *alert.LastSeats == 0:
+		check.Notify = true
+		check.Message = fmt.Sprintf("Seats are available again from %d.", minPrice)
+	case alert.LastPrice != nil && minPrice < *alert.LastPrice:
+		check.Notify = true
+		check.Message = fmt.Sprintf("The fare dropped from %d to %d.", *alert.LastPrice, minPrice)
+	case alert.LastPrice == nil && alert.MaxPrice != nil:
+		check.Notify = true
+		check.Message = fmt.Sprintf("Flights are available from %d, within your limit of %d.", minPrice, *alert.MaxPrice)
+	}
+
+	return check
+}
+
+// Run checks the alerts on every interval until ctx is done.
+func (s *AlertScheduler) Run(ctx context.Context) {
+	interval := s.Interval
+	if interval <= 0 {
+		interval = 15 * time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Check(); err != nil {
+			log.Printf("alert_scheduler: check failed, error: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
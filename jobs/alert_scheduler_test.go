@@ -0,0 +1,49 @@
+package jobs
+
+import (
+	"aliagha/models"
+	"aliagha/services"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func int32Ptr(v int32) *int32 {
+	return &v
+}
+
+func TestEvaluateAlert(t *testing.T) {
+	flights := []services.FlightResponse{
+		{ID: 1, Price: 300, RemainingSeats: 4},
+		{ID: 2, Price: 200, RemainingSeats: 0},
+		{ID: 3, Price: 250, RemainingSeats: 6},
+	}
+
+	tests := []struct {
+		name     string
+		alert    models.Alert
+		flights  []services.FlightResponse
+		notify   bool
+		minPrice *int32
+		seats    int32
+	}{
+		{"first check without threshold", models.Alert{}, flights, false, int32Ptr(250), 10},
+		{"first check within threshold", models.Alert{MaxPrice: int32Ptr(260)}, flights, true, int32Ptr(250), 10},
+		{"first check above threshold", models.Alert{MaxPrice: int32Ptr(240)}, flights, false, int32Ptr(250), 10},
+		{"fare dropped", models.Alert{LastPrice: int32Ptr(300), LastSeats: int32Ptr(4)}, flights, true, int32Ptr(250), 10},
+		{"fare unchanged", models.Alert{LastPrice: int32Ptr(250), LastSeats: int32Ptr(10)}, flights, false, int32Ptr(250), 10},
+		{"fare dropped above threshold", models.Alert{MaxPrice: int32Ptr(240), LastPrice: int32Ptr(300)}, flights, false, int32Ptr(250), 10},
+		{"seats opened up", models.Alert{LastSeats: int32Ptr(0)}, flights, true, int32Ptr(250), 10},
+		{"sold out", models.Alert{LastPrice: int32Ptr(250), LastSeats: int32Ptr(10)}, flights[1:2], false, nil, 0},
+	}
+
+	for _, test := range tests {
+		check := evaluateAlert(test.alert, test.flights)
+		require.Equal(t, test.notify, check.Notify, test.name)
+		require.Equal(t, test.minPrice, check.MinPrice, test.name)
+		require.Equal(t, test.seats, check.Seats, test.name)
+		if test.notify {
+			require.NotEmpty(t, check.Message, test.name)
+		}
+	}
+}
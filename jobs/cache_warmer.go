@@ -0,0 +1,130 @@
+package jobs
+
+import (
+	"aliagha/config"
+	"aliagha/services"
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+type CacheWarmer struct {
+	Redis        *redis.Client
+	Provider     services.FlightProvider
+	Config       *config.CacheWarmer
+	TTL          time.Duration
+	FareRecorder *services.FareRecorder
+}
+
+type WarmResult struct {
+	Routes int
+	Warmed int
+	Failed int
+}
+
+// Routes merges the configured routes with the most searched ones,
+// dropping duplicates while keeping the configured routes first.
+func (w *CacheWarmer) Routes() ([]services.Route, error) {
+	routes := make([]services.Route, 0, len(w.Config.Routes)+w.Config.PopularRoutes)
+	seen := make(map[services.Route]bool)
+
+	for _, r := range w.Config.Routes {
+		route := services.Route{DepartureCity: r.DepartureCity, ArrivalCity: r.ArrivalCity}
+		if !seen[route] {
+			seen[route] = true
+			routes = append(routes, route)
+		}
+	}
+
+	popular, err := services.PopularRoutes(w.Redis, w.Config.PopularRoutes)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, route := range popular {
+		if !seen[route] {
+			seen[route] = true
+			routes = append(routes, route)
+		}
+	}
+
+	return routes, nil
+}
+
+// Warm fetches flights for every route over the next configured days and
+// stores them under the same keys Flight.Get reads from.
+func (w *CacheWarmer) Warm() (WarmResult, error) {
+	routes, err := w.Routes()
+	if err != nil {
+		return WarmResult{}, err
+	}
+
+	days := w.Config.Days
+	if days <= 0 {
+		days = 1
+	}
+
+	result := WarmResult{Routes: len(routes)}
+	today := time.Now()
+	for _, route := range routes {
+		for i := 0; i < days; i++ {
+			date := today.AddDate(0, 0, i).Format("2006-01-02")
+			if err := w.warmRoute(route, date); err != nil {
+				log.Printf("cache_warmer: %s -> %s on %s failed, error: %v", route.DepartureCity, route.ArrivalCity, date, err)
+				result.Failed++
+				continue
+			}
+
+			result.Warmed++
+		}
+	}
+
+	return result, nil
+}
+
+func (w *CacheWarmer) warmRoute(route services.Route, date string) error {
+	flights, err := w.Provider.GetFlights(route.DepartureCity, route.ArrivalCity, date)
+	if err != nil {
+		return err
+	}
+
+	if w.FareRecorder != nil {
+		w.FareRecorder.Record(route.DepartureCity, route.ArrivalCity, date, flights)
+	}
+
+	jsonData, err := json.Marshal(flights)
+	if err != nil {
+		return err
+	}
+
+	return w.Redis.Set(services.FlightsCacheKey(route.DepartureCity, route.ArrivalCity, date), jsonData, w.TTL).Err()
+}
+
+// Run warms the cache right away and then on every interval until ctx is done.
+func (w *CacheWarmer) Run(ctx context.Context) {
+	interval := w.Config.Interval
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		result, err := w.Warm()
+		if err != nil {
+			log.Printf("cache_warmer: warming failed, error: %v", err)
+		} else {
+			log.Printf("cache_warmer: warmed %d entries for %d routes, %d failed", result.Warmed, result.Routes, result.Failed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
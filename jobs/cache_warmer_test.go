@@ -0,0 +1,105 @@
+package jobs
+
+import (
+	"aliagha/config"
+	"aliagha/database"
+	"aliagha/services"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"bou.ke/monkey"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+	"github.com/stretchr/testify/suite"
+)
+
+type CacheWarmerTestSuite struct {
+	suite.Suite
+	warmer      *CacheWarmer
+	redis       *redis.Client
+	redisServer *miniredis.Miniredis
+}
+
+func (suite *CacheWarmerTestSuite) SetupSuite() {
+	server, client := database.NewRedisMock()
+	suite.redisServer = server
+	suite.redis = client
+	suite.warmer = &CacheWarmer{
+		Redis:    client,
+		Provider: &services.APIMockClient{},
+		Config: &config.CacheWarmer{
+			Days:          2,
+			PopularRoutes: 5,
+			Routes:        []config.Route{{DepartureCity: "CityA", ArrivalCity: "CityB"}},
+		},
+		TTL: time.Minute,
+	}
+}
+
+func (suite *CacheWarmerTestSuite) SetupTest() {
+	suite.redis.FlushAll()
+}
+
+func (suite *CacheWarmerTestSuite) TearDownSuite() {
+	suite.redisServer.Close()
+}
+
+func (suite *CacheWarmerTestSuite) TestRoutes_MergesConfiguredAndPopular_Success() {
+	require := suite.Require()
+
+	require.NoError(services.TrackRouteSearch(suite.redis, "CityC", "CityD"))
+	require.NoError(services.TrackRouteSearch(suite.redis, "CityC", "CityD"))
+	require.NoError(services.TrackRouteSearch(suite.redis, "CityA", "CityB"))
+	require.NoError(services.TrackRouteSearch(suite.redis, "CityE", "CityF"))
+
+	routes, err := suite.warmer.Routes()
+	require.NoError(err)
+	require.Equal([]services.Route{
+		{DepartureCity: "CityA", ArrivalCity: "CityB"},
+		{DepartureCity: "CityC", ArrivalCity: "CityD"},
+		{DepartureCity: "CityE", ArrivalCity: "CityF"},
+	}, routes)
+}
+
+func (suite *CacheWarmerTestSuite) TestWarm_Success() {
+	require := suite.Require()
+
+	var a services.APIMockClient
+	monkey.PatchInstanceMethod(reflect.TypeOf(&a), "GetFlights", func(_ *services.APIMockClient, _, _, _ string) ([]services.FlightResponse, error) {
+		return []services.FlightResponse{{ID: 1, Airline: "AirlineX", Price: 200}}, nil
+	})
+	defer monkey.UnpatchInstanceMethod(reflect.TypeOf(&a), "GetFlights")
+
+	result, err := suite.warmer.Warm()
+	require.NoError(err)
+	require.Equal(WarmResult{Routes: 1, Warmed: 2, Failed: 0}, result)
+
+	for i := 0; i < 2; i++ {
+		date := time.Now().AddDate(0, 0, i).Format("2006-01-02")
+		cache, err := suite.redisServer.Get(services.FlightsCacheKey("CityA", "CityB", date))
+		require.NoError(err)
+		require.Contains(cache, `"airline":"AirlineX"`)
+		require.Equal(time.Minute, suite.redisServer.TTL(services.FlightsCacheKey("CityA", "CityB", date)))
+	}
+}
+
+func (suite *CacheWarmerTestSuite) TestWarm_APIMockErr_Failure() {
+	require := suite.Require()
+
+	var a services.APIMockClient
+	monkey.PatchInstanceMethod(reflect.TypeOf(&a), "GetFlights", func(_ *services.APIMockClient, _, _, _ string) ([]services.FlightResponse, error) {
+		return nil, errors.New("error")
+	})
+	defer monkey.UnpatchInstanceMethod(reflect.TypeOf(&a), "GetFlights")
+
+	result, err := suite.warmer.Warm()
+	require.NoError(err)
+	require.Equal(WarmResult{Routes: 1, Warmed: 0, Failed: 2}, result)
+	require.Empty(suite.redisServer.Keys())
+}
+
+func TestCacheWarmer(t *testing.T) {
+	suite.Run(t, new(CacheWarmerTestSuite))
+}
@@ -0,0 +1,48 @@
+package jobs
+
+import (
+	"aliagha/services"
+	"context"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReferenceSync keeps cities, airplanes and canceling situations in line with
+// upstream so that reserved flights can reference them.
+type ReferenceSync struct {
+	DB       *gorm.DB
+	Source   services.ReferenceSource
+	Interval time.Duration
+}
+
+func (s *ReferenceSync) Sync() (services.SyncReport, error) {
+	return services.SyncReference(s.DB, s.Source, false)
+}
+
+// Run syncs right away and then every Interval until ctx is done.
+func (s *ReferenceSync) Run(ctx context.Context) {
+	interval := s.Interval
+	if interval <= 0 {
+		interval = 6 * time.Hour
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		report, err := s.Sync()
+		if err != nil {
+			log.Printf("reference_sync: sync failed, error: %v", err)
+		} else {
+			log.Printf("reference_sync: %s", report)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package jobs
+
+import (
+	"aliagha/models"
+	"context"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const unverifiedUserCleanupBatch = 500
+
+// UnverifiedUserCleanup deletes accounts that verified neither their email nor
+// their cellphone within GracePeriod of signing up, along with their
+// passengers, alerts, API keys and sessions. Accounts that were ever verified
+// are kept even when a changed contact is no longer verified, as are accounts
+// with tickets or payments.
+type UnverifiedUserCleanup struct {
+	DB          *gorm.DB
+	GracePeriod time.Duration
+	Interval    time.Duration
+}
+
+// Cleanup deletes the accounts that were unverified for longer than the grace
+// period at now, and returns how many were deleted.
+func (c *UnverifiedUserCleanup) Cleanup(now time.Time) (int, error) {
+	deleted := 0
+	for {
+		var ids []int32
+		err := c.DB.Transaction(func(tx *gorm.DB) error {
+			err := tx.Model(&models.User{}).
+				Where("verified_at IS NULL AND created_at < ?", now.Add(-c.GracePeriod)).
+				Where("NOT EXISTS (SELECT 1 FROM tickets WHERE tickets.u_id = users.id)").
+				Where("NOT EXISTS (SELECT 1 FROM payments WHERE payments.u_id = users.id)").
+				Where("NOT EXISTS (SELECT 1 FROM user_roles WHERE user_roles.u_id = users.id)").
+				Order("id").Limit(unverifiedUserCleanupBatch).Pluck("id", &ids).Error
+			if err != nil || len(ids) == 0 {
+				return err
+			}
+
+			if err := tx.Where("u_id IN ?", ids).Delete(&models.Alert{}).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Where("u_id IN ?", ids).Delete(&models.Passenger{}).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Where("u_id IN ?", ids).Delete(&models.APIKey{}).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Where("u_id IN ?", ids).Delete(&models.Session{}).Error; err != nil {
+				return err
+			}
+
+			return tx.Where("id IN ?", ids).Delete(&models.User{}).Error
+		})
+		if err != nil {
+			return deleted, err
+		}
+
+		deleted += len(ids)
+		if len(ids) < unverifiedUserCleanupBatch {
+			return deleted, nil
+		}
+	}
+}
+
+// Run cleans up right away and then every Interval until ctx is done.
+func (c *UnverifiedUserCleanup) Run(ctx context.Context) {
+	interval := c.Interval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := c.Cleanup(time.Now())
+		if err != nil {
+			log.Printf("unverified_user_cleanup: cleanup failed, error: %v", err)
+		} else if deleted > 0 {
+			log.Printf("unverified_user_cleanup: deleted %d unverified users", deleted)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
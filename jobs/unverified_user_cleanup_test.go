@@ -0,0 +1,71 @@
+package jobs
+
+import (
+	"aliagha/database"
+	"aliagha/models"
+	"aliagha/services"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestUnverifiedUserCleanup_Cleanup(t *testing.T) {
+	now := time.Date(2023, 6, 28, 10, 0, 0, 0, time.UTC)
+	db := database.NewSQLiteMock()
+
+	_, err := services.SeedFixtures(db, services.FixtureOptions{Seed: 1, Scale: services.FixtureScales["small"], Now: now})
+	require.NoError(t, err)
+
+	old := now.AddDate(0, 0, -8)
+	create := func(email string, createdAt time.Time) models.User {
+		user := models.User{Name: "Unverified", Cellphone: "09121234567", Email: email, CreatedAt: createdAt, UpdatedAt: createdAt}
+		require.NoError(t, db.Create(&user).Error)
+		return user
+	}
+
+	expired := create("expired@example.com", old)
+	recent := create("recent@example.com", now.Add(-time.Hour))
+	// Updating an account does not restart the grace period.
+	edited := create("edited@example.com", old)
+	require.NoError(t, db.Model(&models.User{}).Where("id = ?", edited.ID).
+		Updates(map[string]interface{}{"name": "Edited", "updated_at": now.Add(-time.Hour)}).Error)
+	// A verified account whose email changed is no longer verified, but is
+	// never cleaned up.
+	changed := create("changed@example.com", old)
+	require.NoError(t, db.Model(&models.User{}).Where("id = ?", changed.ID).
+		Updates(map[string]interface{}{"email": "new@example.com", "verified_at": old, "updated_at": now.Add(-time.Hour)}).Error)
+	require.NoError(t, db.Omit("User").Create(&models.Passenger{UID: expired.ID, NationalCode: "0013542419", Name: "P", Birthdate: old}).Error)
+	require.NoError(t, db.Omit("User").Create(&models.Alert{UID: expired.ID, DepCity: "A", ArrCity: "B", FlightDate: now, Active: true}).Error)
+
+	// A seeded user with tickets is kept even when it is unverified.
+	var booked models.Ticket
+	require.NoError(t, db.First(&booked).Error)
+	require.NoError(t, db.Model(&models.User{}).Where("id = ?", booked.UID).
+		Updates(map[string]interface{}{"email_verified_at": nil, "cellphone_verified_at": nil, "verified_at": nil, "created_at": old, "updated_at": old}).Error)
+
+	var before int64
+	require.NoError(t, db.Model(&models.User{}).Count(&before).Error)
+
+	cleanup := &UnverifiedUserCleanup{DB: db, GracePeriod: 7 * 24 * time.Hour}
+	deleted, err := cleanup.Cleanup(now)
+	require.NoError(t, err)
+	require.Equal(t, 2, deleted)
+
+	var after int64
+	require.NoError(t, db.Model(&models.User{}).Count(&after).Error)
+	require.Equal(t, before-2, after)
+
+	require.ErrorIs(t, db.First(&models.User{}, expired.ID).Error, gorm.ErrRecordNotFound)
+	require.ErrorIs(t, db.First(&models.User{}, edited.ID).Error, gorm.ErrRecordNotFound)
+	require.NoError(t, db.First(&models.User{}, recent.ID).Error)
+	require.NoError(t, db.First(&models.User{}, changed.ID).Error)
+	require.NoError(t, db.First(&models.User{}, booked.UID).Error)
+
+	var dependents int64
+	require.NoError(t, db.Model(&models.Passenger{}).Where("u_id = ?", expired.ID).Count(&dependents).Error)
+	require.Zero(t, dependents)
+	require.NoError(t, db.Model(&models.Alert{}).Where("u_id = ?", expired.ID).Count(&dependents).Error)
+	require.Zero(t, dependents)
+}
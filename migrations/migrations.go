@@ -0,0 +1,8 @@
+// Package migrations embeds the SQL migrations of every supported database
+// driver, one folder per driver, so the binary can migrate without them on disk.
+package migrations
+
+import "embed"
+
+//go:embed mysql/*.sql postgres/*.sql sqlite/*.sql
+var FS embed.FS
@@ -0,0 +1,13 @@
+ALTER TABLE tickets DROP FOREIGN KEY fk_tickets_flight;
+
+-- Flights sold by several providers keep a single row.
+DELETE later FROM flights later JOIN flights earlier ON earlier.id = later.id AND earlier.provider < later.provider;
+
+ALTER TABLE flights
+    DROP PRIMARY KEY ,
+    DROP COLUMN provider ,
+    ADD PRIMARY KEY (id) ,
+    MODIFY id int NOT NULL AUTO_INCREMENT;
+
+ALTER TABLE tickets
+    ADD CONSTRAINT tickets_ibfk_2 FOREIGN KEY (f_id) REFERENCES flights(id);
@@ -0,0 +1,23 @@
+-- Flight ids are only unique within a provider, so flights are keyed by both.
+-- Flights booked before keep an empty provider, and tickets that recorded a
+-- provider get a copy of their flight under it.
+ALTER TABLE tickets DROP FOREIGN KEY tickets_ibfk_2;
+
+ALTER TABLE flights
+    MODIFY id int NOT NULL ,
+    ADD COLUMN provider varchar(64) NOT NULL DEFAULT '' ,
+    DROP PRIMARY KEY ,
+    ADD PRIMARY KEY (provider, id);
+
+INSERT INTO flights (provider, id, dep_city_id, arr_city_id, dep_time, arr_time, airplane_id, airline, price, cxl_sit_id,
+       flight_class, baggage_allowance, meal_service, gate, created_at, updated_at, dep_airport_id, arr_airport_id)
+SELECT DISTINCT tickets.provider, flights.id, flights.dep_city_id, flights.arr_city_id, flights.dep_time, flights.arr_time,
+       flights.airplane_id, flights.airline, flights.price, flights.cxl_sit_id, flights.flight_class,
+       flights.baggage_allowance, flights.meal_service, flights.gate, flights.created_at, flights.updated_at,
+       flights.dep_airport_id, flights.arr_airport_id
+FROM tickets
+JOIN flights ON flights.id = tickets.f_id AND flights.provider = ''
+WHERE tickets.provider <> '';
+
+ALTER TABLE tickets
+    ADD CONSTRAINT fk_tickets_flight FOREIGN KEY (provider, f_id) REFERENCES flights(provider, id);
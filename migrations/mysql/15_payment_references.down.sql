@@ -0,0 +1,3 @@
+ALTER TABLE payments DROP COLUMN ref_id;
+
+ALTER TABLE payments DROP COLUMN trans_id;
@@ -0,0 +1,5 @@
+-- The authority the gateway returns for a payment request and the reference
+-- id of the verified payment.
+ALTER TABLE payments ADD COLUMN trans_id varchar(255) NULL;
+
+ALTER TABLE payments ADD COLUMN ref_id varchar(255) NULL;
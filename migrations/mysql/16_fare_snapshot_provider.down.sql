@@ -0,0 +1,4 @@
+ALTER TABLE fare_snapshots
+    DROP INDEX fare_snapshots_flight ,
+    DROP COLUMN provider ,
+    ADD INDEX flight_id (flight_id, captured_at);
@@ -0,0 +1,6 @@
+-- Flight ids are only unique within a provider, so snapshots are looked up by
+-- both. Snapshots taken before keep an empty provider.
+ALTER TABLE fare_snapshots
+    ADD COLUMN provider varchar(64) NOT NULL DEFAULT '' AFTER id ,
+    DROP INDEX flight_id ,
+    ADD INDEX fare_snapshots_flight (provider, flight_id, captured_at);
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS alerts (
+    id int PRIMARY KEY AUTO_INCREMENT ,
+    u_id int NOT NULL ,
+    dep_city varchar(255) NOT NULL ,
+    arr_city varchar(255) NOT NULL ,
+    flight_date date NOT NULL ,
+    max_price int NULL ,
+    last_price int NULL ,
+    last_seats int NULL ,
+    active boolean NOT NULL DEFAULT TRUE ,
+    last_notified_at datetime NULL ,
+    created_at datetime DEFAULT NOW() ,
+    updated_at datetime DEFAULT NOW() ON UPDATE NOW() ,
+
+    FOREIGN KEY (u_id) REFERENCES users(id) ,
+    INDEX (active, flight_date)
+    );
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS fare_snapshots (
+    id bigint PRIMARY KEY AUTO_INCREMENT ,
+    flight_id int NOT NULL ,
+    dep_city varchar(255) NOT NULL ,
+    arr_city varchar(255) NOT NULL ,
+    flight_date date NOT NULL ,
+    airline varchar(255) NOT NULL ,
+    price int NOT NULL ,
+    remaining_seats int NOT NULL ,
+    captured_at datetime NOT NULL ,
+
+    INDEX (flight_id, captured_at) ,
+    INDEX (dep_city, arr_city, flight_date, captured_at)
+    );
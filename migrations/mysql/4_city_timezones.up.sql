@@ -0,0 +1 @@
+ALTER TABLE cities ADD COLUMN timezone varchar(64) NOT NULL DEFAULT 'Asia/Tehran' AFTER name;
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS airports (
+    id int PRIMARY KEY AUTO_INCREMENT ,
+    city_id int NOT NULL ,
+    iata char(3) NOT NULL UNIQUE ,
+    icao char(4) UNIQUE ,
+    name varchar(255) NOT NULL ,
+    latitude decimal(9,6) NOT NULL ,
+    longitude decimal(9,6) NOT NULL ,
+    created_at datetime DEFAULT NOW() ,
+    updated_at datetime DEFAULT NOW() ON UPDATE NOW() ,
+
+    FOREIGN KEY (city_id) REFERENCES cities(id)
+    );
+
+ALTER TABLE flights
+    ADD COLUMN dep_airport_id int NULL AFTER arr_city_id ,
+    ADD COLUMN arr_airport_id int NULL AFTER dep_airport_id ,
+    ADD CONSTRAINT fk_flights_dep_airport FOREIGN KEY (dep_airport_id) REFERENCES airports(id) ,
+    ADD CONSTRAINT fk_flights_arr_airport FOREIGN KEY (arr_airport_id) REFERENCES airports(id);
@@ -0,0 +1,5 @@
+DROP INDEX users_cellphone_index ON users;
+
+UPDATE users SET email = CONCAT('user-', id, '@invalid') WHERE email IS NULL;
+
+ALTER TABLE users MODIFY email varchar(255) NOT NULL;
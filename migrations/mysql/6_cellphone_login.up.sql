@@ -0,0 +1,4 @@
+-- Users who sign up with an SMS code have no email address yet.
+ALTER TABLE users MODIFY email varchar(255) NULL;
+
+CREATE INDEX users_cellphone_index ON users (cellphone);
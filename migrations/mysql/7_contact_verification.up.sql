@@ -0,0 +1,7 @@
+ALTER TABLE users ADD COLUMN email_verified_at datetime NULL;
+ALTER TABLE users ADD COLUMN cellphone_verified_at datetime NULL;
+
+-- Accounts created before verification existed keep working and are not
+-- cleaned up as unverified.
+UPDATE users SET email_verified_at = created_at WHERE email IS NOT NULL;
+UPDATE users SET cellphone_verified_at = created_at;
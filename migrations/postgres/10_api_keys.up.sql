@@ -0,0 +1,19 @@
+-- Keys of partner agencies calling the API server-to-server. Only a hash of
+-- the key is stored; prefix is kept to tell keys apart.
+CREATE TABLE IF NOT EXISTS api_keys (
+    id serial PRIMARY KEY ,
+    u_id int NOT NULL ,
+    name varchar(255) NOT NULL ,
+    prefix varchar(16) NOT NULL ,
+    key_hash char(64) NOT NULL UNIQUE ,
+    scopes varchar(255) NOT NULL ,
+    rate_limit int NOT NULL DEFAULT 0 ,
+    expires_at timestamp NULL ,
+    last_used_at timestamp NULL ,
+    revoked_at timestamp NULL ,
+    created_at timestamp DEFAULT NOW() ,
+
+    FOREIGN KEY (u_id) REFERENCES users(id)
+    );
+
+CREATE INDEX api_keys_u_id_index ON api_keys (u_id);
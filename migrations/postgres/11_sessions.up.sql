@@ -0,0 +1,17 @@
+-- One row per login. family is the refresh token family of the session; the
+-- session is active as long as the family is, which is kept in Redis.
+CREATE TABLE IF NOT EXISTS sessions (
+    id serial PRIMARY KEY ,
+    u_id int NOT NULL ,
+    family varchar(64) NOT NULL UNIQUE ,
+    device varchar(100) NOT NULL DEFAULT '' ,
+    ip varchar(45) NOT NULL DEFAULT '' ,
+    user_agent varchar(255) NOT NULL DEFAULT '' ,
+    created_at timestamp DEFAULT NOW() ,
+    last_seen_at timestamp DEFAULT NOW() ,
+    revoked_at timestamp NULL ,
+
+    FOREIGN KEY (u_id) REFERENCES users(id)
+    );
+
+CREATE INDEX sessions_u_id_index ON sessions (u_id);
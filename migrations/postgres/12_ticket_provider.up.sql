@@ -0,0 +1,3 @@
+-- Flight ids are only unique within a provider, so tickets keep the provider
+-- that sold them to route cancellations.
+ALTER TABLE tickets ADD COLUMN provider varchar(64) NOT NULL DEFAULT '';
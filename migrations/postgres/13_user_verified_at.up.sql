@@ -0,0 +1,5 @@
+-- Unlike the per contact columns, verified_at is not cleared when a contact
+-- changes, so accounts that were ever verified are never cleaned up.
+ALTER TABLE users ADD COLUMN verified_at timestamp NULL;
+
+UPDATE users SET verified_at = COALESCE(email_verified_at, cellphone_verified_at);
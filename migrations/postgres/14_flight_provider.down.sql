@@ -0,0 +1,13 @@
+ALTER TABLE tickets DROP CONSTRAINT fk_tickets_flight;
+
+-- Flights sold by several providers keep a single row.
+DELETE FROM flights later USING flights earlier WHERE earlier.id = later.id AND earlier.provider < later.provider;
+
+ALTER TABLE flights
+    DROP CONSTRAINT flights_pkey ,
+    DROP COLUMN provider ,
+    ADD PRIMARY KEY (id) ,
+    ALTER COLUMN id SET DEFAULT nextval('flights_id_seq');
+
+ALTER TABLE tickets
+    ADD CONSTRAINT tickets_f_id_fkey FOREIGN KEY (f_id) REFERENCES flights(id);
@@ -0,0 +1,96 @@
+
+CREATE TABLE IF NOT EXISTS users
+(
+    id serial PRIMARY KEY ,
+    name varchar(255) NOT NULL ,
+    password varchar(255) NOT NULL ,
+    cellphone varchar(16) NOT NULL ,
+    email varchar(255) NOT NULL UNIQUE ,
+    created_at timestamp DEFAULT NOW() ,
+    updated_at timestamp DEFAULT NOW()
+    );
+
+CREATE TABLE IF NOT EXISTS passengers (
+    id serial PRIMARY KEY ,
+    u_id int NOT NULL,
+    national_code int NOT NULL ,
+    name varchar(255) NOT NULL ,
+    birthdate date NOT NULL ,
+    created_at timestamp DEFAULT NOW() ,
+    updated_at timestamp DEFAULT NOW() ,
+
+    FOREIGN KEY (u_id) REFERENCES users(id) ,
+    UNIQUE (u_id, national_code)
+    );
+
+CREATE TABLE IF NOT EXISTS cities (
+    id serial PRIMARY KEY ,
+    name varchar(255) UNIQUE NOT NULL ,
+    created_at timestamp DEFAULT NOW() ,
+    updated_at timestamp DEFAULT NOW()
+    );
+
+CREATE TABLE IF NOT EXISTS airplanes (
+    id serial PRIMARY KEY ,
+    name varchar(255) UNIQUE NOT NULL ,
+    created_at timestamp DEFAULT NOW() ,
+    updated_at timestamp DEFAULT NOW()
+    );
+
+CREATE TABLE IF NOT EXISTS canceling_situations (
+    id serial PRIMARY KEY ,
+    description varchar(255) NOT NULL ,
+    data varchar(255) NOT NULL ,
+    created_at timestamp DEFAULT NOW() ,
+    updated_at timestamp DEFAULT NOW()
+    );
+
+CREATE TABLE IF NOT EXISTS flights (
+    id serial PRIMARY KEY,
+    dep_city_id int NOT NULL ,
+    arr_city_id int NOT NULL ,
+    dep_time timestamp NOT NULL ,
+    arr_time timestamp NOT NULL ,
+    airplane_id int NOT NULL ,
+    airline varchar(255) NOT NULL ,
+    price int NOT NULL ,
+    cxl_sit_id int NOT NULL ,
+    flight_class varchar(255) NOT NULL ,
+    baggage_allowance varchar(255) NOT NULL ,
+    meal_service varchar(255) NOT NULL,
+    gate varchar(255) NOT NULL ,
+    created_at timestamp DEFAULT NOW(),
+    updated_at timestamp DEFAULT NOW(),
+
+    FOREIGN KEY (dep_city_id) REFERENCES cities(id),
+    FOREIGN KEY (arr_city_id) REFERENCES cities(id),
+    FOREIGN KEY (airplane_id) REFERENCES airplanes(id),
+    FOREIGN KEY (cxl_sit_id) REFERENCES canceling_situations(id)
+    );
+
+CREATE TABLE IF NOT EXISTS tickets (
+    id serial PRIMARY KEY ,
+    u_id int NOT NULL ,
+    p_ids varchar(255) NOT NULL ,
+    f_id int NOT NULL ,
+    status text NOT NULL ,
+    price int NOT NULL ,
+    created_at timestamp DEFAULT NOW() ,
+    updated_at timestamp DEFAULT NOW() ,
+
+    FOREIGN KEY (u_id) REFERENCES users(id) ,
+    FOREIGN KEY (f_id) REFERENCES flights(id)
+    );
+
+CREATE TABLE IF NOT EXISTS payments (
+    id serial PRIMARY KEY,
+    u_id int NOT NULL ,
+    classification text NOT NULL ,
+    ticket_id int NOT NULL ,
+    status varchar(255) NOT NULL ,
+    created_at timestamp DEFAULT NOW() ,
+    updated_at timestamp DEFAULT NOW() ,
+
+    FOREIGN KEY (u_id) REFERENCES users(id) ,
+    FOREIGN KEY (ticket_id) REFERENCES tickets(id)
+    );
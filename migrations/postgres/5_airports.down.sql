@@ -0,0 +1,7 @@
+ALTER TABLE flights
+    DROP CONSTRAINT fk_flights_dep_airport ,
+    DROP CONSTRAINT fk_flights_arr_airport ,
+    DROP COLUMN dep_airport_id ,
+    DROP COLUMN arr_airport_id;
+
+DROP TABLE IF EXISTS airports;
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS airports (
+    id serial PRIMARY KEY ,
+    city_id int NOT NULL ,
+    iata char(3) NOT NULL UNIQUE ,
+    icao char(4) UNIQUE ,
+    name varchar(255) NOT NULL ,
+    latitude decimal(9,6) NOT NULL ,
+    longitude decimal(9,6) NOT NULL ,
+    created_at timestamp DEFAULT NOW() ,
+    updated_at timestamp DEFAULT NOW() ,
+
+    FOREIGN KEY (city_id) REFERENCES cities(id)
+    );
+
+ALTER TABLE flights
+    ADD COLUMN dep_airport_id int NULL ,
+    ADD COLUMN arr_airport_id int NULL ,
+    ADD CONSTRAINT fk_flights_dep_airport FOREIGN KEY (dep_airport_id) REFERENCES airports(id) ,
+    ADD CONSTRAINT fk_flights_arr_airport FOREIGN KEY (arr_airport_id) REFERENCES airports(id);
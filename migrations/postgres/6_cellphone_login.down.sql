@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS users_cellphone_index;
+
+UPDATE users SET email = 'user-' || id || '@invalid' WHERE email IS NULL;
+
+ALTER TABLE users ALTER COLUMN email SET NOT NULL;
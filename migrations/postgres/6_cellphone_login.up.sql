@@ -0,0 +1,4 @@
+-- Users who sign up with an SMS code have no email address yet.
+ALTER TABLE users ALTER COLUMN email DROP NOT NULL;
+
+CREATE INDEX users_cellphone_index ON users (cellphone);
@@ -0,0 +1,2 @@
+-- Deleted accounts are anonymized and kept for their tickets and payments.
+ALTER TABLE users ADD COLUMN deleted_at timestamp NULL;
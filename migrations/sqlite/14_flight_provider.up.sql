@@ -0,0 +1,75 @@
+-- Flight ids are only unique within a provider, so flights are keyed by both.
+-- SQLite cannot change a primary key, so flights and the tickets referencing
+-- them are rebuilt. Flights booked before keep an empty provider, and tickets
+-- that recorded a provider get a copy of their flight under it.
+PRAGMA defer_foreign_keys = ON;
+
+CREATE TEMPORARY TABLE flights_backup AS
+SELECT '' AS provider, id, dep_city_id, arr_city_id, dep_time, arr_time, airplane_id, airline, price, cxl_sit_id,
+       flight_class, baggage_allowance, meal_service, gate, created_at, updated_at, dep_airport_id, arr_airport_id
+FROM flights;
+
+INSERT INTO flights_backup
+SELECT DISTINCT tickets.provider, flights.id, flights.dep_city_id, flights.arr_city_id, flights.dep_time, flights.arr_time,
+       flights.airplane_id, flights.airline, flights.price, flights.cxl_sit_id, flights.flight_class,
+       flights.baggage_allowance, flights.meal_service, flights.gate, flights.created_at, flights.updated_at,
+       flights.dep_airport_id, flights.arr_airport_id
+FROM tickets
+JOIN flights ON flights.id = tickets.f_id
+WHERE tickets.provider <> '';
+
+CREATE TEMPORARY TABLE tickets_backup AS SELECT * FROM tickets;
+
+DROP TABLE tickets;
+
+DROP TABLE flights;
+
+CREATE TABLE flights (
+    provider varchar(64) NOT NULL DEFAULT '' ,
+    id int NOT NULL ,
+    dep_city_id int NOT NULL ,
+    arr_city_id int NOT NULL ,
+    dep_time datetime NOT NULL ,
+    arr_time datetime NOT NULL ,
+    airplane_id int NOT NULL ,
+    airline varchar(255) NOT NULL ,
+    price int NOT NULL ,
+    cxl_sit_id int NOT NULL ,
+    flight_class varchar(255) NOT NULL ,
+    baggage_allowance varchar(255) NOT NULL ,
+    meal_service varchar(255) NOT NULL,
+    gate varchar(255) NOT NULL ,
+    created_at datetime DEFAULT CURRENT_TIMESTAMP,
+    updated_at datetime DEFAULT CURRENT_TIMESTAMP,
+    dep_airport_id int NULL REFERENCES airports(id),
+    arr_airport_id int NULL REFERENCES airports(id),
+
+    PRIMARY KEY (provider, id),
+    FOREIGN KEY (dep_city_id) REFERENCES cities(id),
+    FOREIGN KEY (arr_city_id) REFERENCES cities(id),
+    FOREIGN KEY (airplane_id) REFERENCES airplanes(id),
+    FOREIGN KEY (cxl_sit_id) REFERENCES canceling_situations(id)
+    );
+
+CREATE TABLE tickets (
+    id integer PRIMARY KEY AUTOINCREMENT ,
+    u_id int NOT NULL ,
+    p_ids varchar(255) NOT NULL ,
+    f_id int NOT NULL ,
+    status text NOT NULL ,
+    price int NOT NULL ,
+    created_at datetime DEFAULT CURRENT_TIMESTAMP ,
+    updated_at datetime DEFAULT CURRENT_TIMESTAMP ,
+    provider varchar(64) NOT NULL DEFAULT '' ,
+
+    FOREIGN KEY (u_id) REFERENCES users(id) ,
+    FOREIGN KEY (provider, f_id) REFERENCES flights(provider, id)
+    );
+
+INSERT INTO flights SELECT * FROM flights_backup;
+
+INSERT INTO tickets SELECT * FROM tickets_backup;
+
+DROP TABLE flights_backup;
+
+DROP TABLE tickets_backup;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS fare_snapshots_flight;
+
+ALTER TABLE fare_snapshots DROP COLUMN provider;
+
+CREATE INDEX IF NOT EXISTS fare_snapshots_flight ON fare_snapshots (flight_id, captured_at);
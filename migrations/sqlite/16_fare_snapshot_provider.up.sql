@@ -0,0 +1,7 @@
+-- Flight ids are only unique within a provider, so snapshots are looked up by
+-- both. Snapshots taken before keep an empty provider.
+ALTER TABLE fare_snapshots ADD COLUMN provider varchar(64) NOT NULL DEFAULT '';
+
+DROP INDEX IF EXISTS fare_snapshots_flight;
+
+CREATE INDEX IF NOT EXISTS fare_snapshots_flight ON fare_snapshots (provider, flight_id, captured_at);
@@ -0,0 +1,96 @@
+
+CREATE TABLE IF NOT EXISTS users
+(
+    id integer PRIMARY KEY AUTOINCREMENT ,
+    name varchar(255) NOT NULL ,
+    password varchar(255) NOT NULL ,
+    cellphone varchar(16) NOT NULL ,
+    email varchar(255) NOT NULL UNIQUE ,
+    created_at datetime DEFAULT CURRENT_TIMESTAMP ,
+    updated_at datetime DEFAULT CURRENT_TIMESTAMP
+    );
+
+CREATE TABLE IF NOT EXISTS passengers (
+    id integer PRIMARY KEY AUTOINCREMENT ,
+    u_id int NOT NULL,
+    national_code int NOT NULL ,
+    name varchar(255) NOT NULL ,
+    birthdate date NOT NULL ,
+    created_at datetime DEFAULT CURRENT_TIMESTAMP ,
+    updated_at datetime DEFAULT CURRENT_TIMESTAMP ,
+
+    FOREIGN KEY (u_id) REFERENCES users(id) ,
+    UNIQUE (u_id, national_code)
+    );
+
+CREATE TABLE IF NOT EXISTS cities (
+    id integer PRIMARY KEY AUTOINCREMENT ,
+    name varchar(255) UNIQUE NOT NULL ,
+    created_at datetime DEFAULT CURRENT_TIMESTAMP ,
+    updated_at datetime DEFAULT CURRENT_TIMESTAMP
+    );
+
+CREATE TABLE IF NOT EXISTS airplanes (
+    id integer PRIMARY KEY AUTOINCREMENT ,
+    name varchar(255) UNIQUE NOT NULL ,
+    created_at datetime DEFAULT CURRENT_TIMESTAMP ,
+    updated_at datetime DEFAULT CURRENT_TIMESTAMP
+    );
+
+CREATE TABLE IF NOT EXISTS canceling_situations (
+    id integer PRIMARY KEY AUTOINCREMENT ,
+    description varchar(255) NOT NULL ,
+    data varchar(255) NOT NULL ,
+    created_at datetime DEFAULT CURRENT_TIMESTAMP ,
+    updated_at datetime DEFAULT CURRENT_TIMESTAMP
+    );
+
+CREATE TABLE IF NOT EXISTS flights (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    dep_city_id int NOT NULL ,
+    arr_city_id int NOT NULL ,
+    dep_time datetime NOT NULL ,
+    arr_time datetime NOT NULL ,
+    airplane_id int NOT NULL ,
+    airline varchar(255) NOT NULL ,
+    price int NOT NULL ,
+    cxl_sit_id int NOT NULL ,
+    flight_class varchar(255) NOT NULL ,
+    baggage_allowance varchar(255) NOT NULL ,
+    meal_service varchar(255) NOT NULL,
+    gate varchar(255) NOT NULL ,
+    created_at datetime DEFAULT CURRENT_TIMESTAMP,
+    updated_at datetime DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (dep_city_id) REFERENCES cities(id),
+    FOREIGN KEY (arr_city_id) REFERENCES cities(id),
+    FOREIGN KEY (airplane_id) REFERENCES airplanes(id),
+    FOREIGN KEY (cxl_sit_id) REFERENCES canceling_situations(id)
+    );
+
+CREATE TABLE IF NOT EXISTS tickets (
+    id integer PRIMARY KEY AUTOINCREMENT ,
+    u_id int NOT NULL ,
+    p_ids varchar(255) NOT NULL ,
+    f_id int NOT NULL ,
+    status text NOT NULL ,
+    price int NOT NULL ,
+    created_at datetime DEFAULT CURRENT_TIMESTAMP ,
+    updated_at datetime DEFAULT CURRENT_TIMESTAMP ,
+
+    FOREIGN KEY (u_id) REFERENCES users(id) ,
+    FOREIGN KEY (f_id) REFERENCES flights(id)
+    );
+
+CREATE TABLE IF NOT EXISTS payments (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    u_id int NOT NULL ,
+    classification text NOT NULL ,
+    ticket_id int NOT NULL ,
+    status varchar(255) NOT NULL ,
+    created_at datetime DEFAULT CURRENT_TIMESTAMP ,
+    updated_at datetime DEFAULT CURRENT_TIMESTAMP ,
+
+    FOREIGN KEY (u_id) REFERENCES users(id) ,
+    FOREIGN KEY (ticket_id) REFERENCES tickets(id)
+    );
\ No newline at end of file
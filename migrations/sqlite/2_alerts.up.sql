@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS alerts (
+    id integer PRIMARY KEY AUTOINCREMENT ,
+    u_id int NOT NULL ,
+    dep_city varchar(255) NOT NULL ,
+    arr_city varchar(255) NOT NULL ,
+    flight_date date NOT NULL ,
+    max_price int NULL ,
+    last_price int NULL ,
+    last_seats int NULL ,
+    active boolean NOT NULL DEFAULT TRUE ,
+    last_notified_at datetime NULL ,
+    created_at datetime DEFAULT CURRENT_TIMESTAMP ,
+    updated_at datetime DEFAULT CURRENT_TIMESTAMP ,
+
+    FOREIGN KEY (u_id) REFERENCES users(id)
+    );
+
+CREATE INDEX IF NOT EXISTS alerts_active_flight_date ON alerts (active, flight_date);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS fare_snapshots (
+    id integer PRIMARY KEY AUTOINCREMENT ,
+    flight_id int NOT NULL ,
+    dep_city varchar(255) NOT NULL ,
+    arr_city varchar(255) NOT NULL ,
+    flight_date date NOT NULL ,
+    airline varchar(255) NOT NULL ,
+    price int NOT NULL ,
+    remaining_seats int NOT NULL ,
+    captured_at datetime NOT NULL
+    );
+
+CREATE INDEX IF NOT EXISTS fare_snapshots_flight ON fare_snapshots (flight_id, captured_at);
+
+CREATE INDEX IF NOT EXISTS fare_snapshots_route ON fare_snapshots (dep_city, arr_city, flight_date, captured_at);
@@ -0,0 +1,40 @@
+-- SQLite cannot drop columns that are part of a foreign key, so flights is
+-- rebuilt without the airport columns. Its rows are copied back after the
+-- rebuild, which settles the foreign keys of tickets before the commit.
+PRAGMA defer_foreign_keys = ON;
+
+CREATE TEMPORARY TABLE flights_backup AS
+SELECT id, dep_city_id, arr_city_id, dep_time, arr_time, airplane_id, airline, price, cxl_sit_id,
+       flight_class, baggage_allowance, meal_service, gate, created_at, updated_at
+FROM flights;
+
+DROP TABLE flights;
+
+CREATE TABLE flights (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    dep_city_id int NOT NULL ,
+    arr_city_id int NOT NULL ,
+    dep_time datetime NOT NULL ,
+    arr_time datetime NOT NULL ,
+    airplane_id int NOT NULL ,
+    airline varchar(255) NOT NULL ,
+    price int NOT NULL ,
+    cxl_sit_id int NOT NULL ,
+    flight_class varchar(255) NOT NULL ,
+    baggage_allowance varchar(255) NOT NULL ,
+    meal_service varchar(255) NOT NULL,
+    gate varchar(255) NOT NULL ,
+    created_at datetime DEFAULT CURRENT_TIMESTAMP,
+    updated_at datetime DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (dep_city_id) REFERENCES cities(id),
+    FOREIGN KEY (arr_city_id) REFERENCES cities(id),
+    FOREIGN KEY (airplane_id) REFERENCES airplanes(id),
+    FOREIGN KEY (cxl_sit_id) REFERENCES canceling_situations(id)
+    );
+
+INSERT INTO flights SELECT * FROM flights_backup;
+
+DROP TABLE flights_backup;
+
+DROP TABLE IF EXISTS airports;
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS airports (
+    id integer PRIMARY KEY AUTOINCREMENT ,
+    city_id int NOT NULL ,
+    iata char(3) NOT NULL UNIQUE ,
+    icao char(4) UNIQUE ,
+    name varchar(255) NOT NULL ,
+    latitude decimal(9,6) NOT NULL ,
+    longitude decimal(9,6) NOT NULL ,
+    created_at datetime DEFAULT CURRENT_TIMESTAMP ,
+    updated_at datetime DEFAULT CURRENT_TIMESTAMP ,
+
+    FOREIGN KEY (city_id) REFERENCES cities(id)
+    );
+
+ALTER TABLE flights ADD COLUMN dep_airport_id int NULL REFERENCES airports(id);
+
+ALTER TABLE flights ADD COLUMN arr_airport_id int NULL REFERENCES airports(id);
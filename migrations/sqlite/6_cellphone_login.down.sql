@@ -0,0 +1,22 @@
+PRAGMA defer_foreign_keys = ON;
+
+CREATE TEMPORARY TABLE users_backup AS
+SELECT id, name, password, cellphone, COALESCE(email, 'user-' || id || '@invalid') AS email, created_at, updated_at
+FROM users;
+
+DROP TABLE users;
+
+CREATE TABLE users
+(
+    id integer PRIMARY KEY AUTOINCREMENT ,
+    name varchar(255) NOT NULL ,
+    password varchar(255) NOT NULL ,
+    cellphone varchar(16) NOT NULL ,
+    email varchar(255) NOT NULL UNIQUE ,
+    created_at datetime DEFAULT CURRENT_TIMESTAMP ,
+    updated_at datetime DEFAULT CURRENT_TIMESTAMP
+    );
+
+INSERT INTO users SELECT * FROM users_backup;
+
+DROP TABLE users_backup;
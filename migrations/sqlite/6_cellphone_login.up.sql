@@ -0,0 +1,26 @@
+-- Users who sign up with an SMS code have no email address yet. SQLite cannot
+-- drop a NOT NULL constraint, so users is rebuilt; its rows are copied back
+-- with the same ids, which settles the foreign keys before the commit.
+PRAGMA defer_foreign_keys = ON;
+
+CREATE TEMPORARY TABLE users_backup AS
+SELECT id, name, password, cellphone, email, created_at, updated_at FROM users;
+
+DROP TABLE users;
+
+CREATE TABLE users
+(
+    id integer PRIMARY KEY AUTOINCREMENT ,
+    name varchar(255) NOT NULL ,
+    password varchar(255) NOT NULL ,
+    cellphone varchar(16) NOT NULL ,
+    email varchar(255) UNIQUE ,
+    created_at datetime DEFAULT CURRENT_TIMESTAMP ,
+    updated_at datetime DEFAULT CURRENT_TIMESTAMP
+    );
+
+INSERT INTO users SELECT * FROM users_backup;
+
+DROP TABLE users_backup;
+
+CREATE INDEX users_cellphone_index ON users (cellphone);
@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS roles (
+    id integer PRIMARY KEY AUTOINCREMENT ,
+    name varchar(64) NOT NULL UNIQUE ,
+    description varchar(255) NOT NULL DEFAULT '' ,
+    created_at datetime DEFAULT CURRENT_TIMESTAMP
+    );
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id int NOT NULL ,
+    permission varchar(64) NOT NULL ,
+
+    PRIMARY KEY (role_id, permission) ,
+    FOREIGN KEY (role_id) REFERENCES roles(id)
+    );
+
+CREATE TABLE IF NOT EXISTS user_roles (
+    u_id int NOT NULL ,
+    role_id int NOT NULL ,
+    created_at datetime DEFAULT CURRENT_TIMESTAMP ,
+
+    PRIMARY KEY (u_id, role_id) ,
+    FOREIGN KEY (u_id) REFERENCES users(id) ,
+    FOREIGN KEY (role_id) REFERENCES roles(id)
+    );
+
+INSERT INTO roles (name, description) VALUES
+    ('admin', 'Full access to every back-office endpoint'),
+    ('agent', 'Support agents looking up users and their tickets');
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT id, '*' FROM roles WHERE name = 'admin';
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT id, 'users:read' FROM roles WHERE name = 'agent'
+UNION ALL
+SELECT id, 'tickets:read' FROM roles WHERE name = 'agent';
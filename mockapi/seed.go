@@ -0,0 +1,172 @@
+package mockapi
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultSeed is served when no seed file is given. Its cities, airports and
+// canceling situations match the bundled airports dataset.
+//
+//go:embed data/seed.yaml
+var defaultSeed []byte
+
+type Seed struct {
+	// Timezone is the zone schedule departure times are given in.
+	Timezone            string               `yaml:"timezone"`
+	Days                int                  `yaml:"days"`
+	Cities              []City               `yaml:"cities"`
+	Airports            []Airport            `yaml:"airports"`
+	Airplanes           []Airplane           `yaml:"airplanes"`
+	CancelingSituations []CancelingSituation `yaml:"canceling_situations"`
+	Schedules           []Schedule           `yaml:"schedules"`
+}
+
+type City struct {
+	ID   int32  `yaml:"id" json:"id"`
+	Name string `yaml:"name" json:"name"`
+}
+
+type Airport struct {
+	IATA string `yaml:"iata" json:"iata"`
+	ICAO string `yaml:"icao" json:"icao,omitempty"`
+	Name string `yaml:"name" json:"name"`
+}
+
+type Airplane struct {
+	ID   int32  `yaml:"id" json:"id"`
+	Name string `yaml:"name" json:"name"`
+}
+
+type CancelingSituation struct {
+	ID          int32  `yaml:"id" json:"id"`
+	Description string `yaml:"description" json:"description"`
+	Data        string `yaml:"data" json:"data"`
+}
+
+// Schedule is a flight operated every day at the same local time.
+type Schedule struct {
+	DepCityID        int32         `yaml:"dep_city_id"`
+	ArrCityID        int32         `yaml:"arr_city_id"`
+	DepAirport       string        `yaml:"dep_airport"`
+	ArrAirport       string        `yaml:"arr_airport"`
+	Departure        string        `yaml:"departure"`
+	Duration         time.Duration `yaml:"duration"`
+	Airline          string        `yaml:"airline"`
+	AirplaneID       int32         `yaml:"airplane_id"`
+	Price            int32         `yaml:"price"`
+	CxlSitID         int32         `yaml:"cxl_sit_id"`
+	Seats            int32         `yaml:"seats"`
+	FlightClass      string        `yaml:"flight_class"`
+	BaggageAllowance string        `yaml:"baggage_allowance"`
+	MealService      string        `yaml:"meal_service"`
+	Gate             string        `yaml:"gate"`
+}
+
+// DefaultSeed returns the seed bundled with the binary.
+func DefaultSeed() (*Seed, error) {
+	return ParseSeed(defaultSeed)
+}
+
+func LoadSeed(path string) (*Seed, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseSeed(data)
+}
+
+func ParseSeed(data []byte) (*Seed, error) {
+	var seed Seed
+	if err := yaml.Unmarshal(data, &seed); err != nil {
+		return nil, fmt.Errorf("mockapi: parsing seed failed, error: %v", err)
+	}
+
+	if err := seed.validate(); err != nil {
+		return nil, fmt.Errorf("mockapi: invalid seed, error: %v", err)
+	}
+
+	return &seed, nil
+}
+
+func (s *Seed) validate() error {
+	if s.Days <= 0 {
+		s.Days = 30
+	}
+
+	if len(s.Schedules) >= maxSchedules {
+		return fmt.Errorf("at most %d schedules are supported", maxSchedules-1)
+	}
+
+	cities := make(map[int32]bool, len(s.Cities))
+	for _, city := range s.Cities {
+		cities[city.ID] = true
+	}
+
+	airports := make(map[string]bool, len(s.Airports))
+	for _, airport := range s.Airports {
+		airports[strings.ToUpper(airport.IATA)] = true
+	}
+
+	airplanes := make(map[int32]bool, len(s.Airplanes))
+	for _, airplane := range s.Airplanes {
+		airplanes[airplane.ID] = true
+	}
+
+	situations := make(map[int32]bool, len(s.CancelingSituations))
+	for _, situation := range s.CancelingSituations {
+		situations[situation.ID] = true
+	}
+
+	for i, schedule := range s.Schedules {
+		switch {
+		case !cities[schedule.DepCityID] || !cities[schedule.ArrCityID]:
+			return fmt.Errorf("schedule %d: unknown city", i+1)
+		case schedule.DepAirport != "" && !airports[strings.ToUpper(schedule.DepAirport)],
+			schedule.ArrAirport != "" && !airports[strings.ToUpper(schedule.ArrAirport)]:
+			return fmt.Errorf("schedule %d: unknown airport", i+1)
+		case !airplanes[schedule.AirplaneID]:
+			return fmt.Errorf("schedule %d: unknown airplane %d", i+1, schedule.AirplaneID)
+		case !situations[schedule.CxlSitID]:
+			return fmt.Errorf("schedule %d: unknown canceling situation %d", i+1, schedule.CxlSitID)
+		case schedule.Duration <= 0:
+			return fmt.Errorf("schedule %d: duration must be positive", i+1)
+		case schedule.Seats <= 0:
+			return fmt.Errorf("schedule %d: seats must be positive", i+1)
+		}
+
+		if _, err := parseClock(schedule.Departure); err != nil {
+			return fmt.Errorf("schedule %d: %v", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+// parseClock parses an "HH:MM" time of day.
+func parseClock(clock string) (time.Duration, error) {
+	parts := strings.Split(clock, ":")
+	if len(parts) != 2 {
+		return 0, errors.New("departure must be formatted as HH:MM")
+	}
+
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, fmt.Errorf("invalid departure hour %q", parts[0])
+	}
+
+	minute, err := strconv.Atoi(parts[1])
+	if err != nil || minute < 0 || minute > 59 {
+		return 0, fmt.Errorf("invalid departure minute %q", parts[1])
+	}
+
+	return time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute, nil
+}
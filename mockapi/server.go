@@ -0,0 +1,319 @@
+package mockapi
+
+import (
+	"aliagha/services"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// maxSchedules bounds the schedules of a seed, since a flight id is made of
+// the day of the flight and the index of its schedule.
+const maxSchedules = 1000
+
+// epoch is the day flight ids are counted from.
+var epoch = time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+type Options struct {
+	// Latency is added to every response, plus a random delay up to Jitter.
+	Latency time.Duration
+	Jitter  time.Duration
+	// ErrorRate is the share of requests answered with 503, between 0 and 1.
+	ErrorRate float64
+	Now       func() time.Time
+}
+
+// Server imitates the airline API the application reserves flights through.
+// Flights are generated from the seed schedules for every day from today to
+// Days ahead; reserved seats are only kept in memory.
+type Server struct {
+	seed     *Seed
+	options  Options
+	location *time.Location
+
+	mu       sync.Mutex
+	reserved map[int32]int32
+}
+
+type seatsRequest struct {
+	FlightID int32 `json:"flight_id"`
+	Count    int32 `json:"count"`
+}
+
+type detailRequest struct {
+	FlightID int32 `json:"flight_id" query:"flight_id"`
+}
+
+func NewServer(seed *Seed, options Options) (*Server, error) {
+	location := time.UTC
+	if seed.Timezone != "" {
+		var err error
+		if location, err = time.LoadLocation(seed.Timezone); err != nil {
+			return nil, err
+		}
+	}
+
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+
+	return &Server{
+		seed:     seed,
+		options:  options,
+		location: location,
+		reserved: make(map[int32]int32),
+	}, nil
+}
+
+// Echo returns an echo instance serving the airline API routes.
+func (s *Server) Echo() *echo.Echo {
+	e := echo.New()
+	e.HideBanner = true
+	e.Use(s.delay, s.injectErrors)
+
+	e.GET("/flights", s.flights)
+	e.GET("/flights/detail", s.detail)
+	e.POST("/flights/reserve", s.reserve)
+	e.POST("/flights/cancel", s.cancel)
+	e.GET("/cities", s.cities)
+	e.GET("/airplanes", s.airplanes)
+	e.GET("/canceling-situations", s.cancelingSituations)
+
+	return e
+}
+
+func (s *Server) delay(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		latency := s.options.Latency
+		if s.options.Jitter > 0 {
+			latency += time.Duration(rand.Int63n(int64(s.options.Jitter)))
+		}
+
+		if latency > 0 {
+			select {
+			case <-time.After(latency):
+			case <-c.Request().Context().Done():
+				return c.Request().Context().Err()
+			}
+		}
+
+		return next(c)
+	}
+}
+
+func (s *Server) injectErrors(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if s.options.ErrorRate > 0 && rand.Float64() < s.options.ErrorRate {
+			return c.JSON(http.StatusServiceUnavailable, "Service Unavailable")
+		}
+
+		return next(c)
+	}
+}
+
+func (s *Server) flights(c echo.Context) error {
+	date, err := time.ParseInLocation("2006-01-02", c.QueryParam("date"), s.location)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, "Invalid date")
+	}
+
+	depCity, arrCity := c.QueryParam("departure_city"), c.QueryParam("arrival_city")
+	day := dayNumber(date)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	flights := make([]services.FlightResponse, 0)
+	if !s.bookable(day) {
+		return c.JSON(http.StatusOK, flights)
+	}
+
+	for i, schedule := range s.seed.Schedules {
+		if !strings.EqualFold(s.cityName(schedule.DepCityID), depCity) ||
+			!strings.EqualFold(s.cityName(schedule.ArrCityID), arrCity) {
+			continue
+		}
+
+		flights = append(flights, summary(s.flight(day, i)))
+	}
+
+	return c.JSON(http.StatusOK, flights)
+}
+
+func (s *Server) detail(c echo.Context) error {
+	var req detailRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, "Bad Request")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	flight, ok := s.lookup(req.FlightID)
+	if !ok {
+		return c.JSON(http.StatusNotFound, "Flight not found")
+	}
+
+	return c.JSON(http.StatusOK, flight)
+}
+
+func (s *Server) reserve(c echo.Context) error {
+	var req seatsRequest
+	if err := c.Bind(&req); err != nil || req.Count <= 0 {
+		return c.JSON(http.StatusBadRequest, "Bad Request")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	flight, ok := s.lookup(req.FlightID)
+	if !ok {
+		return c.JSON(http.StatusNotFound, "Flight not found")
+	}
+
+	if flight.RemainingSeats < req.Count {
+		return c.JSON(http.StatusBadRequest, "Not enough seats")
+	}
+
+	s.reserved[req.FlightID] += req.Count
+
+	return c.JSON(http.StatusAccepted, "Reserved")
+}
+
+func (s *Server) cancel(c echo.Context) error {
+	var req seatsRequest
+	if err := c.Bind(&req); err != nil || req.Count <= 0 {
+		return c.JSON(http.StatusBadRequest, "Bad Request")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.lookup(req.FlightID); !ok {
+		return c.JSON(http.StatusNotFound, "Flight not found")
+	}
+
+	if s.reserved[req.FlightID] < req.Count {
+		return c.JSON(http.StatusBadRequest, "Cancelling more seats than reserved")
+	}
+
+	s.reserved[req.FlightID] -= req.Count
+
+	return c.JSON(http.StatusOK, "Cancelled")
+}
+
+func (s *Server) cities(c echo.Context) error {
+	return c.JSON(http.StatusOK, s.seed.Cities)
+}
+
+func (s *Server) airplanes(c echo.Context) error {
+	return c.JSON(http.StatusOK, s.seed.Airplanes)
+}
+
+func (s *Server) cancelingSituations(c echo.Context) error {
+	return c.JSON(http.StatusOK, s.seed.CancelingSituations)
+}
+
+// lookup returns the flight with the given id if it is still bookable. The
+// caller must hold s.mu.
+func (s *Server) lookup(id int32) (services.FlightInfoResponse, bool) {
+	day, index := int(id/maxSchedules), int(id%maxSchedules)-1
+	if id <= 0 || index < 0 || index >= len(s.seed.Schedules) || !s.bookable(day) {
+		return services.FlightInfoResponse{}, false
+	}
+
+	return s.flight(day, index), true
+}
+
+// bookable reports whether flights of the given day are offered: from today
+// in the seed timezone up to Days ahead.
+func (s *Server) bookable(day int) bool {
+	today := dayNumber(s.options.Now().In(s.location))
+	return day >= today && day <= today+s.seed.Days
+}
+
+func (s *Server) flight(day, index int) services.FlightInfoResponse {
+	schedule := s.seed.Schedules[index]
+	clock, _ := parseClock(schedule.Departure)
+
+	date := epoch.AddDate(0, 0, day)
+	depTime := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, s.location).Add(clock)
+	id := int32(day*maxSchedules + index + 1)
+
+	return services.FlightInfoResponse{
+		ID:               id,
+		DepCity:          services.City{ID: schedule.DepCityID, Name: s.cityName(schedule.DepCityID)},
+		ArrCity:          services.City{ID: schedule.ArrCityID, Name: s.cityName(schedule.ArrCityID)},
+		DepAirport:       s.airport(schedule.DepAirport),
+		ArrAirport:       s.airport(schedule.ArrAirport),
+		DepTime:          depTime,
+		ArrTime:          depTime.Add(schedule.Duration),
+		Airplane:         services.Airplane{ID: schedule.AirplaneID, Name: s.airplaneName(schedule.AirplaneID)},
+		Airline:          schedule.Airline,
+		Price:            schedule.Price,
+		CxlSitID:         schedule.CxlSitID,
+		RemainingSeats:   schedule.Seats - s.reserved[id],
+		FlightClass:      schedule.FlightClass,
+		BaggageAllowance: schedule.BaggageAllowance,
+		MealService:      schedule.MealService,
+		Gate:             schedule.Gate,
+	}
+}
+
+func (s *Server) cityName(id int32) string {
+	for _, city := range s.seed.Cities {
+		if city.ID == id {
+			return city.Name
+		}
+	}
+
+	return ""
+}
+
+func (s *Server) airplaneName(id int32) string {
+	for _, airplane := range s.seed.Airplanes {
+		if airplane.ID == id {
+			return airplane.Name
+		}
+	}
+
+	return ""
+}
+
+func (s *Server) airport(code string) *services.Airport {
+	for _, airport := range s.seed.Airports {
+		if strings.EqualFold(airport.IATA, code) {
+			return &services.Airport{IATA: airport.IATA, ICAO: airport.ICAO, Name: airport.Name}
+		}
+	}
+
+	return nil
+}
+
+// summary returns the part of a flight listed in search results.
+func summary(flight services.FlightInfoResponse) services.FlightResponse {
+	return services.FlightResponse{
+		ID:             flight.ID,
+		DepCity:        flight.DepCity,
+		ArrCity:        flight.ArrCity,
+		DepAirport:     flight.DepAirport,
+		ArrAirport:     flight.ArrAirport,
+		DepTime:        flight.DepTime,
+		ArrTime:        flight.ArrTime,
+		Airplane:       flight.Airplane,
+		Airline:        flight.Airline,
+		Price:          flight.Price,
+		CxlSitID:       flight.CxlSitID,
+		RemainingSeats: flight.RemainingSeats,
+	}
+}
+
+// dayNumber counts the days from epoch to the date of t in its own location.
+func dayNumber(t time.Time) int {
+	date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return int(date.Sub(epoch).Hours() / 24)
+}
@@ -0,0 +1,190 @@
+package mockapi
+
+import (
+	"aliagha/services"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/eapache/go-resiliency/breaker"
+	"github.com/stretchr/testify/suite"
+)
+
+const testSeed = `
+timezone: Asia/Tehran
+days: 7
+cities:
+  - {id: 1, name: CityA}
+  - {id: 2, name: CityB}
+airports:
+  - {iata: AAA, icao: OAAA, name: Airport A}
+  - {iata: BBB, name: Airport B}
+airplanes:
+  - {id: 1, name: Airplane1}
+canceling_situations:
+  - {id: 1, description: Economy, data: "24:10,0:50"}
+schedules:
+  - {dep_city_id: 1, arr_city_id: 2, dep_airport: AAA, arr_airport: BBB, departure: "08:30", duration: 1h30m, airline: AirlineX, airplane_id: 1, price: 1000, cxl_sit_id: 1, seats: 3, gate: A1}
+  - {dep_city_id: 2, arr_city_id: 1, departure: "18:00", duration: 1h, airline: AirlineY, airplane_id: 1, price: 900, cxl_sit_id: 1, seats: 10}
+`
+
+type ServerTestSuite struct {
+	suite.Suite
+	now    time.Time
+	server *httptest.Server
+	client *services.APIMockClient
+}
+
+func (suite *ServerTestSuite) SetupTest() {
+	suite.now = time.Date(2023, 6, 28, 10, 0, 0, 0, time.UTC)
+	suite.serve(Options{Now: func() time.Time { return suite.now }})
+}
+
+func (suite *ServerTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *ServerTestSuite) serve(options Options) {
+	require := suite.Require()
+
+	seed, err := ParseSeed([]byte(testSeed))
+	require.NoError(err)
+
+	server, err := NewServer(seed, options)
+	require.NoError(err)
+
+	if suite.server != nil {
+		suite.server.Close()
+	}
+
+	suite.server = httptest.NewServer(server.Echo())
+	suite.client = &services.APIMockClient{
+		Client:  &http.Client{},
+		Breaker: &breaker.Breaker{},
+		BaseURL: suite.server.URL,
+		Timeout: time.Second,
+	}
+}
+
+func (suite *ServerTestSuite) TestGetFlights_Success() {
+	require := suite.Require()
+
+	flights, err := suite.client.GetFlights("citya", "CityB", "2023-06-29")
+	require.NoError(err)
+	require.Len(flights, 1)
+
+	tehran, _ := time.LoadLocation("Asia/Tehran")
+	flight := flights[0]
+	require.Equal(int32(179*1000+1), flight.ID)
+	require.Equal("CityA", flight.DepCity.Name)
+	require.Equal("AirlineX", flight.Airline)
+	require.Equal(int32(3), flight.RemainingSeats)
+	require.True(time.Date(2023, 6, 29, 8, 30, 0, 0, tehran).Equal(flight.DepTime))
+	require.True(flight.DepTime.Add(90 * time.Minute).Equal(flight.ArrTime))
+	require.Equal(&services.Airport{IATA: "AAA", ICAO: "OAAA", Name: "Airport A"}, flight.DepAirport)
+}
+
+func (suite *ServerTestSuite) TestGetFlights_OutsideWindow_Empty() {
+	require := suite.Require()
+
+	flights, err := suite.client.GetFlights("CityA", "CityB", "2023-06-27")
+	require.NoError(err)
+	require.Empty(flights)
+
+	flights, err = suite.client.GetFlights("CityA", "CityB", "2023-07-06")
+	require.NoError(err)
+	require.Empty(flights)
+}
+
+func (suite *ServerTestSuite) TestReserveAndCancel_Success() {
+	require := suite.Require()
+
+	flights, err := suite.client.GetFlights("CityA", "CityB", "2023-06-28")
+	require.NoError(err)
+	require.Len(flights, 1)
+	id := flights[0].ID
+
+	require.NoError(suite.client.Reserve(id, 2))
+
+	info, err := suite.client.GetFlightInfo(id)
+	require.NoError(err)
+	require.Equal(int32(1), info.RemainingSeats)
+	require.Equal("A1", info.Gate)
+
+	require.Error(suite.client.Reserve(id, 2))
+
+	require.NoError(suite.client.Cancel(id, 1))
+	info, err = suite.client.GetFlightInfo(id)
+	require.NoError(err)
+	require.Equal(int32(2), info.RemainingSeats)
+
+	require.Error(suite.client.Cancel(id, 2))
+}
+
+func (suite *ServerTestSuite) TestGetFlightInfo_Unknown_Failure() {
+	require := suite.Require()
+
+	_, err := suite.client.GetFlightInfo(179*1000 + 3)
+	require.Error(err)
+
+	require.Error(suite.client.Reserve(1, 1))
+}
+
+func (suite *ServerTestSuite) TestReference_Success() {
+	require := suite.Require()
+
+	cities, err := suite.client.GetCities()
+	require.NoError(err)
+	require.Equal([]services.GetCityResponse{{ID: 1, Name: "CityA"}, {ID: 2, Name: "CityB"}}, cities)
+
+	airplanes, err := suite.client.GetAirplanes()
+	require.NoError(err)
+	require.Equal([]services.GetAirplaneResponse{{ID: 1, Name: "Airplane1"}}, airplanes)
+
+	situations, err := suite.client.GetCancelingSituations()
+	require.NoError(err)
+	require.Equal([]services.GetCancelingSituationResponse{{ID: 1, Description: "Economy", Data: "24:10,0:50"}}, situations)
+}
+
+func (suite *ServerTestSuite) TestErrorRate_Failure() {
+	require := suite.Require()
+
+	suite.serve(Options{ErrorRate: 1, Now: func() time.Time { return suite.now }})
+
+	_, err := suite.client.GetFlights("CityA", "CityB", "2023-06-28")
+	require.ErrorContains(err, "status: 503")
+}
+
+func (suite *ServerTestSuite) TestLatency_Timeout() {
+	require := suite.Require()
+
+	suite.serve(Options{Latency: 200 * time.Millisecond, Now: func() time.Time { return suite.now }})
+	suite.client.Timeout = 50 * time.Millisecond
+
+	_, err := suite.client.GetCities()
+	require.Error(err)
+}
+
+func TestServer(t *testing.T) {
+	suite.Run(t, new(ServerTestSuite))
+}
+
+func TestParseSeed_Invalid(t *testing.T) {
+	seeds := map[string]string{
+		"unknown city":     `{cities: [{id: 1, name: A}], airplanes: [{id: 1, name: P}], canceling_situations: [{id: 1}], schedules: [{dep_city_id: 1, arr_city_id: 2, departure: "08:00", duration: 1h, airplane_id: 1, cxl_sit_id: 1, seats: 1}]}`,
+		"invalid time":     `{cities: [{id: 1, name: A}], airplanes: [{id: 1, name: P}], canceling_situations: [{id: 1}], schedules: [{dep_city_id: 1, arr_city_id: 1, departure: "25:00", duration: 1h, airplane_id: 1, cxl_sit_id: 1, seats: 1}]}`,
+		"unknown airport":  `{cities: [{id: 1, name: A}], airplanes: [{id: 1, name: P}], canceling_situations: [{id: 1}], schedules: [{dep_city_id: 1, arr_city_id: 1, dep_airport: XXX, departure: "08:00", duration: 1h, airplane_id: 1, cxl_sit_id: 1, seats: 1}]}`,
+		"missing duration": `{cities: [{id: 1, name: A}], airplanes: [{id: 1, name: P}], canceling_situations: [{id: 1}], schedules: [{dep_city_id: 1, arr_city_id: 1, departure: "08:00", airplane_id: 1, cxl_sit_id: 1, seats: 1}]}`,
+	}
+
+	for name, seed := range seeds {
+		if _, err := ParseSeed([]byte(seed)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	if _, err := DefaultSeed(); err != nil {
+		t.Errorf("bundled seed: %v", err)
+	}
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+type Airport struct {
+	ID        int32     `gorm:"column:id;primaryKey;autoIncrement:true" json:"id"`
+	CityID    int32     `gorm:"column:city_id;not null" json:"city_id"`
+	City      City      `gorm:"foreignKey:CityID"`
+	IATA      string    `gorm:"column:iata;not null;unique" json:"iata"`
+	ICAO      *string   `gorm:"column:icao;unique" json:"icao"`
+	Name      string    `gorm:"column:name;not null" json:"name"`
+	Latitude  float64   `gorm:"column:latitude;not null" json:"latitude"`
+	Longitude float64   `gorm:"column:longitude;not null" json:"longitude"`
+	CreatedAt time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+type Alert struct {
+	ID             int32      `gorm:"column:id;primaryKey;autoIncrement:true" json:"id"`
+	UID            int32      `gorm:"column:u_id;not null" json:"u_id"`
+	User           User       `gorm:"foreignKey:UID"`
+	DepCity        string     `gorm:"column:dep_city;not null" json:"dep_city"`
+	ArrCity        string     `gorm:"column:arr_city;not null" json:"arr_city"`
+	FlightDate     time.Time  `gorm:"column:flight_date;not null" json:"flight_date"`
+	MaxPrice       *int32     `gorm:"column:max_price;null" json:"max_price"`
+	LastPrice      *int32     `gorm:"column:last_price;null" json:"last_price"`
+	LastSeats      *int32     `gorm:"column:last_seats;null" json:"last_seats"`
+	Active         bool       `gorm:"column:active;not null" json:"active"`
+	LastNotifiedAt *time.Time `gorm:"column:last_notified_at;null" json:"last_notified_at"`
+	CreatedAt      time.Time  `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"column:updated_at;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+type APIKey struct {
+	ID         int32      `gorm:"column:id;primaryKey;autoIncrement:true" json:"id"`
+	UID        int32      `gorm:"column:u_id;not null" json:"u_id"`
+	Name       string     `gorm:"column:name;not null" json:"name"`
+	Prefix     string     `gorm:"column:prefix;not null" json:"prefix"`
+	KeyHash    string     `gorm:"column:key_hash;not null;uniqueIndex" json:"-"`
+	Scopes     string     `gorm:"column:scopes;not null" json:"scopes"`
+	RateLimit  int32      `gorm:"column:rate_limit;not null" json:"rate_limit"`
+	ExpiresAt  *time.Time `gorm:"column:expires_at" json:"expires_at"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at" json:"last_used_at"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at" json:"revoked_at"`
+	CreatedAt  time.Time  `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
@@ -5,6 +5,7 @@ import "time"
 type City struct {
 	ID        int32     `gorm:"column:id;primaryKey;autoIncrement:true" json:"id"`
 	Name      string    `gorm:"column:name;not null" json:"name"`
+	Timezone  string    `gorm:"column:timezone;not null;default:Asia/Tehran" json:"timezone"`
 	CreatedAt time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"created_at"`
 	UpdatedAt time.Time `gorm:"column:updated_at;default:CURRENT_TIMESTAMP" json:"updated_at"`
 }
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+type FareSnapshot struct {
+	ID             int64     `gorm:"column:id;primaryKey;autoIncrement:true" json:"id"`
+	Provider       string    `gorm:"column:provider;not null" json:"provider"`
+	FlightID       int32     `gorm:"column:flight_id;not null" json:"flight_id"`
+	DepCity        string    `gorm:"column:dep_city;not null" json:"dep_city"`
+	ArrCity        string    `gorm:"column:arr_city;not null" json:"arr_city"`
+	FlightDate     time.Time `gorm:"column:flight_date;not null" json:"flight_date"`
+	Airline        string    `gorm:"column:airline;not null" json:"airline"`
+	Price          int32     `gorm:"column:price;not null" json:"price"`
+	RemainingSeats int32     `gorm:"column:remaining_seats;not null" json:"remaining_seats"`
+	CapturedAt     time.Time `gorm:"column:captured_at;not null" json:"captured_at"`
+}
@@ -3,11 +3,18 @@ package models
 import "time"
 
 type Flight struct {
-	ID               int32              `gorm:"column:id;primaryKey;autoIncrement:true" json:"id"`
+	// Provider and ID key a flight, as flight ids are only unique within a
+	// provider.
+	Provider         string             `gorm:"column:provider;primaryKey" json:"provider"`
+	ID               int32              `gorm:"column:id;primaryKey;autoIncrement:false" json:"id"`
 	DepCityID        int32              `gorm:"column:dep_city_id;not null" json:"dep_city_id"`
 	DepCity          City               `gorm:"foreignKey:DepCityID"`
 	ArrCityID        int32              `gorm:"column:arr_city_id;not null" json:"arr_city_id"`
 	ArrCity          City               `gorm:"foreignKey:ArrCityID"`
+	DepAirportID     *int32             `gorm:"column:dep_airport_id" json:"dep_airport_id"`
+	DepAirport       *Airport           `gorm:"foreignKey:DepAirportID"`
+	ArrAirportID     *int32             `gorm:"column:arr_airport_id" json:"arr_airport_id"`
+	ArrAirport       *Airport           `gorm:"foreignKey:ArrAirportID"`
 	DepTime          time.Time          `gorm:"column:dep_time;not null" json:"dep_time"`
 	ArrTime          time.Time          `gorm:"column:arr_time;not null" json:"arr_time"`
 	AirplaneID       int32              `gorm:"column:airplane_id;not null" json:"airplane_id"`
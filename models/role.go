@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+type Role struct {
+	ID          int32     `gorm:"column:id;primaryKey;autoIncrement:true" json:"id"`
+	Name        string    `gorm:"column:name;not null;uniqueIndex" json:"name"`
+	Description string    `gorm:"column:description;not null" json:"description"`
+	CreatedAt   time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+type RolePermission struct {
+	RoleID     int32  `gorm:"column:role_id;primaryKey" json:"role_id"`
+	Permission string `gorm:"column:permission;primaryKey" json:"permission"`
+}
+
+type UserRole struct {
+	UID       int32     `gorm:"column:u_id;primaryKey" json:"u_id"`
+	RoleID    int32     `gorm:"column:role_id;primaryKey" json:"role_id"`
+	CreatedAt time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+type Session struct {
+	ID         int32      `gorm:"column:id;primaryKey;autoIncrement:true" json:"id"`
+	UID        int32      `gorm:"column:u_id;not null" json:"u_id"`
+	Family     string     `gorm:"column:family;not null;uniqueIndex" json:"-"`
+	Device     string     `gorm:"column:device;not null" json:"device"`
+	IP         string     `gorm:"column:ip;not null" json:"ip"`
+	UserAgent  string     `gorm:"column:user_agent;not null" json:"user_agent"`
+	CreatedAt  time.Time  `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"created_at"`
+	LastSeenAt time.Time  `gorm:"column:last_seen_at;default:CURRENT_TIMESTAMP" json:"last_seen_at"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at" json:"revoked_at"`
+}
@@ -8,7 +8,8 @@ type Ticket struct {
 	User      User      `gorm:"foreignKey:UID"`
 	PIDs      string    `gorm:"column:p_ids;not null" json:"p_ids"`
 	FID       int32     `gorm:"column:f_id;not null" json:"f_id"`
-	Flight    Flight    `gorm:"foreignKey:FID"`
+	Flight    Flight    `gorm:"foreignKey:Provider,FID;references:Provider,ID"`
+	Provider  string    `gorm:"column:provider;not null" json:"provider"`
 	Status    string    `gorm:"column:status;not null" json:"status"`
 	Price     int32     `gorm:"column:price;not null" json:"price"`
 	CreatedAt time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"created_at"`
@@ -3,11 +3,18 @@ package models
 import "time"
 
 type User struct {
-	ID        int32     `gorm:"column:id;primaryKey;autoIncrement:true" json:"id"`
-	Name      string    `gorm:"column:name;not null" json:"name"`
-	Password  string    `gorm:"column:password;not null" json:"password"`
-	Cellphone string    `gorm:"column:cellphone;not null" json:"cellphone"`
-	Email     string    `gorm:"column:email;not null;uniqueIndex" json:"email"`
-	CreatedAt time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"created_at"`
-	UpdatedAt time.Time `gorm:"column:updated_at;default:CURRENT_TIMESTAMP" json:"updated_at"`
+	ID                  int32      `gorm:"column:id;primaryKey;autoIncrement:true" json:"id"`
+	Name                string     `gorm:"column:name;not null" json:"name"`
+	Password            string     `gorm:"column:password;not null" json:"password"`
+	Cellphone           string     `gorm:"column:cellphone;not null" json:"cellphone"`
+	Email               string     `gorm:"column:email;uniqueIndex" json:"email"`
+	EmailVerifiedAt     *time.Time `gorm:"column:email_verified_at" json:"email_verified_at"`
+	CellphoneVerifiedAt *time.Time `gorm:"column:cellphone_verified_at" json:"cellphone_verified_at"`
+	CreatedAt           time.Time  `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt           time.Time  `gorm:"column:updated_at;default:CURRENT_TIMESTAMP" json:"updated_at"`
+	DeletedAt           *time.Time `gorm:"column:deleted_at" json:"deleted_at"`
+	// VerifiedAt is when a contact was last verified; unlike the columns of
+	// each contact it stays set when the contact changes.
+	VerifiedAt *time.Time `gorm:"column:verified_at" json:"verified_at"`
 }
+
@@ -1,17 +1,20 @@
 #!/bin/bash
 
 # Set the path to the Go binary
-GO_BIN="/usr/local/go/bin/go"
+GO_BIN="${GO_BIN:-go}"
 
 # Set the path to the project directory
 PROJECT_DIR="$PWD"
 
 cleanup() {
+    if [ -n "$MOCKAPI_PID" ]; then
+        kill "$MOCKAPI_PID" 2>/dev/null
+    fi
     rm -f "$PROJECT_DIR/mycommand"
 }
 
-# Set up trap to call cleanup function on INT (Ctrl+C)
-trap cleanup INT
+# Set up trap to call cleanup function on INT (Ctrl+C) and on exit
+trap cleanup INT EXIT
 
 # Build the project
 cd "$PROJECT_DIR" || exit
@@ -23,6 +26,9 @@ cd "$PROJECT_DIR" || exit
 # Run the migrate command
 ./mycommand migrate -c config -a up -f migrations
 
+# Start the mock airline API the service searches and reserves flights through
+./mycommand mockapi &
+MOCKAPI_PID=$!
+
 # Run the serve command
 ./mycommand serve -c config
-
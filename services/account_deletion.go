@@ -0,0 +1,86 @@
+package services
+
+import (
+	"aliagha/models"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DeletedUserName replaces the name of deleted accounts.
+const DeletedUserName = "Deleted user"
+
+// DeletedPassengerName replaces the name of the passengers of deleted accounts
+// that are kept for their tickets.
+const DeletedPassengerName = "Deleted passenger"
+
+// deletedPassengerBirthdate replaces the birthdate of those passengers.
+var deletedPassengerBirthdate = time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// AnonymizeUser deletes the account of the user by clearing its personal data.
+// The row is kept, as tickets and payments reference it and are needed for
+// accounting; so are the passengers named on tickets, with their personal data
+// cleared too. Other passengers, alerts, roles and sessions are deleted and API
+// keys are revoked.
+func AnonymizeUser(db *gorm.DB, userID int32, now time.Time) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var ticketPassengers []string
+		if err := tx.Model(&models.Ticket{}).Where("u_id = ?", userID).Pluck("p_ids", &ticketPassengers).Error; err != nil {
+			return err
+		}
+
+		var keep []string
+		for _, ids := range ticketPassengers {
+			keep = append(keep, strings.Split(ids, ",")...)
+		}
+
+		passengers := tx.Where("u_id = ?", userID)
+		if len(keep) > 0 {
+			passengers = passengers.Where("id NOT IN ?", keep)
+		}
+
+		if err := passengers.Delete(&models.Passenger{}).Error; err != nil {
+			return err
+		}
+
+		// National codes are unique per user, so each passenger gets its id.
+		err := tx.Model(&models.Passenger{}).Where("u_id = ?", userID).Updates(map[string]interface{}{
+			"name":          DeletedPassengerName,
+			"national_code": gorm.Expr("id"),
+			"birthdate":     deletedPassengerBirthdate,
+			"updated_at":    now,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("u_id = ?", userID).Delete(&models.Alert{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("u_id = ?", userID).Delete(&models.UserRole{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("u_id = ?", userID).Delete(&models.Session{}).Error; err != nil {
+			return err
+		}
+
+		err = tx.Model(&models.APIKey{}).Where("u_id = ? AND revoked_at IS NULL", userID).Update("revoked_at", now).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"name":                  DeletedUserName,
+			"password":              "",
+			"cellphone":             "",
+			"email":                 nil,
+			"email_verified_at":     nil,
+			"cellphone_verified_at": nil,
+			"deleted_at":            now,
+			"updated_at":            now,
+		}).Error
+	})
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"aliagha/database"
+	"aliagha/models"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnonymizeUser(t *testing.T) {
+	now := time.Date(2023, 6, 28, 10, 0, 0, 0, time.UTC)
+	db := database.NewSQLiteMock()
+
+	_, err := SeedFixtures(db, FixtureOptions{Seed: 1, Scale: FixtureScales["small"], Now: now})
+	require.NoError(t, err)
+
+	var ticket models.Ticket
+	require.NoError(t, db.First(&ticket).Error)
+	userID := ticket.UID
+
+	unbooked := models.Passenger{UID: userID, NationalCode: "0013542419", Name: "Unbooked", Birthdate: now.AddDate(-30, 0, 0)}
+	require.NoError(t, db.Omit("User").Create(&unbooked).Error)
+	require.NoError(t, db.Omit("User").Create(&models.Alert{UID: userID, DepCity: "A", ArrCity: "B", FlightDate: now, Active: true}).Error)
+	require.NoError(t, GrantRole(db, userID, "agent"))
+
+	var tickets int64
+	require.NoError(t, db.Model(&models.Ticket{}).Where("u_id = ?", userID).Count(&tickets).Error)
+
+	require.NoError(t, AnonymizeUser(db, userID, now))
+
+	var user models.User
+	require.NoError(t, db.First(&user, userID).Error)
+	require.Equal(t, DeletedUserName, user.Name)
+	require.Empty(t, user.Email)
+	require.Empty(t, user.Cellphone)
+	require.Empty(t, user.Password)
+	require.Nil(t, user.EmailVerifiedAt)
+	require.Nil(t, user.CellphoneVerifiedAt)
+	require.NotNil(t, user.DeletedAt)
+
+	var count int64
+	require.NoError(t, db.Model(&models.Ticket{}).Where("u_id = ?", userID).Count(&count).Error)
+	require.Equal(t, tickets, count)
+
+	require.NoError(t, db.Model(&models.Alert{}).Where("u_id = ?", userID).Count(&count).Error)
+	require.Zero(t, count)
+
+	roles, _, err := UserRoles(db, userID)
+	require.NoError(t, err)
+	require.Empty(t, roles)
+
+	// Passengers named on tickets are kept without their personal data, the
+	// others are deleted.
+	var booked []models.Ticket
+	require.NoError(t, db.Where("u_id = ?", userID).Find(&booked).Error)
+	for _, ticket := range booked {
+		for _, id := range strings.Split(ticket.PIDs, ",") {
+			var passenger models.Passenger
+			require.NoError(t, db.First(&passenger, id).Error)
+			require.Equal(t, DeletedPassengerName, passenger.Name)
+			require.Equal(t, strconv.Itoa(int(passenger.ID)), passenger.NationalCode)
+			require.Equal(t, 1900, passenger.Birthdate.Year())
+		}
+	}
+
+	require.NoError(t, db.Model(&models.Passenger{}).Where("id = ?", unbooked.ID).Count(&count).Error)
+	require.Zero(t, count)
+
+	var violations []map[string]interface{}
+	require.NoError(t, db.Raw("PRAGMA foreign_key_check").Scan(&violations).Error)
+	require.Empty(t, violations)
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+type NamedProvider struct {
+	Name     string
+	Provider FlightProvider
+	Timeout  time.Duration
+}
+
+var (
+	ErrProviderRequired = errors.New("aggregator: flight ids are only unique within a provider, name the provider")
+	ErrUnknownProvider  = errors.New("aggregator: unknown provider")
+)
+
+// Aggregator queries several providers at once and merges their flights,
+// tagging each with the name of its provider. Flight ids are only unique
+// within a provider, so detail, reserve and cancel calls go through Provider
+// with the name a search returned; called directly they only work when there
+// is a single provider.
+type Aggregator struct {
+	Providers []NamedProvider
+}
+
+type flightsResult struct {
+	flights []FlightResponse
+	err     error
+}
+
+func NewAggregator(providers ...NamedProvider) *Aggregator {
+	return &Aggregator{Providers: providers}
+}
+
+// GetFlights returns the flights of every provider that answered in time.
+// Identical flights offered by more than one provider are merged, keeping the
+// cheapest offer. It only fails when no provider answered.
+func (a *Aggregator) GetFlights(depCity, arrCity, date string) ([]FlightResponse, error) {
+	results := make([]flightsResult, len(a.Providers))
+
+	var wg sync.WaitGroup
+	for i := range a.Providers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = a.fetch(a.Providers[i], depCity, arrCity, date)
+		}(i)
+	}
+	wg.Wait()
+
+	flights := make([]FlightResponse, 0)
+	seen := make(map[string]int)
+	failed := 0
+	for i, result := range results {
+		if result.err != nil {
+			log.Printf("aggregator: provider %s failed, error: %v", a.Providers[i].Name, result.err)
+			failed++
+			continue
+		}
+
+		for _, flight := range result.flights {
+			flight.Provider = a.Providers[i].Name
+			key := flightKey(flight)
+			if idx, ok := seen[key]; ok {
+				if flight.Price < flights[idx].Price {
+					flights[idx] = flight
+				}
+				continue
+			}
+
+			seen[key] = len(flights)
+			flights = append(flights, flight)
+		}
+	}
+
+	if len(a.Providers) > 0 && failed == len(a.Providers) {
+		return nil, errors.New("aggregator: all providers failed")
+	}
+
+	return flights, nil
+}
+
+func (a *Aggregator) fetch(provider NamedProvider, depCity, arrCity, date string) flightsResult {
+	done := make(chan flightsResult, 1)
+	go func() {
+		flights, err := provider.Provider.GetFlights(depCity, arrCity, date)
+		done <- flightsResult{flights: flights, err: err}
+	}()
+
+	if provider.Timeout <= 0 {
+		return <-done
+	}
+
+	timer := time.NewTimer(provider.Timeout)
+	defer timer.Stop()
+
+	select {
+	case result := <-done:
+		return result
+	case <-timer.C:
+		return flightsResult{err: fmt.Errorf("timed out after %s", provider.Timeout)}
+	}
+}
+
+// Provider returns the provider with the name, which tags the flights it
+// returns with it.
+func (a *Aggregator) Provider(name string) (FlightProvider, error) {
+	for _, provider := range a.Providers {
+		if provider.Name == name {
+			return taggedProvider{provider}, nil
+		}
+	}
+
+	return nil, ErrUnknownProvider
+}
+
+// Name returns the name of the provider with the name, or of the only provider
+// when no name is given.
+func (a *Aggregator) Name(name string) (string, error) {
+	if name == "" {
+		if len(a.Providers) != 1 {
+			return "", ErrProviderRequired
+		}
+
+		return a.Providers[0].Name, nil
+	}
+
+	for _, provider := range a.Providers {
+		if provider.Name == name {
+			return name, nil
+		}
+	}
+
+	return "", ErrUnknownProvider
+}
+
+func (a *Aggregator) single() (FlightProvider, error) {
+	if len(a.Providers) != 1 {
+		return nil, ErrProviderRequired
+	}
+
+	return taggedProvider{a.Providers[0]}, nil
+}
+
+func (a *Aggregator) GetFlightInfo(flightId int32) (FlightInfoResponse, error) {
+	provider, err := a.single()
+	if err != nil {
+		return FlightInfoResponse{}, err
+	}
+
+	return provider.GetFlightInfo(flightId)
+}
+
+func (a *Aggregator) Reserve(flightId, cnt int32) error {
+	provider, err := a.single()
+	if err != nil {
+		return err
+	}
+
+	return provider.Reserve(flightId, cnt)
+}
+
+func (a *Aggregator) Cancel(flightId, cnt int32) error {
+	provider, err := a.single()
+	if err != nil {
+		return err
+	}
+
+	return provider.Cancel(flightId, cnt)
+}
+
+// taggedProvider sets the provider name on the flights it returns.
+type taggedProvider struct {
+	NamedProvider
+}
+
+func (p taggedProvider) GetFlights(depCity, arrCity, date string) ([]FlightResponse, error) {
+	flights, err := p.Provider.GetFlights(depCity, arrCity, date)
+	for i := range flights {
+		flights[i].Provider = p.Name
+	}
+
+	return flights, err
+}
+
+func (p taggedProvider) GetFlightInfo(flightId int32) (FlightInfoResponse, error) {
+	flight, err := p.Provider.GetFlightInfo(flightId)
+	if err != nil {
+		return FlightInfoResponse{}, err
+	}
+
+	flight.Provider = p.Name
+	return flight, nil
+}
+
+func (p taggedProvider) Reserve(flightId, cnt int32) error {
+	return p.Provider.Reserve(flightId, cnt)
+}
+
+func (p taggedProvider) Cancel(flightId, cnt int32) error {
+	return p.Provider.Cancel(flightId, cnt)
+}
+
+// flightKey identifies the same flight across providers: the same airline
+// flying the same route at the same times.
+func flightKey(flight FlightResponse) string {
+	return fmt.Sprintf("%s|%s|%s|%d|%d",
+		flight.Airline, flight.DepCity.Name, flight.ArrCity.Name, flight.DepTime.Unix(), flight.ArrTime.Unix())
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type fakeProvider struct {
+	flights  []FlightResponse
+	err      error
+	delay    time.Duration
+	reserved map[int32]int32
+}
+
+func (p *fakeProvider) GetFlights(_, _, _ string) ([]FlightResponse, error) {
+	time.Sleep(p.delay)
+	return p.flights, p.err
+}
+
+func (p *fakeProvider) GetFlightInfo(flightId int32) (FlightInfoResponse, error) {
+	for _, flight := range p.flights {
+		if flight.ID == flightId {
+			return FlightInfoResponse{ID: flight.ID, Airline: flight.Airline, Price: flight.Price}, nil
+		}
+	}
+
+	return FlightInfoResponse{}, errors.New("not found")
+}
+
+func (p *fakeProvider) Reserve(flightId, cnt int32) error {
+	if p.reserved == nil {
+		p.reserved = make(map[int32]int32)
+	}
+
+	p.reserved[flightId] += cnt
+	return nil
+}
+
+func (p *fakeProvider) Cancel(flightId, cnt int32) error {
+	p.reserved[flightId] -= cnt
+	return nil
+}
+
+type AggregatorTestSuite struct {
+	suite.Suite
+	depTime time.Time
+}
+
+func (suite *AggregatorTestSuite) SetupSuite() {
+	suite.depTime = time.Date(2023, 6, 28, 10, 0, 0, 0, time.UTC)
+}
+
+func (suite *AggregatorTestSuite) flight(id, price int32, airline string) FlightResponse {
+	return FlightResponse{
+		ID: id, DepCity: City{ID: 1, Name: "CityA"}, ArrCity: City{ID: 2, Name: "CityB"},
+		DepTime: suite.depTime, ArrTime: suite.depTime.Add(3 * time.Hour), Airline: airline, Price: price,
+	}
+}
+
+func (suite *AggregatorTestSuite) TestGetFlights_Deduplicate_Success() {
+	require := suite.Require()
+
+	first := &fakeProvider{flights: []FlightResponse{suite.flight(1, 300, "AirlineX"), suite.flight(2, 250, "AirlineY")}}
+	second := &fakeProvider{flights: []FlightResponse{suite.flight(101, 280, "AirlineX")}}
+	aggregator := NewAggregator(
+		NamedProvider{Name: "first", Provider: first},
+		NamedProvider{Name: "second", Provider: second},
+	)
+
+	flights, err := aggregator.GetFlights("CityA", "CityB", "2023-06-28")
+	require.NoError(err)
+	require.Len(flights, 2)
+	require.Equal(int32(101), flights[0].ID)
+	require.Equal(int32(280), flights[0].Price)
+	require.Equal("second", flights[0].Provider)
+	require.Equal(int32(2), flights[1].ID)
+	require.Equal("first", flights[1].Provider)
+}
+
+func (suite *AggregatorTestSuite) TestGetFlights_PartialFailure_Success() {
+	require := suite.Require()
+
+	aggregator := NewAggregator(
+		NamedProvider{Name: "broken", Provider: &fakeProvider{err: errors.New("error")}},
+		NamedProvider{Name: "slow", Provider: &fakeProvider{flights: []FlightResponse{suite.flight(5, 100, "AirlineZ")}, delay: time.Second}, Timeout: 10 * time.Millisecond},
+		NamedProvider{Name: "ok", Provider: &fakeProvider{flights: []FlightResponse{suite.flight(1, 200, "AirlineX")}}},
+	)
+
+	flights, err := aggregator.GetFlights("CityA", "CityB", "2023-06-28")
+	require.NoError(err)
+	require.Len(flights, 1)
+	require.Equal("ok", flights[0].Provider)
+}
+
+func (suite *AggregatorTestSuite) TestGetFlights_AllFailed_Failure() {
+	require := suite.Require()
+
+	aggregator := NewAggregator(
+		NamedProvider{Name: "broken", Provider: &fakeProvider{err: errors.New("error")}},
+		NamedProvider{Name: "slow", Provider: &fakeProvider{delay: time.Second}, Timeout: 10 * time.Millisecond},
+	)
+
+	_, err := aggregator.GetFlights("CityA", "CityB", "2023-06-28")
+	require.Error(err)
+}
+
+func (suite *AggregatorTestSuite) TestReserve_SameIDs_RoutesByProvider_Success() {
+	require := suite.Require()
+
+	// Both providers number their flights on their own and return flight 7.
+	first := &fakeProvider{flights: []FlightResponse{suite.flight(7, 300, "AirlineX")}}
+	second := &fakeProvider{flights: []FlightResponse{suite.flight(7, 200, "AirlineY")}}
+	aggregator := NewAggregator(
+		NamedProvider{Name: "first", Provider: first},
+		NamedProvider{Name: "second", Provider: second},
+	)
+
+	flights, err := aggregator.GetFlights("CityA", "CityB", "2023-06-28")
+	require.NoError(err)
+	require.Len(flights, 2)
+
+	for _, flight := range flights {
+		provider, err := ProviderFor(aggregator, flight.Provider)
+		require.NoError(err)
+
+		info, err := provider.GetFlightInfo(flight.ID)
+		require.NoError(err)
+		require.Equal(flight.Airline, info.Airline)
+		require.Equal(flight.Provider, info.Provider)
+	}
+
+	provider, err := ProviderFor(aggregator, "first")
+	require.NoError(err)
+	require.NoError(provider.Reserve(7, 2))
+	require.Equal(int32(2), first.reserved[7])
+	require.Nil(second.reserved)
+
+	require.NoError(provider.Cancel(7, 1))
+	require.Equal(int32(1), first.reserved[7])
+	require.Nil(second.reserved)
+
+	// Without a provider the flight is ambiguous.
+	require.ErrorIs(aggregator.Reserve(7, 1), ErrProviderRequired)
+	_, err = aggregator.GetFlightInfo(7)
+	require.ErrorIs(err, ErrProviderRequired)
+
+	_, err = ProviderFor(aggregator, "unknown")
+	require.ErrorIs(err, ErrUnknownProvider)
+}
+
+func (suite *AggregatorTestSuite) TestReserve_SingleProvider_Success() {
+	require := suite.Require()
+
+	only := &fakeProvider{flights: []FlightResponse{suite.flight(7, 200, "AirlineY")}}
+	aggregator := NewAggregator(NamedProvider{Name: "only", Provider: only})
+
+	require.NoError(aggregator.Reserve(7, 1))
+	require.Equal(int32(1), only.reserved[7])
+
+	info, err := aggregator.GetFlightInfo(7)
+	require.NoError(err)
+	require.Equal("only", info.Provider)
+
+	_, err = aggregator.GetFlightInfo(99)
+	require.Error(err)
+}
+
+func TestAggregator(t *testing.T) {
+	suite.Run(t, new(AggregatorTestSuite))
+}
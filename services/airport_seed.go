@@ -0,0 +1,143 @@
+package services
+
+import (
+	"aliagha/models"
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// airportsDataset lists the commercial airports of Iran. It is derived from
+// the public domain OurAirports dataset (https://ourairports.com/data/).
+//
+//go:embed data/airports.csv
+var airportsDataset []byte
+
+var airportColumns = []string{"iata", "icao", "name", "city", "latitude", "longitude"}
+
+type AirportSeedResult struct {
+	Airports      int
+	CitiesCreated int
+}
+
+// BundledAirports returns the airports dataset shipped with the binary.
+func BundledAirports() io.Reader {
+	return bytes.NewReader(airportsDataset)
+}
+
+// SeedAirports imports airports from a CSV with the columns iata, icao, name,
+// city, latitude and longitude. Existing airports are updated by IATA code and
+// missing cities are created.
+func SeedAirports(db *gorm.DB, r io.Reader) (AirportSeedResult, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return AirportSeedResult{}, fmt.Errorf("failed to read airports header: %s", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, name := range airportColumns {
+		if _, ok := columns[name]; !ok {
+			return AirportSeedResult{}, fmt.Errorf("airports dataset has no %s column", name)
+		}
+	}
+
+	var result AirportSeedResult
+	err = db.Transaction(func(tx *gorm.DB) error {
+		cities := make(map[string]int32)
+		for line := 2; ; line++ {
+			record, err := reader.Read()
+			if err == io.EOF {
+				return nil
+			}
+
+			if err != nil {
+				return fmt.Errorf("line %d: %s", line, err)
+			}
+
+			airport, cityName, err := parseAirportRecord(record, columns)
+			if err != nil {
+				return fmt.Errorf("line %d: %s", line, err)
+			}
+
+			cityID, ok := cities[cityName]
+			if !ok {
+				city := models.City{Name: cityName}
+				res := tx.Model(&models.City{}).Where("name = ?", cityName).FirstOrCreate(&city)
+				if res.Error != nil {
+					return res.Error
+				}
+
+				if res.RowsAffected > 0 {
+					result.CitiesCreated++
+				}
+
+				cityID = city.ID
+				cities[cityName] = cityID
+			}
+
+			airport.CityID = cityID
+			err = tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "iata"}},
+				DoUpdates: clause.AssignmentColumns([]string{"city_id", "icao", "name", "latitude", "longitude"}),
+			}).Omit("City").Create(&airport).Error
+			if err != nil {
+				return err
+			}
+
+			result.Airports++
+		}
+	})
+
+	return result, err
+}
+
+func parseAirportRecord(record []string, columns map[string]int) (models.Airport, string, error) {
+	field := func(name string) string {
+		return strings.TrimSpace(record[columns[name]])
+	}
+
+	iata := strings.ToUpper(field("iata"))
+	if len(iata) != 3 {
+		return models.Airport{}, "", fmt.Errorf("invalid IATA code %q", iata)
+	}
+
+	city := field("city")
+	if city == "" {
+		return models.Airport{}, "", fmt.Errorf("airport %s has no city", iata)
+	}
+
+	latitude, err := strconv.ParseFloat(field("latitude"), 64)
+	if err != nil || latitude < -90 || latitude > 90 {
+		return models.Airport{}, "", fmt.Errorf("invalid latitude %q", field("latitude"))
+	}
+
+	longitude, err := strconv.ParseFloat(field("longitude"), 64)
+	if err != nil || longitude < -180 || longitude > 180 {
+		return models.Airport{}, "", fmt.Errorf("invalid longitude %q", field("longitude"))
+	}
+
+	airport := models.Airport{
+		IATA:      iata,
+		Name:      field("name"),
+		Latitude:  latitude,
+		Longitude: longitude,
+	}
+
+	if icao := strings.ToUpper(field("icao")); icao != "" {
+		airport.ICAO = &icao
+	}
+
+	return airport, city, nil
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBundledAirports(t *testing.T) {
+	records, err := csv.NewReader(BundledAirports()).ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, airportColumns, records[0])
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[name] = i
+	}
+
+	seen := make(map[string]bool)
+	for _, record := range records[1:] {
+		airport, city, err := parseAirportRecord(record, columns)
+		require.NoError(t, err)
+		require.NotEmpty(t, city)
+		require.NotNil(t, airport.ICAO)
+		require.False(t, seen[airport.IATA], airport.IATA)
+		seen[airport.IATA] = true
+	}
+
+	require.True(t, seen["IKA"])
+	require.True(t, seen["THR"])
+}
+
+func TestSeedAirports_InvalidDataset_Failure(t *testing.T) {
+	_, err := SeedAirports(nil, strings.NewReader("iata,name,city\nIKA,Imam Khomeini,Tehran\n"))
+	require.Error(t, err)
+
+	columns := map[string]int{"iata": 0, "icao": 1, "name": 2, "city": 3, "latitude": 4, "longitude": 5}
+	_, _, err = parseAirportRecord([]string{"IKAX", "OIIE", "Imam Khomeini", "Tehran", "35.4", "51.1"}, columns)
+	require.Error(t, err)
+
+	_, _, err = parseAirportRecord([]string{"IKA", "OIIE", "Imam Khomeini", "Tehran", "135.4", "51.1"}, columns)
+	require.Error(t, err)
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"aliagha/models"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AirportDirectory looks airports up by code or by city. Airports are read
+// from the airports table and reloaded every TTL.
+type AirportDirectory struct {
+	DB  *gorm.DB
+	TTL time.Duration
+
+	mu       sync.Mutex
+	byCode   map[string]models.Airport
+	byCity   map[string][]models.Airport
+	loadedAt time.Time
+}
+
+// Lookup returns the airport with the given IATA or ICAO code.
+func (d *AirportDirectory) Lookup(code string) (models.Airport, bool) {
+	if d == nil {
+		return models.Airport{}, false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.refresh()
+
+	airport, ok := d.byCode[strings.ToUpper(code)]
+	return airport, ok
+}
+
+// CityAirports returns the airports serving the named city.
+func (d *AirportDirectory) CityAirports(city string) []models.Airport {
+	if d == nil {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.refresh()
+
+	return d.byCity[strings.ToLower(city)]
+}
+
+// Resolve completes the airport of a flight. Airports given by the provider
+// are filled in from the directory; a missing one is inferred when the city
+// has a single airport.
+func (d *AirportDirectory) Resolve(city string, airport *Airport) *Airport {
+	if airport != nil {
+		if known, ok := d.Lookup(airport.IATA); ok {
+			return newAirport(known)
+		}
+
+		return airport
+	}
+
+	if airports := d.CityAirports(city); len(airports) == 1 {
+		return newAirport(airports[0])
+	}
+
+	return nil
+}
+
+func (d *AirportDirectory) refresh() {
+	if time.Since(d.loadedAt) <= d.TTL {
+		return
+	}
+
+	// A failed load keeps the previous airports until the next attempt.
+	if err := d.load(); err != nil {
+		log.Printf("airports: failed to load airports, error: %v", err)
+	}
+	d.loadedAt = time.Now()
+}
+
+func (d *AirportDirectory) load() error {
+	var airports []models.Airport
+	if err := d.DB.Model(&models.Airport{}).Preload("City").Find(&airports).Error; err != nil {
+		return err
+	}
+
+	byCode := make(map[string]models.Airport, len(airports)*2)
+	byCity := make(map[string][]models.Airport)
+	for _, airport := range airports {
+		byCode[strings.ToUpper(airport.IATA)] = airport
+		if airport.ICAO != nil && *airport.ICAO != "" {
+			byCode[strings.ToUpper(*airport.ICAO)] = airport
+		}
+
+		city := strings.ToLower(airport.City.Name)
+		byCity[city] = append(byCity[city], airport)
+	}
+
+	d.byCode = byCode
+	d.byCity = byCity
+
+	return nil
+}
+
+func newAirport(airport models.Airport) *Airport {
+	resp := &Airport{IATA: airport.IATA, Name: airport.Name}
+	if airport.ICAO != nil {
+		resp.ICAO = *airport.ICAO
+	}
+
+	return resp
+}
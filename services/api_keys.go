@@ -0,0 +1,231 @@
+package services
+
+import (
+	"aliagha/models"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis"
+	"gorm.io/gorm"
+)
+
+// Scopes an API key can be granted; each covers the endpoints of the logged in
+// user it is named after.
+const (
+	ScopeSearch     = "search"
+	ScopePassengers = "passengers"
+	ScopeBookings   = "bookings"
+	ScopeTickets    = "tickets"
+)
+
+var APIKeyScopes = []string{ScopeSearch, ScopePassengers, ScopeBookings, ScopeTickets}
+
+var (
+	ErrInvalidAPIKey     = errors.New("invalid api key")
+	ErrUnknownAPIKey     = errors.New("unknown api key")
+	ErrUnknownScope      = errors.New("unknown scope")
+	ErrAPIKeyRateLimited = errors.New("api key rate limit exceeded")
+)
+
+const (
+	apiKeyPrefix = "ak_"
+	// last_used_at is written at most once per apiKeyLastUsedResolution.
+	apiKeyLastUsedResolution = time.Minute
+)
+
+// APIKeys issues and checks the keys partner agencies call the API with. A
+// key acts for the user owning it, within its scopes. Keys are stored as a
+// SHA-256 hash, which is enough for random keys of this length.
+//
+// Requests are counted per key and day in Redis for UsageRetention, or for
+// good when it is zero, and limited to the rate limit of the key, or
+// DefaultRateLimit, per minute.
+type APIKeys struct {
+	DB    *gorm.DB
+	Redis *redis.Client
+
+	DefaultRateLimit int
+	UsageRetention   time.Duration
+}
+
+type NewAPIKey struct {
+	UserID    int32
+	Name      string
+	Scopes    []string
+	ExpiresAt *time.Time
+	// RateLimit is the number of requests per minute; zero uses the default.
+	RateLimit int32
+}
+
+type APIKeyUsage struct {
+	Date     string
+	Requests int64
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func apiKeyRateKey(id int32, window time.Time) string {
+	return fmt.Sprintf("api-key-rate-%d-%d", id, window.Unix())
+}
+
+func apiKeyUsageKey(id int32, date string) string {
+	return fmt.Sprintf("api-key-usage-%d-%s", id, date)
+}
+
+// APIKeyHasScope reports whether the key was granted scope.
+func APIKeyHasScope(apiKey *models.APIKey, scope string) bool {
+	for _, s := range strings.Split(apiKey.Scopes, ",") {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Create issues a key and returns it; it can not be read back later.
+func (k *APIKeys) Create(opts NewAPIKey) (string, *models.APIKey, error) {
+	scopes := make(map[string]bool)
+	for _, scope := range opts.Scopes {
+		if !contains(APIKeyScopes, scope) {
+			return "", nil, fmt.Errorf("%w %q", ErrUnknownScope, scope)
+		}
+
+		scopes[scope] = true
+	}
+
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, err
+	}
+
+	key := apiKeyPrefix + hex.EncodeToString(b)
+	apiKey := &models.APIKey{
+		UID:       opts.UserID,
+		Name:      opts.Name,
+		Prefix:    key[:len(apiKeyPrefix)+8],
+		KeyHash:   hashAPIKey(key),
+		Scopes:    strings.Join(sortedKeys(scopes), ","),
+		RateLimit: opts.RateLimit,
+		ExpiresAt: opts.ExpiresAt,
+		CreatedAt: time.Now(),
+	}
+
+	if err := k.DB.Create(apiKey).Error; err != nil {
+		return "", nil, err
+	}
+
+	return key, apiKey, nil
+}
+
+// Authenticate returns the key unless it is unknown, revoked, expired or its
+// owner deleted their account, and records when it was last used.
+func (k *APIKeys) Authenticate(key string, now time.Time) (*models.APIKey, error) {
+	var apiKey models.APIKey
+	err := k.DB.Joins("JOIN users ON users.id = api_keys.u_id").
+		Where("api_keys.key_hash = ? AND api_keys.revoked_at IS NULL AND users.deleted_at IS NULL", hashAPIKey(key)).
+		First(&apiKey).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if apiKey.ExpiresAt != nil && !now.Before(*apiKey.ExpiresAt) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= apiKeyLastUsedResolution {
+		err := k.DB.Model(&models.APIKey{}).Where("id = ?", apiKey.ID).Update("last_used_at", now).Error
+		if err != nil {
+			return nil, err
+		}
+
+		apiKey.LastUsedAt = &now
+	}
+
+	return &apiKey, nil
+}
+
+// Allow counts a request of the key. Over the rate limit it returns
+// ErrAPIKeyRateLimited and how long until the next minute starts.
+func (k *APIKeys) Allow(apiKey *models.APIKey, now time.Time) (time.Duration, error) {
+	limit := int64(apiKey.RateLimit)
+	if limit == 0 {
+		limit = int64(k.DefaultRateLimit)
+	}
+
+	if limit > 0 {
+		window := now.Truncate(time.Minute)
+		pipe := k.Redis.TxPipeline()
+		count := pipe.Incr(apiKeyRateKey(apiKey.ID, window))
+		pipe.Expire(apiKeyRateKey(apiKey.ID, window), time.Minute)
+		if _, err := pipe.Exec(); err != nil {
+			return 0, err
+		}
+
+		if count.Val() > limit {
+			return window.Add(time.Minute).Sub(now), ErrAPIKeyRateLimited
+		}
+	}
+
+	usageKey := apiKeyUsageKey(apiKey.ID, now.UTC().Format("2006-01-02"))
+	pipe := k.Redis.TxPipeline()
+	pipe.Incr(usageKey)
+	if k.UsageRetention > 0 {
+		pipe.Expire(usageKey, k.UsageRetention)
+	}
+	_, err := pipe.Exec()
+	return 0, err
+}
+
+// Usage returns the requests of the key for each of the last days up to now,
+// oldest first.
+func (k *APIKeys) Usage(id int32, days int, now time.Time) ([]APIKeyUsage, error) {
+	usage := make([]APIKeyUsage, 0, days)
+	for i := days - 1; i >= 0; i-- {
+		date := now.UTC().AddDate(0, 0, -i).Format("2006-01-02")
+		requests, err := k.Redis.Get(apiKeyUsageKey(id, date)).Int64()
+		if err != nil && err != redis.Nil {
+			return nil, err
+		}
+
+		usage = append(usage, APIKeyUsage{Date: date, Requests: requests})
+	}
+
+	return usage, nil
+}
+
+// Revoke stops the key from being accepted.
+func (k *APIKeys) Revoke(id int32, now time.Time) error {
+	result := k.DB.Model(&models.APIKey{}).Where("id = ? AND revoked_at IS NULL", id).Update("revoked_at", now)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrUnknownAPIKey
+	}
+
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
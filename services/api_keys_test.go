@@ -0,0 +1,148 @@
+package services
+
+import (
+	"aliagha/database"
+	"aliagha/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type APIKeysTestSuite struct {
+	suite.Suite
+	redisServer *miniredis.Miniredis
+	db          *gorm.DB
+	apiKeys     *APIKeys
+	user        models.User
+	now         time.Time
+}
+
+func (suite *APIKeysTestSuite) SetupTest() {
+	server, client := database.NewRedisMock()
+	suite.redisServer = server
+	suite.db = database.NewSQLiteMock()
+	suite.apiKeys = &APIKeys{DB: suite.db, Redis: client, DefaultRateLimit: 3, UsageRetention: 24 * time.Hour}
+	suite.now = time.Date(2023, 6, 28, 10, 0, 30, 0, time.UTC)
+
+	suite.user = models.User{Name: "Partner", Cellphone: "09121234567", Email: "partner@example.com"}
+	suite.Require().NoError(suite.db.Create(&suite.user).Error)
+}
+
+func (suite *APIKeysTestSuite) TearDownTest() {
+	suite.redisServer.Close()
+}
+
+func (suite *APIKeysTestSuite) TestCreate_Success() {
+	require := suite.Require()
+
+	key, apiKey, err := suite.apiKeys.Create(NewAPIKey{
+		UserID: suite.user.ID,
+		Name:   "agency",
+		Scopes: []string{ScopeSearch, ScopeBookings, ScopeSearch},
+	})
+	require.NoError(err)
+	require.True(strings.HasPrefix(key, apiKey.Prefix))
+	require.Equal("bookings,search", apiKey.Scopes)
+
+	var stored models.APIKey
+	require.NoError(suite.db.First(&stored, apiKey.ID).Error)
+	require.NotContains(stored.KeyHash, key)
+	require.Equal(hashAPIKey(key), stored.KeyHash)
+
+	_, _, err = suite.apiKeys.Create(NewAPIKey{UserID: suite.user.ID, Name: "agency", Scopes: []string{"admin"}})
+	require.ErrorIs(err, ErrUnknownScope)
+}
+
+func (suite *APIKeysTestSuite) TestAuthenticate_Success() {
+	require := suite.Require()
+
+	key, created, err := suite.apiKeys.Create(NewAPIKey{UserID: suite.user.ID, Name: "agency", Scopes: []string{ScopeTickets}})
+	require.NoError(err)
+
+	apiKey, err := suite.apiKeys.Authenticate(key, suite.now)
+	require.NoError(err)
+	require.Equal(created.ID, apiKey.ID)
+	require.Equal(suite.user.ID, apiKey.UID)
+	require.True(APIKeyHasScope(apiKey, ScopeTickets))
+	require.False(APIKeyHasScope(apiKey, ScopeBookings))
+
+	var stored models.APIKey
+	require.NoError(suite.db.First(&stored, created.ID).Error)
+	require.NotNil(stored.LastUsedAt)
+	require.True(stored.LastUsedAt.Equal(suite.now))
+
+	_, err = suite.apiKeys.Authenticate(key+"x", suite.now)
+	require.ErrorIs(err, ErrInvalidAPIKey)
+}
+
+func (suite *APIKeysTestSuite) TestAuthenticate_ExpiredRevokedOrDeleted_Failure() {
+	require := suite.Require()
+
+	expiresAt := suite.now.Add(time.Hour)
+	expiring, _, err := suite.apiKeys.Create(NewAPIKey{UserID: suite.user.ID, Name: "expiring", Scopes: []string{ScopeSearch}, ExpiresAt: &expiresAt})
+	require.NoError(err)
+
+	_, err = suite.apiKeys.Authenticate(expiring, suite.now)
+	require.NoError(err)
+	_, err = suite.apiKeys.Authenticate(expiring, expiresAt)
+	require.ErrorIs(err, ErrInvalidAPIKey)
+
+	revoked, apiKey, err := suite.apiKeys.Create(NewAPIKey{UserID: suite.user.ID, Name: "revoked", Scopes: []string{ScopeSearch}})
+	require.NoError(err)
+	require.NoError(suite.apiKeys.Revoke(apiKey.ID, suite.now))
+	require.ErrorIs(suite.apiKeys.Revoke(apiKey.ID, suite.now), ErrUnknownAPIKey)
+
+	_, err = suite.apiKeys.Authenticate(revoked, suite.now)
+	require.ErrorIs(err, ErrInvalidAPIKey)
+
+	key, _, err := suite.apiKeys.Create(NewAPIKey{UserID: suite.user.ID, Name: "owner deleted", Scopes: []string{ScopeSearch}})
+	require.NoError(err)
+	require.NoError(AnonymizeUser(suite.db, suite.user.ID, suite.now))
+
+	_, err = suite.apiKeys.Authenticate(key, suite.now)
+	require.ErrorIs(err, ErrInvalidAPIKey)
+}
+
+func (suite *APIKeysTestSuite) TestAllow_RateLimitAndUsage() {
+	require := suite.Require()
+
+	_, apiKey, err := suite.apiKeys.Create(NewAPIKey{UserID: suite.user.ID, Name: "agency", Scopes: []string{ScopeSearch}})
+	require.NoError(err)
+
+	for i := 0; i < 3; i++ {
+		_, err := suite.apiKeys.Allow(apiKey, suite.now)
+		require.NoError(err)
+	}
+
+	wait, err := suite.apiKeys.Allow(apiKey, suite.now)
+	require.ErrorIs(err, ErrAPIKeyRateLimited)
+	require.Equal(30*time.Second, wait)
+
+	// The limit is per minute.
+	_, err = suite.apiKeys.Allow(apiKey, suite.now.Add(time.Minute))
+	require.NoError(err)
+
+	// A limit of its own overrides the default.
+	apiKey.RateLimit = 10
+	_, err = suite.apiKeys.Allow(apiKey, suite.now)
+	require.NoError(err)
+
+	_, err = suite.apiKeys.Allow(apiKey, suite.now.AddDate(0, 0, 1))
+	require.NoError(err)
+
+	usage, err := suite.apiKeys.Usage(apiKey.ID, 3, suite.now.AddDate(0, 0, 1))
+	require.NoError(err)
+	require.Equal([]APIKeyUsage{
+		{Date: "2023-06-27", Requests: 0},
+		{Date: "2023-06-28", Requests: 5},
+		{Date: "2023-06-29", Requests: 1},
+	}, usage)
+}
+
+func TestAPIKeys(t *testing.T) {
+	suite.Run(t, new(APIKeysTestSuite))
+}
@@ -23,6 +23,8 @@ type FlightResponse struct {
 	ID             int32     `json:"id"`
 	DepCity        City      `json:"dep_city"`
 	ArrCity        City      `json:"arr_city"`
+	DepAirport     *Airport  `json:"dep_airport,omitempty"`
+	ArrAirport     *Airport  `json:"arr_airport,omitempty"`
 	DepTime        time.Time `json:"dep_time"`
 	ArrTime        time.Time `json:"arr_time"`
 	Airplane       Airplane  `json:"airplane"`
@@ -30,6 +32,7 @@ type FlightResponse struct {
 	Price          int32     `json:"price"`
 	CxlSitID       int32     `json:"cxl_sit_id"`
 	RemainingSeats int32     `json:"remaining_seats"`
+	Provider       string    `json:"provider,omitempty"`
 }
 
 type City struct {
@@ -37,6 +40,12 @@ type City struct {
 	Name string `json:"name"`
 }
 
+type Airport struct {
+	IATA string `json:"iata"`
+	ICAO string `json:"icao,omitempty"`
+	Name string `json:"name"`
+}
+
 type Airplane struct {
 	ID   int32  `json:"id"`
 	Name string `json:"name"`
@@ -111,8 +120,11 @@ func (c *APIMockClient) Reserve(flightId, cnt int32) error {
 			return fmt.Errorf("apimock_post_reserve: request failed, error: %v", err.Error())
 		}
 
+		defer response.Body.Close()
+
 		if response.StatusCode != http.StatusAccepted {
-			return fmt.Errorf("apimock_post_reserve: request failed, error: %v", err.Error())
+			responseBody, _ := ioutil.ReadAll(response.Body)
+			return fmt.Errorf("apimock_post_reserve: unhandeled response, status: %d, response: %s", response.StatusCode, responseBody)
 		}
 
 		return nil
@@ -142,8 +154,11 @@ func (c *APIMockClient) Cancel(flightId, cnt int32) error {
 			return fmt.Errorf("apimock_post_cancel: request failed, error: %v", err.Error())
 		}
 
+		defer response.Body.Close()
+
 		if response.StatusCode != http.StatusOK {
-			return fmt.Errorf("apimock_post_cancel: request failed, error: %v", err.Error())
+			responseBody, _ := ioutil.ReadAll(response.Body)
+			return fmt.Errorf("apimock_post_cancel: unhandeled response, status: %d, response: %s", response.StatusCode, responseBody)
 		}
 
 		return nil
@@ -156,6 +171,8 @@ type FlightInfoResponse struct {
 	ID               int32     `json:"id"`
 	DepCity          City      `json:"dep_city"`
 	ArrCity          City      `json:"arr_city"`
+	DepAirport       *Airport  `json:"dep_airport,omitempty"`
+	ArrAirport       *Airport  `json:"arr_airport,omitempty"`
 	DepTime          time.Time `json:"dep_time"`
 	ArrTime          time.Time `json:"arr_time"`
 	Airplane         Airplane  `json:"airplane"`
@@ -167,6 +184,7 @@ type FlightInfoResponse struct {
 	BaggageAllowance string    `json:"baggage_allowance"`
 	MealService      string    `json:"meal_service"`
 	Gate             string    `json:"gate"`
+	Provider         string    `json:"provider,omitempty"`
 }
 
 func (c *APIMockClient) GetFlightInfo(flightId int32) (FlightInfoResponse, error) {
@@ -197,7 +215,7 @@ func (c *APIMockClient) GetFlightInfo(flightId int32) (FlightInfoResponse, error
 		}
 
 		if response.StatusCode != http.StatusOK {
-			return fmt.Errorf("apimock_get_flight_info: request failed, error: %v", err.Error())
+			return fmt.Errorf("apimock_get_flight_info: unhandeled response, status: %d, response: %s", response.StatusCode, responseBody)
 		}
 
 		if err := json.Unmarshal(responseBody, &flight); err != nil {
@@ -245,7 +263,7 @@ func (c *APIMockClient) GetCities() ([]GetCityResponse, error) {
 		}
 
 		if response.StatusCode != http.StatusOK {
-			return fmt.Errorf("apimock_get_cities: request failed, error: %v", err.Error())
+			return fmt.Errorf("apimock_get_cities: unhandeled response, status: %d, response: %s", response.StatusCode, responseBody)
 		}
 
 		if err := json.Unmarshal(responseBody, &resp); err != nil {
@@ -293,7 +311,7 @@ func (c *APIMockClient) GetAirplanes() ([]GetAirplaneResponse, error) {
 		}
 
 		if response.StatusCode != http.StatusOK {
-			return fmt.Errorf("apimock_get_airplanes: request failed, error: %v", err.Error())
+			return fmt.Errorf("apimock_get_airplanes: unhandeled response, status: %d, response: %s", response.StatusCode, responseBody)
 		}
 
 		if err := json.Unmarshal(responseBody, &resp); err != nil {
@@ -309,3 +327,53 @@ func (c *APIMockClient) GetAirplanes() ([]GetAirplaneResponse, error) {
 
 	return resp, nil
 }
+
+type GetCancelingSituationResponse struct {
+	ID          int32  `json:"id"`
+	Description string `json:"description"`
+	Data        string `json:"data"`
+}
+
+func (c *APIMockClient) GetCancelingSituations() ([]GetCancelingSituationResponse, error) {
+	url := c.BaseURL + "/canceling-situations"
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp []GetCancelingSituationResponse
+	err = c.Breaker.Run(func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), c.Timeout)
+		defer cancel()
+
+		req = req.WithContext(ctx)
+
+		response, err := c.Client.Do(req)
+		if err != nil {
+			return fmt.Errorf("apimock_get_canceling_situations: request failed, error: %v", err.Error())
+		}
+		defer response.Body.Close()
+
+		responseBody, err := ioutil.ReadAll(response.Body)
+		if err != nil {
+			return fmt.Errorf("apimock_get_canceling_situations: reading response failed, error: %v", err.Error())
+		}
+
+		if response.StatusCode != http.StatusOK {
+			return fmt.Errorf("apimock_get_canceling_situations: unhandeled response, status: %d, response: %s", response.StatusCode, responseBody)
+		}
+
+		if err := json.Unmarshal(responseBody, &resp); err != nil {
+			return fmt.Errorf("apimock_get_canceling_situations: parsing response body failed, error: %v", err.Error())
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token was already used, its family is revoked")
+)
+
+// TokenStore keeps refresh tokens and revoked access tokens in Redis.
+//
+// Refresh tokens are stored by their SHA-256 hash and rotate on every use.
+// Each login starts a token family; all tokens rotated from it belong to the
+// same family, and the family is revoked when one of its used tokens is
+// presented again, as that means it has leaked.
+type TokenStore struct {
+	Redis      *redis.Client
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+func refreshTokenKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "refresh-token-" + hex.EncodeToString(sum[:])
+}
+
+func refreshFamilyKey(family string) string {
+	return "refresh-family-" + family
+}
+
+func userFamiliesKey(userID int32) string {
+	return fmt.Sprintf("refresh-families-%d", userID)
+}
+
+func revokedTokenKey(jti string) string {
+	return "revoked-token-" + jti
+}
+
+func tokensValidAfterKey(userID int32) string {
+	return fmt.Sprintf("tokens-valid-after-%d", userID)
+}
+
+func randomToken(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// StartRefreshFamily starts a new token family for the user and returns the
+// family along with its first refresh token.
+func (s *TokenStore) StartRefreshFamily(userID int32) (string, string, error) {
+	family, err := randomToken(16)
+	if err != nil {
+		return "", "", err
+	}
+
+	token, err := s.issue(userID, family)
+	if err != nil {
+		return "", "", err
+	}
+
+	return family, token, nil
+}
+
+// issue stores a new token of the family. The family lives as long as its
+// newest token, while used tokens are kept until they expire so reuse can be
+// detected.
+func (s *TokenStore) issue(userID int32, family string) (string, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	key := refreshTokenKey(token)
+	pipe := s.Redis.TxPipeline()
+	pipe.HMSet(key, map[string]interface{}{"user_id": userID, "family": family})
+	pipe.Expire(key, s.RefreshTTL)
+	pipe.Set(refreshFamilyKey(family), userID, s.RefreshTTL)
+	pipe.SAdd(userFamiliesKey(userID), family)
+	pipe.Expire(userFamiliesKey(userID), s.RefreshTTL)
+	if _, err := pipe.Exec(); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// RotateRefreshToken exchanges a refresh token for a new one of the same
+// family and returns the user and family it belongs to. A token can be
+// rotated once; presenting it again revokes the whole family and returns
+// ErrRefreshTokenReused.
+func (s *TokenStore) RotateRefreshToken(token string) (int32, string, string, error) {
+	key := refreshTokenKey(token)
+
+	// Marking the token used first makes concurrent rotations of the same
+	// token see each other.
+	used, err := s.Redis.HIncrBy(key, "used", 1).Result()
+	if err != nil {
+		return 0, "", "", err
+	}
+
+	values, err := s.Redis.HGetAll(key).Result()
+	if err != nil {
+		return 0, "", "", err
+	}
+
+	family := values["family"]
+	userID, err := strconv.ParseInt(values["user_id"], 10, 32)
+	if family == "" || err != nil {
+		// The token is unknown or expired meanwhile; drop what HIncrBy created.
+		if err := s.Redis.Del(key).Err(); err != nil {
+			return 0, "", "", err
+		}
+
+		return 0, "", "", ErrInvalidRefreshToken
+	}
+
+	active, err := s.Redis.Exists(refreshFamilyKey(family)).Result()
+	if err != nil {
+		return 0, "", "", err
+	}
+
+	if active == 0 {
+		return 0, "", "", ErrInvalidRefreshToken
+	}
+
+	if used > 1 {
+		if err := s.Redis.Del(refreshFamilyKey(family)).Err(); err != nil {
+			return 0, "", "", err
+		}
+
+		return 0, "", "", ErrRefreshTokenReused
+	}
+
+	next, err := s.issue(int32(userID), family)
+	if err != nil {
+		return 0, "", "", err
+	}
+
+	return int32(userID), family, next, nil
+}
+
+// RefreshTokenFamily returns the family of the token, or
+// ErrInvalidRefreshToken when the token is unknown.
+func (s *TokenStore) RefreshTokenFamily(token string) (string, error) {
+	family, err := s.Redis.HGet(refreshTokenKey(token), "family").Result()
+	if err == redis.Nil {
+		return "", ErrInvalidRefreshToken
+	}
+
+	return family, err
+}
+
+// RevokeRefreshToken revokes the family of the token. Unknown tokens are
+// ignored.
+func (s *TokenStore) RevokeRefreshToken(token string) error {
+	family, err := s.RefreshTokenFamily(token)
+	if err == ErrInvalidRefreshToken {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return s.RevokeRefreshFamily(family)
+}
+
+// RevokeRefreshFamily revokes every refresh token of the family.
+func (s *TokenStore) RevokeRefreshFamily(family string) error {
+	return s.Redis.Del(refreshFamilyKey(family)).Err()
+}
+
+// IsRefreshFamilyActive reports whether the family was neither revoked nor
+// left unused until it expired.
+func (s *TokenStore) IsRefreshFamilyActive(family string) (bool, error) {
+	active, err := s.Redis.Exists(refreshFamilyKey(family)).Result()
+	return active > 0, err
+}
+
+// RevokeAccessToken adds the token id to the denylist until the token
+// expires on its own.
+func (s *TokenStore) RevokeAccessToken(jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	return s.Redis.Set(revokedTokenKey(jti), 1, ttl).Err()
+}
+
+// RevokeUserTokens revokes every refresh token family of the user and every
+// access token issued up to now. Tokens issued later, such as the ones returned
+// after a password change, stay valid.
+func (s *TokenStore) RevokeUserTokens(userID int32) error {
+	families, err := s.Redis.SMembers(userFamiliesKey(userID)).Result()
+	if err != nil {
+		return err
+	}
+
+	keys := []string{userFamiliesKey(userID)}
+	for _, family := range families {
+		keys = append(keys, refreshFamilyKey(family))
+	}
+
+	ttl := s.AccessTTL
+	if ttl <= 0 {
+		ttl = s.RefreshTTL
+	}
+
+	pipe := s.Redis.TxPipeline()
+	pipe.Del(keys...)
+	pipe.Set(tokensValidAfterKey(userID), time.Now().UnixNano(), ttl)
+	_, err = pipe.Exec()
+
+	return err
+}
+
+// IsAccessTokenRevoked reports whether the token was revoked on its own or
+// with all tokens of its user.
+func (s *TokenStore) IsAccessTokenRevoked(userID int32, jti string, issuedAt time.Time) (bool, error) {
+	pipe := s.Redis.Pipeline()
+	revoked := pipe.Exists(revokedTokenKey(jti))
+	validAfter := pipe.Get(tokensValidAfterKey(userID))
+	if _, err := pipe.Exec(); err != nil && err != redis.Nil {
+		return false, err
+	}
+
+	if revoked.Val() > 0 {
+		return true, nil
+	}
+
+	after, err := validAfter.Int64()
+	if err == redis.Nil {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	// Tokens issued up to and including the revocation are revoked, so one
+	// refreshed in the same second does not slip through.
+	return issuedAt.UnixNano() <= after, nil
+}
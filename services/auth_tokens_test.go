@@ -0,0 +1,153 @@
+package services
+
+import (
+	"aliagha/database"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/suite"
+)
+
+type TokenStoreTestSuite struct {
+	suite.Suite
+	redisServer *miniredis.Miniredis
+	tokens      *TokenStore
+}
+
+func (suite *TokenStoreTestSuite) SetupSuite() {
+	server, client := database.NewRedisMock()
+	suite.redisServer = server
+	suite.tokens = &TokenStore{Redis: client, AccessTTL: time.Minute, RefreshTTL: time.Hour}
+}
+
+func (suite *TokenStoreTestSuite) SetupTest() {
+	suite.redisServer.FlushAll()
+}
+
+func (suite *TokenStoreTestSuite) TearDownSuite() {
+	suite.redisServer.Close()
+}
+
+func (suite *TokenStoreTestSuite) TestRotateRefreshToken_Success() {
+	require := suite.Require()
+
+	family, token, err := suite.tokens.StartRefreshFamily(7)
+	require.NoError(err)
+
+	userID, rotated, next, err := suite.tokens.RotateRefreshToken(token)
+	require.NoError(err)
+	require.Equal(int32(7), userID)
+	require.Equal(family, rotated)
+	require.NotEqual(token, next)
+
+	userID, rotated, _, err = suite.tokens.RotateRefreshToken(next)
+	require.NoError(err)
+	require.Equal(int32(7), userID)
+	require.Equal(family, rotated)
+
+	for _, key := range suite.redisServer.Keys() {
+		require.NotContains(key, token)
+	}
+}
+
+func (suite *TokenStoreTestSuite) TestRotateRefreshToken_Reuse_RevokesFamily() {
+	require := suite.Require()
+
+	_, token, err := suite.tokens.StartRefreshFamily(7)
+	require.NoError(err)
+	_, other, err := suite.tokens.StartRefreshFamily(7)
+	require.NoError(err)
+
+	_, _, next, err := suite.tokens.RotateRefreshToken(token)
+	require.NoError(err)
+
+	_, _, _, err = suite.tokens.RotateRefreshToken(token)
+	require.ErrorIs(err, ErrRefreshTokenReused)
+
+	_, _, _, err = suite.tokens.RotateRefreshToken(next)
+	require.ErrorIs(err, ErrInvalidRefreshToken)
+
+	_, _, _, err = suite.tokens.RotateRefreshToken(other)
+	require.NoError(err, "other families are not affected")
+}
+
+func (suite *TokenStoreTestSuite) TestRotateRefreshToken_Unknown_Failure() {
+	require := suite.Require()
+
+	_, _, _, err := suite.tokens.RotateRefreshToken("unknown")
+	require.ErrorIs(err, ErrInvalidRefreshToken)
+	require.Empty(suite.redisServer.Keys())
+}
+
+func (suite *TokenStoreTestSuite) TestRevokeRefreshToken_Success() {
+	require := suite.Require()
+
+	_, token, err := suite.tokens.StartRefreshFamily(7)
+	require.NoError(err)
+	require.NoError(suite.tokens.RevokeRefreshToken(token))
+	require.NoError(suite.tokens.RevokeRefreshToken("unknown"))
+
+	_, _, _, err = suite.tokens.RotateRefreshToken(token)
+	require.ErrorIs(err, ErrInvalidRefreshToken)
+}
+
+func (suite *TokenStoreTestSuite) TestRevokeAccessToken_Success() {
+	require := suite.Require()
+
+	require.NoError(suite.tokens.RevokeAccessToken("jti-1", time.Now().Add(time.Minute)))
+	require.NoError(suite.tokens.RevokeAccessToken("jti-2", time.Now().Add(-time.Minute)))
+
+	revoked, err := suite.tokens.IsAccessTokenRevoked(7, "jti-1", time.Now())
+	require.NoError(err)
+	require.True(revoked)
+
+	revoked, err = suite.tokens.IsAccessTokenRevoked(7, "jti-2", time.Now())
+	require.NoError(err)
+	require.False(revoked)
+
+	suite.redisServer.FastForward(2 * time.Minute)
+	revoked, err = suite.tokens.IsAccessTokenRevoked(7, "jti-1", time.Now())
+	require.NoError(err)
+	require.False(revoked)
+}
+
+func (suite *TokenStoreTestSuite) TestRevokeUserTokens_Success() {
+	require := suite.Require()
+
+	_, token, err := suite.tokens.StartRefreshFamily(7)
+	require.NoError(err)
+	_, _, token, err = suite.tokens.RotateRefreshToken(token)
+	require.NoError(err)
+	_, other, err := suite.tokens.StartRefreshFamily(8)
+	require.NoError(err)
+
+	require.NoError(suite.tokens.RevokeUserTokens(7))
+
+	_, _, _, err = suite.tokens.RotateRefreshToken(token)
+	require.ErrorIs(err, ErrInvalidRefreshToken)
+	_, _, _, err = suite.tokens.RotateRefreshToken(other)
+	require.NoError(err)
+
+	revoked, err := suite.tokens.IsAccessTokenRevoked(7, "jti-1", time.Now().Add(-time.Second))
+	require.NoError(err)
+	require.True(revoked)
+
+	// A token carrying only whole seconds may have been issued just before
+	// the revocation in the same second.
+	revoked, err = suite.tokens.IsAccessTokenRevoked(7, "jti-2", time.Unix(time.Now().Unix(), 0))
+	require.NoError(err)
+	require.True(revoked, "tokens issued in the second of the revocation are revoked")
+
+	revoked, err = suite.tokens.IsAccessTokenRevoked(7, "jti-3", time.Now())
+	require.NoError(err)
+	require.False(revoked, "tokens issued after the revocation are valid")
+
+	revoked, err = suite.tokens.IsAccessTokenRevoked(8, "jti-4", time.Now().Add(-time.Second))
+	require.NoError(err)
+	require.False(revoked)
+}
+
+func TestTokenStore(t *testing.T) {
+	suite.Run(t, new(TokenStoreTestSuite))
+}
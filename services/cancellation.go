@@ -0,0 +1,98 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CancellationRule is the penalty charged when a ticket is cancelled at least
+// HoursBefore hours before departure.
+type CancellationRule struct {
+	HoursBefore int `json:"hours_before"`
+	Penalty     int `json:"penalty_percent"`
+}
+
+// ParseCancellationRules parses the data of a canceling situation, a comma
+// separated list of "hours:percent" pairs such as "72:10,24:30,3:50,0:80".
+// Rules are returned from the earliest cancellation to the latest.
+func ParseCancellationRules(data string) ([]CancellationRule, error) {
+	rules := make([]CancellationRule, 0)
+	for _, part := range strings.Split(data, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		pair := strings.SplitN(part, ":", 2)
+		if len(pair) != 2 {
+			return nil, fmt.Errorf("invalid cancellation rule %q", part)
+		}
+
+		hours, err := strconv.Atoi(strings.TrimSpace(pair[0]))
+		if err != nil || hours < 0 {
+			return nil, fmt.Errorf("invalid cancellation hours %q", pair[0])
+		}
+
+		penalty, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(pair[1]), "%"))
+		if err != nil || penalty < 0 || penalty > 100 {
+			return nil, fmt.Errorf("invalid cancellation penalty %q", pair[1])
+		}
+
+		rules = append(rules, CancellationRule{HoursBefore: hours, Penalty: penalty})
+	}
+
+	if len(rules) == 0 {
+		return nil, errors.New("no cancellation rules")
+	}
+
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].HoursBefore > rules[j].HoursBefore
+	})
+
+	return rules, nil
+}
+
+// DescribeCancellationRules turns rules into sentences a passenger can read.
+func DescribeCancellationRules(rules []CancellationRule) []string {
+	descriptions := make([]string, 0, len(rules)+1)
+	for i, rule := range rules {
+		var window string
+		switch {
+		case i == 0 && rule.HoursBefore == 0:
+			window = "Any time before departure"
+		case i == 0:
+			window = fmt.Sprintf("At least %s before departure", hoursText(rule.HoursBefore))
+		case rule.HoursBefore == 0:
+			window = fmt.Sprintf("Less than %s before departure", hoursText(rules[i-1].HoursBefore))
+		default:
+			window = fmt.Sprintf("Between %s and %s before departure", hoursText(rule.HoursBefore), hoursText(rules[i-1].HoursBefore))
+		}
+
+		if rule.Penalty == 0 {
+			descriptions = append(descriptions, window+": free cancellation")
+		} else {
+			descriptions = append(descriptions, fmt.Sprintf("%s: %d%% of the fare is charged", window, rule.Penalty))
+		}
+	}
+
+	if last := rules[len(rules)-1]; last.HoursBefore > 0 {
+		descriptions = append(descriptions, fmt.Sprintf("Less than %s before departure: the ticket is not refundable", hoursText(last.HoursBefore)))
+	}
+
+	return descriptions
+}
+
+func hoursText(hours int) string {
+	if hours%24 == 0 && hours >= 48 {
+		return fmt.Sprintf("%d days", hours/24)
+	}
+
+	if hours == 1 {
+		return "1 hour"
+	}
+
+	return fmt.Sprintf("%d hours", hours)
+}
@@ -0,0 +1,54 @@
+package services
+
+type cityAlias struct {
+	Persian string
+	English []string
+	IATA    []string
+}
+
+// cityAliases maps the Persian city names used by the airline API to their
+// common English transliterations and the IATA codes of the airports serving them.
+var cityAliases = []cityAlias{
+	{Persian: "تهران", English: []string{"Tehran", "Teheran"}, IATA: []string{"THR", "IKA"}},
+	{Persian: "مشهد", English: []string{"Mashhad", "Mashad", "Meshed"}, IATA: []string{"MHD"}},
+	{Persian: "اصفهان", English: []string{"Isfahan", "Esfahan", "Ispahan"}, IATA: []string{"IFN"}},
+	{Persian: "شیراز", English: []string{"Shiraz"}, IATA: []string{"SYZ"}},
+	{Persian: "تبریز", English: []string{"Tabriz"}, IATA: []string{"TBZ"}},
+	{Persian: "اهواز", English: []string{"Ahvaz", "Ahwaz"}, IATA: []string{"AWZ"}},
+	{Persian: "کیش", English: []string{"Kish"}, IATA: []string{"KIH"}},
+	{Persian: "قشم", English: []string{"Qeshm", "Gheshm"}, IATA: []string{"GSM"}},
+	{Persian: "بندرعباس", English: []string{"Bandar Abbas", "Bandar-e Abbas"}, IATA: []string{"BND"}},
+	{Persian: "کرمان", English: []string{"Kerman"}, IATA: []string{"KER"}},
+	{Persian: "کرمانشاه", English: []string{"Kermanshah"}, IATA: []string{"KSH"}},
+	{Persian: "یزد", English: []string{"Yazd"}, IATA: []string{"AZD"}},
+	{Persian: "رشت", English: []string{"Rasht", "Resht"}, IATA: []string{"RAS"}},
+	{Persian: "ساری", English: []string{"Sari"}, IATA: []string{"SRY"}},
+	{Persian: "زاهدان", English: []string{"Zahedan"}, IATA: []string{"ZAH"}},
+	{Persian: "ارومیه", English: []string{"Urmia", "Orumiyeh", "Urumieh"}, IATA: []string{"OMH"}},
+	{Persian: "بوشهر", English: []string{"Bushehr", "Bushire"}, IATA: []string{"BUZ"}},
+	{Persian: "آبادان", English: []string{"Abadan"}, IATA: []string{"ABD"}},
+	{Persian: "اردبیل", English: []string{"Ardabil", "Ardebil"}, IATA: []string{"ADU"}},
+	{Persian: "گرگان", English: []string{"Gorgan"}, IATA: []string{"GBT"}},
+	{Persian: "همدان", English: []string{"Hamadan", "Hamedan"}, IATA: []string{"HDM"}},
+	{Persian: "سنندج", English: []string{"Sanandaj"}, IATA: []string{"SDG"}},
+	{Persian: "خرم‌آباد", English: []string{"Khorramabad"}, IATA: []string{"KHD"}},
+	{Persian: "بیرجند", English: []string{"Birjand"}, IATA: []string{"XBJ"}},
+	{Persian: "زنجان", English: []string{"Zanjan"}, IATA: []string{"JWN"}},
+	{Persian: "چابهار", English: []string{"Chabahar"}, IATA: []string{"ZBR"}},
+	{Persian: "عسلویه", English: []string{"Asaluyeh", "Assaluyeh"}, IATA: []string{"PGU"}},
+	{Persian: "یاسوج", English: []string{"Yasuj", "Yasouj"}, IATA: []string{"YES"}},
+	{Persian: "دزفول", English: []string{"Dezful"}, IATA: []string{"DEF"}},
+	{Persian: "ایلام", English: []string{"Ilam"}, IATA: []string{"IIL"}},
+	{Persian: "شهرکرد", English: []string{"Shahrekord", "Shahr-e Kord"}, IATA: []string{"CQD"}},
+	{Persian: "بجنورد", English: []string{"Bojnurd", "Bojnord"}, IATA: []string{"BJB"}},
+	{Persian: "سبزوار", English: []string{"Sabzevar"}, IATA: []string{"AFZ"}},
+	{Persian: "رامسر", English: []string{"Ramsar"}, IATA: []string{"RZR"}},
+	{Persian: "نوشهر", English: []string{"Nowshahr", "Noshahr"}, IATA: []string{"NSH"}},
+	{Persian: "لار", English: []string{"Lar"}, IATA: []string{"LRR"}},
+	{Persian: "جیرفت", English: []string{"Jiroft"}, IATA: []string{"JYR"}},
+	{Persian: "رفسنجان", English: []string{"Rafsanjan"}, IATA: []string{"RJN"}},
+	{Persian: "بم", English: []string{"Bam"}, IATA: []string{"BXR"}},
+	{Persian: "استانبول", English: []string{"Istanbul"}, IATA: []string{"IST", "SAW"}},
+	{Persian: "دبی", English: []string{"Dubai"}, IATA: []string{"DXB", "DWC"}},
+	{Persian: "نجف", English: []string{"Najaf"}, IATA: []string{"NJF"}},
+}
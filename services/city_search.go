@@ -0,0 +1,153 @@
+package services
+
+import (
+	"aliagha/utils"
+	"sort"
+	"strings"
+)
+
+type CitySuggestion struct {
+	ID          int32    `json:"id"`
+	Name        string   `json:"name"`
+	EnglishName string   `json:"english_name,omitempty"`
+	IATA        []string `json:"iata,omitempty"`
+}
+
+type cityEntry struct {
+	suggestion CitySuggestion
+	keys       []string
+}
+
+// CityIndex answers autocomplete queries over the cities returned by the
+// airline API. Names are normalized once when the index is built.
+type CityIndex struct {
+	entries []cityEntry
+}
+
+const (
+	scoreExact = iota
+	scorePrefix
+	scoreWordPrefix
+	scoreFuzzy
+)
+
+func NewCityIndex(cities []GetCityResponse) *CityIndex {
+	aliases := make(map[string]cityAlias)
+	for _, alias := range cityAliases {
+		aliases[utils.NormalizePersian(alias.Persian)] = alias
+		for _, name := range alias.English {
+			aliases[utils.NormalizePersian(name)] = alias
+		}
+	}
+
+	index := &CityIndex{entries: make([]cityEntry, 0, len(cities))}
+	for _, city := range cities {
+		entry := cityEntry{
+			suggestion: CitySuggestion{ID: city.ID, Name: city.Name},
+			keys:       []string{utils.NormalizePersian(city.Name)},
+		}
+
+		if alias, ok := aliases[entry.keys[0]]; ok {
+			entry.keys = append(entry.keys, utils.NormalizePersian(alias.Persian))
+			for _, name := range alias.English {
+				entry.keys = append(entry.keys, utils.NormalizePersian(name))
+			}
+			for _, code := range alias.IATA {
+				entry.keys = append(entry.keys, strings.ToLower(code))
+			}
+
+			entry.suggestion.EnglishName = alias.English[0]
+			entry.suggestion.IATA = alias.IATA
+		}
+
+		index.entries = append(index.entries, entry)
+	}
+
+	return index
+}
+
+// Suggest returns up to limit cities matching query. Exact matches (including
+// IATA codes) come first, then prefix matches, then matches on a later word of
+// the name and finally names within a small edit distance of the query.
+func (i *CityIndex) Suggest(query string, limit int) []CitySuggestion {
+	q := utils.NormalizePersian(query)
+	if q == "" {
+		return []CitySuggestion{}
+	}
+
+	type match struct {
+		entry *cityEntry
+		score int
+	}
+
+	matches := make([]match, 0)
+	for idx := range i.entries {
+		entry := &i.entries[idx]
+		best := -1
+		for _, key := range entry.keys {
+			if score, ok := matchScore(key, q); ok && (best == -1 || score < best) {
+				best = score
+			}
+		}
+
+		if best != -1 {
+			matches = append(matches, match{entry: entry, score: best})
+		}
+	}
+
+	sort.SliceStable(matches, func(a, b int) bool {
+		if matches[a].score != matches[b].score {
+			return matches[a].score < matches[b].score
+		}
+		return matches[a].entry.keys[0] < matches[b].entry.keys[0]
+	})
+
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	suggestions := make([]CitySuggestion, 0, len(matches))
+	for _, m := range matches {
+		suggestions = append(suggestions, m.entry.suggestion)
+	}
+
+	return suggestions
+}
+
+func matchScore(key, q string) (int, bool) {
+	switch {
+	case key == q:
+		return scoreExact, true
+	case strings.HasPrefix(key, q):
+		return scorePrefix, true
+	case strings.Contains(key, " "+q):
+		return scoreWordPrefix, true
+	}
+
+	// Compare against a prefix of the key as long as the query so that
+	// typos are tolerated while the user is still typing.
+	// Short keys such as IATA codes only match exactly or by prefix.
+	keyRunes, qRunes := []rune(key), []rune(q)
+	if len(qRunes) < 3 || len(keyRunes) <= 3 {
+		return 0, false
+	}
+
+	if len(keyRunes) > len(qRunes) {
+		keyRunes = keyRunes[:len(qRunes)]
+	}
+
+	distance := utils.Levenshtein(string(keyRunes), q)
+	if distance <= maxTypos(len(qRunes)) {
+		return scoreFuzzy + distance, true
+	}
+
+	return 0, false
+}
+
+func maxTypos(length int) int {
+	if length <= 5 {
+		return 1
+	}
+
+	return 2
+}
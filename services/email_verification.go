@@ -0,0 +1,64 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+var ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+
+// EmailVerifications mails single use links that verify the email address of
+// a user. A link only verifies the address it was sent to.
+type EmailVerifications struct {
+	Redis  *redis.Client
+	Mailer Mailer
+	TTL    time.Duration
+	// URL is the page of the frontend that verifies the email; the token is
+	// added to it as the token query parameter.
+	URL string
+}
+
+func (v *EmailVerifications) tokens() singleUseTokens {
+	return singleUseTokens{redis: v.Redis, kind: "email-verification", ttl: v.TTL}
+}
+
+// Send mails a new verification link to the email address.
+func (v *EmailVerifications) Send(userID int32, email string) error {
+	token, err := v.tokens().issue(userID, email)
+	if err != nil {
+		return err
+	}
+
+	link, err := tokenLink(v.URL, token)
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Welcome to Aliagha!\n\n"+
+		"Open this link within %s to verify your email address:\n%s\n\n"+
+		"If you did not sign up, ignore this email.", v.TTL, link)
+
+	return v.Mailer.Send(email, "Verify your email address", body)
+}
+
+// SendRegistered tells the owner of a registered email address that someone
+// tried to use it for another account.
+func (v *EmailVerifications) SendRegistered(email string) error {
+	body := "Someone tried to use this email address for an Aliagha account, but it already has one.\n\n" +
+		"If it was you, log in or reset your password. If not, ignore this email."
+
+	return v.Mailer.Send(email, "You already have an Aliagha account", body)
+}
+
+// Consume returns the user and email address of the token and invalidates it.
+func (v *EmailVerifications) Consume(token string) (int32, string, error) {
+	userID, email, err := v.tokens().consume(token)
+	if err == errUnknownToken {
+		return 0, "", ErrInvalidVerificationToken
+	}
+
+	return userID, email, err
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"aliagha/database"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmailVerifications(t *testing.T) {
+	server, client := database.NewRedisMock()
+	defer server.Close()
+
+	mailer := &recordingMailer{}
+	verifications := &EmailVerifications{Redis: client, Mailer: mailer, TTL: time.Hour, URL: "https://aliagha.test/verify-email"}
+
+	require.NoError(t, verifications.Send(7, "user@example.com"))
+	require.Equal(t, []string{"user@example.com"}, mailer.to)
+
+	token := strings.Fields(strings.SplitN(mailer.bodies[0], "?token=", 2)[1])[0]
+	userID, email, err := verifications.Consume(token)
+	require.NoError(t, err)
+	require.Equal(t, int32(7), userID)
+	require.Equal(t, "user@example.com", email)
+
+	_, _, err = verifications.Consume(token)
+	require.ErrorIs(t, err, ErrInvalidVerificationToken)
+
+	// Reset and verification tokens are separate kinds.
+	resets := &PasswordResets{Redis: client, Mailer: mailer, TTL: time.Hour, URL: "https://aliagha.test/reset-password"}
+	require.NoError(t, resets.Send(7, "user@example.com"))
+	token = strings.Fields(strings.SplitN(mailer.bodies[1], "?token=", 2)[1])[0]
+	_, _, err = verifications.Consume(token)
+	require.ErrorIs(t, err, ErrInvalidVerificationToken)
+}
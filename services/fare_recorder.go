@@ -0,0 +1,130 @@
+package services
+
+import (
+	"aliagha/models"
+	"context"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// FareRecorder stores price snapshots of search results in the background so
+// that recording never slows down a search. A flight is only snapshotted again
+// once its price or seats change, or after Interval has passed.
+type FareRecorder struct {
+	DB       *gorm.DB
+	Interval time.Duration
+
+	queue    chan []models.FareSnapshot
+	last     map[fareKey]models.FareSnapshot
+	prunedAt time.Time
+}
+
+// fareKey identifies a flight; flight ids are only unique within a provider.
+type fareKey struct {
+	provider string
+	flightID int32
+}
+
+func NewFareRecorder(db *gorm.DB, interval time.Duration, queueSize int) *FareRecorder {
+	return &FareRecorder{
+		DB:       db,
+		Interval: interval,
+		queue:    make(chan []models.FareSnapshot, queueSize),
+		last:     make(map[fareKey]models.FareSnapshot),
+	}
+}
+
+// Record queues the flights of a search. Snapshots are dropped when the queue
+// is full rather than blocking the caller.
+func (r *FareRecorder) Record(depCity, arrCity, date string, flights []FlightResponse) {
+	flightDate, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	snapshots := make([]models.FareSnapshot, 0, len(flights))
+	for _, flight := range flights {
+		snapshots = append(snapshots, models.FareSnapshot{
+			Provider:       flight.Provider,
+			FlightID:       flight.ID,
+			DepCity:        depCity,
+			ArrCity:        arrCity,
+			FlightDate:     flightDate,
+			Airline:        flight.Airline,
+			Price:          flight.Price,
+			RemainingSeats: flight.RemainingSeats,
+			CapturedAt:     now,
+		})
+	}
+
+	select {
+	case r.queue <- snapshots:
+	default:
+		log.Printf("fare_recorder: queue is full, dropped %d snapshots", len(snapshots))
+	}
+}
+
+// Run writes queued snapshots until ctx is done, then flushes what is left.
+func (r *FareRecorder) Run(ctx context.Context) {
+	for {
+		select {
+		case snapshots := <-r.queue:
+			r.write(snapshots)
+		case <-ctx.Done():
+			for {
+				select {
+				case snapshots := <-r.queue:
+					r.write(snapshots)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (r *FareRecorder) write(snapshots []models.FareSnapshot) {
+	changed := make([]models.FareSnapshot, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		last, ok := r.last[fareKey{snapshot.Provider, snapshot.FlightID}]
+		if ok && last.Price == snapshot.Price && last.RemainingSeats == snapshot.RemainingSeats &&
+			snapshot.CapturedAt.Sub(last.CapturedAt) < r.Interval {
+			continue
+		}
+
+		changed = append(changed, snapshot)
+	}
+
+	if len(changed) == 0 {
+		return
+	}
+
+	if err := r.DB.CreateInBatches(&changed, 100).Error; err != nil {
+		log.Printf("fare_recorder: storing %d snapshots failed, error: %v", len(changed), err)
+		return
+	}
+
+	for _, snapshot := range changed {
+		r.last[fareKey{snapshot.Provider, snapshot.FlightID}] = snapshot
+	}
+
+	r.prune(time.Now())
+}
+
+// prune forgets flights that have not been snapshotted for a while so that
+// the map does not grow with every flight ever searched.
+func (r *FareRecorder) prune(now time.Time) {
+	if now.Sub(r.prunedAt) < r.Interval {
+		return
+	}
+
+	for key, snapshot := range r.last {
+		if now.Sub(snapshot.CapturedAt) > 2*r.Interval {
+			delete(r.last, key)
+		}
+	}
+	r.prunedAt = now
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"aliagha/database"
+	"aliagha/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type FareRecorderTestSuite struct {
+	suite.Suite
+	recorder *FareRecorder
+}
+
+func (suite *FareRecorderTestSuite) SetupTest() {
+	suite.recorder = NewFareRecorder(database.NewSQLiteMock(), time.Hour, 10)
+}
+
+func (suite *FareRecorderTestSuite) snapshot(provider string, price int32, capturedAt time.Time) models.FareSnapshot {
+	return models.FareSnapshot{
+		Provider: provider, FlightID: 7, DepCity: "CityA", ArrCity: "CityB", FlightDate: capturedAt,
+		Airline: "AirlineX", Price: price, RemainingSeats: 10, CapturedAt: capturedAt,
+	}
+}
+
+func (suite *FareRecorderTestSuite) TestWrite_Providers_Success() {
+	require := suite.Require()
+
+	now := time.Now()
+	suite.recorder.write([]models.FareSnapshot{suite.snapshot("alpha", 200, now), suite.snapshot("beta", 250, now)})
+
+	// Unchanged prices are skipped per provider, so a price of one provider
+	// does not hide a change of the other.
+	later := now.Add(time.Minute)
+	suite.recorder.write([]models.FareSnapshot{suite.snapshot("alpha", 250, later), suite.snapshot("beta", 250, later)})
+	suite.recorder.write([]models.FareSnapshot{suite.snapshot("beta", 200, later.Add(time.Minute))})
+
+	var snapshots []models.FareSnapshot
+	require.NoError(suite.recorder.DB.Order("provider, captured_at").Find(&snapshots).Error)
+	require.Len(snapshots, 4)
+	for i, expected := range []struct {
+		provider string
+		price    int32
+	}{{"alpha", 200}, {"alpha", 250}, {"beta", 250}, {"beta", 200}} {
+		require.Equal(expected.provider, snapshots[i].Provider)
+		require.Equal(expected.price, snapshots[i].Price)
+	}
+}
+
+func TestFareRecorder(t *testing.T) {
+	suite.Run(t, new(FareRecorderTestSuite))
+}
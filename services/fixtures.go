@@ -0,0 +1,357 @@
+package services
+
+import (
+	"aliagha/models"
+	"aliagha/utils"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// FixturePassword is the password of every seeded user.
+const FixturePassword = "password"
+
+// fixtureEmailDomain marks seeded users, so seeding twice can be detected.
+const fixtureEmailDomain = "seed.aliagha.test"
+
+// FixtureProvider is the provider of seeded flights, keeping their ids apart
+// from the flights of real providers.
+const FixtureProvider = "fixtures"
+
+var ErrAlreadySeeded = errors.New("the database already has seeded users")
+
+type FixtureScale struct {
+	Users   int
+	Flights int
+	Tickets int
+}
+
+var FixtureScales = map[string]FixtureScale{
+	"small":  {Users: 10, Flights: 40, Tickets: 20},
+	"medium": {Users: 100, Flights: 400, Tickets: 300},
+	"large":  {Users: 1000, Flights: 4000, Tickets: 3000},
+}
+
+type FixtureOptions struct {
+	// Seed makes the generated data reproducible: the same seed, scale and
+	// day give the same rows.
+	Seed  int64
+	Scale FixtureScale
+	// Now is the day flights are spread around, from a month before it to two
+	// months after. It defaults to the current time.
+	Now time.Time
+}
+
+type FixtureResult struct {
+	Users      int
+	Passengers int
+	Airports   int
+	Flights    int
+	Tickets    int
+}
+
+var (
+	fixtureFirstNames = []string{"Ali", "Reza", "Mohammad", "Hossein", "Mehdi", "Amir", "Sara", "Maryam",
+		"Fatemeh", "Zahra", "Narges", "Parisa", "Hamid", "Saeed", "Neda", "Leila", "Kaveh", "Shirin"}
+	fixtureLastNames = []string{"Ahmadi", "Hosseini", "Karimi", "Rezaei", "Moradi", "Mohammadi", "Jafari",
+		"Ghasemi", "Rahimi", "Sadeghi", "Kazemi", "Hashemi", "Tehrani", "Shirazi", "Esfahani"}
+	fixtureAirlines  = []string{"Iran Air", "Mahan Air", "Iran Aseman", "Qeshm Air", "Kish Air", "Zagros Airlines"}
+	fixtureAirplanes = []string{"Airbus A320", "Airbus A300", "Boeing 737", "Fokker 100", "ATR 72", "McDonnell Douglas MD-83"}
+	// fixtureCancelingSituations maps descriptions to "hours:percent" rules.
+	fixtureCancelingSituations = [][2]string{
+		{"Economy", "72:10,24:30,3:50,0:80"},
+		{"Business", "48:5,12:20,0:50"},
+		{"Non-refundable", "0:100"},
+	}
+	fixtureClasses = []struct{ Class, Baggage, Meal string }{
+		{"economy", "20kg", "snack"},
+		{"economy", "15kg", "none"},
+		{"business", "30kg", "hot meal"},
+	}
+)
+
+// SeedFixtures fills the database with realistic data for local development:
+// the bundled airports and their cities, airplanes, canceling situations,
+// users with passengers, flights and tickets. Everything is written in one
+// transaction and in foreign key order. Seeding twice returns ErrAlreadySeeded.
+func SeedFixtures(db *gorm.DB, options FixtureOptions) (FixtureResult, error) {
+	if options.Now.IsZero() {
+		options.Now = time.Now()
+	}
+
+	password, err := bcrypt.GenerateFromPassword([]byte(FixturePassword), bcrypt.DefaultCost)
+	if err != nil {
+		return FixtureResult{}, err
+	}
+
+	var result FixtureResult
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var seeded int64
+		if err := tx.Model(&models.User{}).Where("email LIKE ?", "%@"+fixtureEmailDomain).Count(&seeded).Error; err != nil {
+			return err
+		}
+
+		if seeded > 0 {
+			return ErrAlreadySeeded
+		}
+
+		airportsResult, err := SeedAirports(tx, BundledAirports())
+		if err != nil {
+			return err
+		}
+		result.Airports = airportsResult.Airports
+
+		g := &fixtureGenerator{tx: tx, rand: rand.New(rand.NewSource(options.Seed)), now: options.Now.UTC(), password: string(password)}
+		if err := g.createReference(); err != nil {
+			return err
+		}
+
+		if result.Users, result.Passengers, err = g.createUsers(options.Scale.Users); err != nil {
+			return err
+		}
+
+		if result.Flights, err = g.createFlights(options.Scale.Flights); err != nil {
+			return err
+		}
+
+		result.Tickets, err = g.createTickets(options.Scale.Tickets)
+		return err
+	})
+
+	return result, err
+}
+
+type fixtureGenerator struct {
+	tx       *gorm.DB
+	rand     *rand.Rand
+	now      time.Time
+	password string
+
+	airports     []models.Airport
+	airplaneIDs  []int32
+	situationIDs []int32
+	passengers   map[int32][]int32
+	userIDs      []int32
+	flights      []models.Flight
+}
+
+func (g *fixtureGenerator) createReference() error {
+	if err := g.tx.Model(&models.Airport{}).Order("id").Find(&g.airports).Error; err != nil {
+		return err
+	}
+
+	cities := make(map[int32]bool)
+	for _, airport := range g.airports {
+		cities[airport.CityID] = true
+	}
+
+	if len(cities) < 2 {
+		return errors.New("airports of at least two cities are needed to generate flights")
+	}
+
+	for _, name := range fixtureAirplanes {
+		airplane := models.Airplane{Name: name}
+		if err := g.tx.Model(&models.Airplane{}).Where("name = ?", name).FirstOrCreate(&airplane).Error; err != nil {
+			return err
+		}
+		g.airplaneIDs = append(g.airplaneIDs, airplane.ID)
+	}
+
+	for _, situation := range fixtureCancelingSituations {
+		record := models.CancelingSituation{Description: situation[0], Data: situation[1]}
+		err := g.tx.Model(&models.CancelingSituation{}).Where("description = ?", situation[0]).FirstOrCreate(&record).Error
+		if err != nil {
+			return err
+		}
+		g.situationIDs = append(g.situationIDs, record.ID)
+	}
+
+	return nil
+}
+
+// createUsers creates users with one to three passengers each; the first passenger
+// is the user.
+func (g *fixtureGenerator) createUsers(count int) (int, int, error) {
+	verifiedAt := g.now
+	users := make([]models.User, 0, count)
+	for i := 0; i < count; i++ {
+		first, last := g.pick(fixtureFirstNames), g.pick(fixtureLastNames)
+		users = append(users, models.User{
+			Name:      first + " " + last,
+			Password:  g.password,
+			Cellphone: "09" + g.digits(9),
+			Email:     fmt.Sprintf("%s.%s.%d@%s", strings.ToLower(first), strings.ToLower(last), i+1, fixtureEmailDomain),
+
+			EmailVerifiedAt:     &verifiedAt,
+			CellphoneVerifiedAt: &verifiedAt,
+			VerifiedAt:          &verifiedAt,
+			CreatedAt:           g.now,
+			UpdatedAt:           g.now,
+		})
+	}
+
+	if len(users) > 0 {
+		if err := g.tx.CreateInBatches(&users, 100).Error; err != nil {
+			return 0, 0, err
+		}
+	}
+
+	passengers := make([]models.Passenger, 0, count*2)
+	for _, user := range users {
+		codes := make(map[string]bool)
+		for i, n := 0, 1+g.rand.Intn(3); i < n; i++ {
+			name := user.Name
+			if i > 0 {
+				name = g.pick(fixtureFirstNames) + " " + strings.Split(user.Name, " ")[1]
+			}
+
+			// National codes are stored in an int column, so they start with 1
+			// to keep their leading digit and fit.
+			code := utils.GenerateNationalCode(g.rand, "1")
+			for codes[code] {
+				code = utils.GenerateNationalCode(g.rand, "1")
+			}
+			codes[code] = true
+
+			passengers = append(passengers, models.Passenger{
+				UID:          user.ID,
+				NationalCode: code,
+				Name:         name,
+				Birthdate:    time.Date(1950+g.rand.Intn(70), time.Month(1+g.rand.Intn(12)), 1+g.rand.Intn(28), 0, 0, 0, 0, time.UTC),
+			})
+		}
+	}
+
+	if len(passengers) > 0 {
+		if err := g.tx.Omit("User").CreateInBatches(&passengers, 100).Error; err != nil {
+			return 0, 0, err
+		}
+	}
+
+	g.passengers = make(map[int32][]int32, len(users))
+	for _, user := range users {
+		g.userIDs = append(g.userIDs, user.ID)
+	}
+	for _, passenger := range passengers {
+		g.passengers[passenger.UID] = append(g.passengers[passenger.UID], passenger.ID)
+	}
+
+	return len(users), len(passengers), nil
+}
+
+func (g *fixtureGenerator) createFlights(count int) (int, error) {
+	today := time.Date(g.now.Year(), g.now.Month(), g.now.Day(), 0, 0, 0, 0, time.UTC)
+
+	g.flights = make([]models.Flight, 0, count)
+	for i := 0; i < count; i++ {
+		dep := g.airports[g.rand.Intn(len(g.airports))]
+		arr := g.airports[g.rand.Intn(len(g.airports))]
+		for arr.CityID == dep.CityID {
+			arr = g.airports[g.rand.Intn(len(g.airports))]
+		}
+
+		depAirportID, arrAirportID := dep.ID, arr.ID
+		depTime := today.AddDate(0, 0, g.rand.Intn(91)-30).
+			Add(time.Duration(2+g.rand.Intn(18))*time.Hour + time.Duration(5*g.rand.Intn(12))*time.Minute)
+		class := fixtureClasses[g.rand.Intn(len(fixtureClasses))]
+		price := int32(1500000 + 10000*g.rand.Intn(450))
+		if class.Class == "business" {
+			price *= 2
+		}
+
+		g.flights = append(g.flights, models.Flight{
+			Provider:         FixtureProvider,
+			ID:               int32(i + 1),
+			DepCityID:        dep.CityID,
+			ArrCityID:        arr.CityID,
+			DepAirportID:     &depAirportID,
+			ArrAirportID:     &arrAirportID,
+			DepTime:          depTime,
+			ArrTime:          depTime.Add(time.Duration(50+5*g.rand.Intn(24)) * time.Minute),
+			AirplaneID:       g.airplaneIDs[g.rand.Intn(len(g.airplaneIDs))],
+			Airline:          g.pick(fixtureAirlines),
+			Price:            price,
+			CxlSitID:         g.situationIDs[g.rand.Intn(len(g.situationIDs))],
+			FlightClass:      class.Class,
+			BaggageAllowance: class.Baggage,
+			MealService:      class.Meal,
+			Gate:             string(rune('A'+g.rand.Intn(6))) + strconv.Itoa(1+g.rand.Intn(20)),
+		})
+	}
+
+	if len(g.flights) > 0 {
+		err := g.tx.Omit("DepCity", "ArrCity", "DepAirport", "ArrAirport", "Airplane", "CxlSit").
+			CreateInBatches(&g.flights, 100).Error
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(g.flights), nil
+}
+
+// createTickets books flights for some of the passengers of random users. Tickets
+// of departed flights are paid or cancelled; later ones may still await
+// payment.
+func (g *fixtureGenerator) createTickets(count int) (int, error) {
+	if len(g.userIDs) == 0 || len(g.flights) == 0 {
+		return 0, nil
+	}
+
+	tickets := make([]models.Ticket, 0, count)
+	for i := 0; i < count; i++ {
+		uid := g.userIDs[g.rand.Intn(len(g.userIDs))]
+		flight := g.flights[g.rand.Intn(len(g.flights))]
+
+		passengers := g.passengers[uid]
+		passengers = passengers[:1+g.rand.Intn(len(passengers))]
+		pids := make([]string, 0, len(passengers))
+		for _, pid := range passengers {
+			pids = append(pids, strconv.Itoa(int(pid)))
+		}
+
+		status := "paid"
+		switch n := g.rand.Intn(10); {
+		case n < 2:
+			status = "cancelled"
+		case n < 5 && flight.DepTime.After(g.now):
+			status = "payment pending"
+		}
+
+		tickets = append(tickets, models.Ticket{
+			UID:       uid,
+			PIDs:      strings.Join(pids, ","),
+			FID:       flight.ID,
+			Provider:  flight.Provider,
+			Status:    status,
+			Price:     flight.Price,
+			CreatedAt: g.now,
+			UpdatedAt: g.now,
+		})
+	}
+
+	if err := g.tx.Omit("User", "Flight").CreateInBatches(&tickets, 100).Error; err != nil {
+		return 0, err
+	}
+
+	return len(tickets), nil
+}
+
+func (g *fixtureGenerator) pick(values []string) string {
+	return values[g.rand.Intn(len(values))]
+}
+
+func (g *fixtureGenerator) digits(n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		b.WriteByte(byte('0' + g.rand.Intn(10)))
+	}
+
+	return b.String()
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"aliagha/database"
+	"aliagha/models"
+	"aliagha/utils"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type FixturesTestSuite struct {
+	suite.Suite
+	now time.Time
+}
+
+func (suite *FixturesTestSuite) SetupSuite() {
+	suite.now = time.Date(2023, 6, 28, 10, 0, 0, 0, time.UTC)
+}
+
+func (suite *FixturesTestSuite) TestSeedFixtures_Success() {
+	require := suite.Require()
+
+	db := database.NewSQLiteMock()
+	result, err := SeedFixtures(db, FixtureOptions{Seed: 1, Scale: FixtureScales["small"], Now: suite.now})
+	require.NoError(err)
+	require.Equal(10, result.Users)
+	require.Equal(40, result.Flights)
+	require.Equal(20, result.Tickets)
+	require.GreaterOrEqual(result.Passengers, 10)
+
+	var violations []map[string]interface{}
+	require.NoError(db.Raw("PRAGMA foreign_key_check").Scan(&violations).Error)
+	require.Empty(violations)
+
+	var passengers []models.Passenger
+	require.NoError(db.Find(&passengers).Error)
+	require.Len(passengers, result.Passengers)
+	for _, passenger := range passengers {
+		require.True(utils.IsValidNationalCode(passenger.NationalCode), passenger.NationalCode)
+	}
+
+	var tickets []models.Ticket
+	require.NoError(db.Preload("Flight").Find(&tickets).Error)
+	for _, ticket := range tickets {
+		require.Contains([]string{"paid", "cancelled", "payment pending"}, ticket.Status)
+		if ticket.Status == "payment pending" {
+			require.True(ticket.Flight.DepTime.After(suite.now))
+		}
+
+		var owned int64
+		ids := strings.Split(ticket.PIDs, ",")
+		require.NoError(db.Model(&models.Passenger{}).Where("u_id = ? AND id IN ?", ticket.UID, ids).Count(&owned).Error)
+		require.Equal(int64(len(ids)), owned)
+	}
+
+	_, err = SeedFixtures(db, FixtureOptions{Seed: 1, Scale: FixtureScales["small"], Now: suite.now})
+	require.ErrorIs(err, ErrAlreadySeeded)
+}
+
+func (suite *FixturesTestSuite) TestSeedFixtures_Deterministic() {
+	require := suite.Require()
+
+	snapshot := func(seed int64) ([]string, []string) {
+		db := database.NewSQLiteMock()
+		_, err := SeedFixtures(db, FixtureOptions{Seed: seed, Scale: FixtureScale{Users: 5, Flights: 10, Tickets: 5}, Now: suite.now})
+		require.NoError(err)
+
+		var emails, flights []string
+		var users []models.User
+		require.NoError(db.Order("id").Find(&users).Error)
+		for _, user := range users {
+			// Timestamps come from Now, not from the clock.
+			emails = append(emails, user.Email+" "+user.CreatedAt.UTC().Format(time.RFC3339))
+		}
+
+		var rows []models.Flight
+		require.NoError(db.Order("id").Find(&rows).Error)
+		for _, flight := range rows {
+			flights = append(flights, flight.Airline+" "+flight.DepTime.UTC().Format(time.RFC3339)+" "+strconv.Itoa(int(flight.Price)))
+		}
+
+		return emails, flights
+	}
+
+	emails, flights := snapshot(42)
+	sameEmails, sameFlights := snapshot(42)
+	require.Equal(emails, sameEmails)
+	require.Equal(flights, sameFlights)
+
+	otherEmails, _ := snapshot(43)
+	require.NotEqual(emails, otherEmails)
+}
+
+func TestFixtures(t *testing.T) {
+	suite.Run(t, new(FixturesTestSuite))
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+const (
+	popularRoutesKeyPrefix = "popular-routes-"
+	popularRoutesWindow    = 7
+	routeSeparator         = "|"
+)
+
+type Route struct {
+	DepartureCity string
+	ArrivalCity   string
+}
+
+func FlightsCacheKey(depCity, arrCity, date string) string {
+	return fmt.Sprintf("flights-%s-%s-%s", depCity, arrCity, date)
+}
+
+// FlightDetailCacheKey is the key of a flight of the provider; flight ids are
+// only unique within a provider.
+func FlightDetailCacheKey(provider string, flightID int32) string {
+	if provider == "" {
+		return fmt.Sprintf("flight-detail-%d", flightID)
+	}
+
+	return fmt.Sprintf("flight-detail-%s-%d", provider, flightID)
+}
+
+// TrackRouteSearch counts a search for the given route in today's popularity
+// bucket. Buckets expire once they fall out of the learning window.
+func TrackRouteSearch(client *redis.Client, depCity, arrCity string) error {
+	key := popularRoutesKeyPrefix + time.Now().Format("2006-01-02")
+
+	pipe := client.TxPipeline()
+	pipe.ZIncrBy(key, 1, depCity+routeSeparator+arrCity)
+	pipe.Expire(key, (popularRoutesWindow+1)*24*time.Hour)
+	_, err := pipe.Exec()
+
+	return err
+}
+
+// PopularRoutes returns the most searched routes over the last week,
+// most popular first.
+func PopularRoutes(client *redis.Client, limit int) ([]Route, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, 0, popularRoutesWindow)
+	for i := 0; i < popularRoutesWindow; i++ {
+		keys = append(keys, popularRoutesKeyPrefix+time.Now().AddDate(0, 0, -i).Format("2006-01-02"))
+	}
+
+	unionKey := popularRoutesKeyPrefix + "union"
+	pipe := client.TxPipeline()
+	pipe.ZUnionStore(unionKey, redis.ZStore{}, keys...)
+	members := pipe.ZRevRange(unionKey, 0, int64(limit-1))
+	pipe.Del(unionKey)
+	if _, err := pipe.Exec(); err != nil {
+		return nil, err
+	}
+
+	routes := make([]Route, 0, len(members.Val()))
+	for _, member := range members.Val() {
+		parts := strings.SplitN(member, routeSeparator, 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		routes = append(routes, Route{DepartureCity: parts[0], ArrivalCity: parts[1]})
+	}
+
+	return routes, nil
+}
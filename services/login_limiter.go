@@ -0,0 +1,190 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+var ErrTooManyAttempts = errors.New("too many attempts, try again later")
+
+// LoginLimiter tracks failed logins per account and per IP in Redis. After
+// FreeFailures failures an account has to wait BaseDelay, doubling with every
+// further failure up to MaxDelay; after MaxAccountFailures failures it is locked
+// for LockoutDuration. An IP is locked after MaxIPFailures. Failures are counted
+// for any email, registered or not, so a lockout tells nothing about accounts.
+// Zero limits are not enforced.
+type LoginLimiter struct {
+	Redis *redis.Client
+
+	// Window is how long failures are remembered after the first one.
+	Window             time.Duration
+	FreeFailures       int
+	BaseDelay          time.Duration
+	MaxDelay           time.Duration
+	MaxAccountFailures int
+	MaxIPFailures      int
+	LockoutDuration    time.Duration
+	// MaxRegistrations is how many registrations an IP can try per Window.
+	MaxRegistrations int
+}
+
+func loginFailuresKey(kind, id string) string {
+	return "login-failures-" + kind + "-" + id
+}
+
+func loginDelayKey(account string) string {
+	return "login-delay-account-" + account
+}
+
+func loginLockKey(kind, id string) string {
+	return "login-lock-" + kind + "-" + id
+}
+
+func registerAttemptsKey(ip string) string {
+	return "register-attempts-ip-" + ip
+}
+
+func loginAccount(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Check returns ErrTooManyAttempts and how long to wait when the account or
+// the IP can not try to log in yet.
+func (l *LoginLimiter) Check(email, ip string) (time.Duration, error) {
+	account := loginAccount(email)
+
+	var wait time.Duration
+	for _, key := range []string{loginLockKey("account", account), loginLockKey("ip", ip), loginDelayKey(account)} {
+		ttl, err := l.Redis.PTTL(key).Result()
+		if err != nil {
+			return 0, err
+		}
+
+		if ttl > wait {
+			wait = ttl
+		}
+	}
+
+	if wait > 0 {
+		return wait, ErrTooManyAttempts
+	}
+
+	return 0, nil
+}
+
+// Failure records a failed login, delaying or locking the account and the IP
+// when they reach their limits.
+func (l *LoginLimiter) Failure(email, ip string) error {
+	account := loginAccount(email)
+
+	failures, err := l.count(loginFailuresKey("account", account))
+	if err != nil {
+		return err
+	}
+
+	if l.MaxAccountFailures > 0 && failures >= int64(l.MaxAccountFailures) {
+		if err := l.lock(loginLockKey("account", account), loginFailuresKey("account", account)); err != nil {
+			return err
+		}
+	} else if l.FreeFailures > 0 && failures > int64(l.FreeFailures) {
+		if err := l.Redis.Set(loginDelayKey(account), 1, l.delay(failures)).Err(); err != nil {
+			return err
+		}
+	}
+
+	failures, err = l.count(loginFailuresKey("ip", ip))
+	if err != nil {
+		return err
+	}
+
+	if l.MaxIPFailures > 0 && failures >= int64(l.MaxIPFailures) {
+		return l.lock(loginLockKey("ip", ip), loginFailuresKey("ip", ip))
+	}
+
+	return nil
+}
+
+// Success forgets the failures of the account. Failures of the IP are kept,
+// so logging in to an own account does not reset them.
+func (l *LoginLimiter) Success(email string) error {
+	account := loginAccount(email)
+	return l.Redis.Del(loginFailuresKey("account", account), loginDelayKey(account)).Err()
+}
+
+// UnlockAccount lifts the lockout and delay of the account.
+func (l *LoginLimiter) UnlockAccount(email string) error {
+	account := loginAccount(email)
+	return l.Redis.Del(loginFailuresKey("account", account), loginDelayKey(account), loginLockKey("account", account)).Err()
+}
+
+// UnlockIP lifts the lockout of the IP.
+func (l *LoginLimiter) UnlockIP(ip string) error {
+	return l.Redis.Del(loginFailuresKey("ip", ip), loginLockKey("ip", ip)).Err()
+}
+
+// Registration counts a registration attempt of the IP and returns
+// ErrTooManyAttempts and how long to wait once it is over MaxRegistrations.
+func (l *LoginLimiter) Registration(ip string) (time.Duration, error) {
+	if l.MaxRegistrations <= 0 {
+		return 0, nil
+	}
+
+	attempts, err := l.count(registerAttemptsKey(ip))
+	if err != nil {
+		return 0, err
+	}
+
+	if attempts <= int64(l.MaxRegistrations) {
+		return 0, nil
+	}
+
+	ttl, err := l.Redis.PTTL(registerAttemptsKey(ip)).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	return ttl, ErrTooManyAttempts
+}
+
+// count increments the counter at key, starting its window on the first hit.
+func (l *LoginLimiter) count(key string) (int64, error) {
+	count, err := l.Redis.Incr(key).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	if count == 1 {
+		if err := l.Redis.Expire(key, l.Window).Err(); err != nil {
+			return 0, err
+		}
+	}
+
+	return count, nil
+}
+
+func (l *LoginLimiter) lock(lockKey, failuresKey string) error {
+	pipe := l.Redis.TxPipeline()
+	pipe.Set(lockKey, 1, l.LockoutDuration)
+	pipe.Del(failuresKey)
+	_, err := pipe.Exec()
+	return err
+}
+
+func (l *LoginLimiter) delay(failures int64) time.Duration {
+	delay := l.BaseDelay
+	for i := int64(l.FreeFailures) + 1; i < failures; i++ {
+		delay *= 2
+		if l.MaxDelay > 0 && delay >= l.MaxDelay {
+			return l.MaxDelay
+		}
+	}
+
+	if l.MaxDelay > 0 && delay > l.MaxDelay {
+		return l.MaxDelay
+	}
+
+	return delay
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"aliagha/database"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/suite"
+)
+
+type LoginLimiterTestSuite struct {
+	suite.Suite
+	redisServer *miniredis.Miniredis
+	limiter     *LoginLimiter
+}
+
+func (suite *LoginLimiterTestSuite) SetupSuite() {
+	server, client := database.NewRedisMock()
+	suite.redisServer = server
+	suite.limiter = &LoginLimiter{
+		Redis:              client,
+		Window:             15 * time.Minute,
+		FreeFailures:       2,
+		BaseDelay:          time.Second,
+		MaxDelay:           4 * time.Second,
+		MaxAccountFailures: 8,
+		MaxIPFailures:      10,
+		LockoutDuration:    15 * time.Minute,
+		MaxRegistrations:   2,
+	}
+}
+
+func (suite *LoginLimiterTestSuite) SetupTest() {
+	suite.redisServer.FlushAll()
+}
+
+func (suite *LoginLimiterTestSuite) TearDownSuite() {
+	suite.redisServer.Close()
+}
+
+func (suite *LoginLimiterTestSuite) fail(email, ip string, times int) {
+	for i := 0; i < times; i++ {
+		suite.Require().NoError(suite.limiter.Failure(email, ip))
+	}
+}
+
+func (suite *LoginLimiterTestSuite) TestCheck_ProgressiveDelay() {
+	require := suite.Require()
+
+	suite.fail("user@example.com", "1.1.1.1", 2)
+	_, err := suite.limiter.Check("user@example.com", "1.1.1.1")
+	require.NoError(err)
+
+	for _, expected := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		suite.fail("user@example.com", "1.1.1.1", 1)
+		wait, err := suite.limiter.Check("User@Example.com", "2.2.2.2")
+		require.ErrorIs(err, ErrTooManyAttempts)
+		require.Equal(expected, wait)
+
+		suite.redisServer.FastForward(expected)
+		_, err = suite.limiter.Check("user@example.com", "1.1.1.1")
+		require.NoError(err)
+	}
+}
+
+func (suite *LoginLimiterTestSuite) TestCheck_AccountLockout() {
+	require := suite.Require()
+
+	suite.fail("user@example.com", "1.1.1.1", 8)
+	wait, err := suite.limiter.Check("user@example.com", "2.2.2.2")
+	require.ErrorIs(err, ErrTooManyAttempts)
+	require.Equal(15*time.Minute, wait)
+
+	_, err = suite.limiter.Check("other@example.com", "2.2.2.2")
+	require.NoError(err)
+
+	require.NoError(suite.limiter.UnlockAccount("user@example.com"))
+	_, err = suite.limiter.Check("user@example.com", "2.2.2.2")
+	require.NoError(err)
+}
+
+func (suite *LoginLimiterTestSuite) TestCheck_IPLockout() {
+	require := suite.Require()
+
+	for i := 0; i < 10; i++ {
+		suite.fail(string(rune('a'+i))+"@example.com", "1.1.1.1", 1)
+	}
+
+	_, err := suite.limiter.Check("new@example.com", "1.1.1.1")
+	require.ErrorIs(err, ErrTooManyAttempts)
+
+	_, err = suite.limiter.Check("new@example.com", "2.2.2.2")
+	require.NoError(err)
+
+	require.NoError(suite.limiter.UnlockIP("1.1.1.1"))
+	_, err = suite.limiter.Check("new@example.com", "1.1.1.1")
+	require.NoError(err)
+}
+
+func (suite *LoginLimiterTestSuite) TestSuccess_ResetsAccountFailures() {
+	require := suite.Require()
+
+	suite.fail("user@example.com", "1.1.1.1", 3)
+	require.NoError(suite.limiter.Success("user@example.com"))
+
+	_, err := suite.limiter.Check("user@example.com", "1.1.1.1")
+	require.NoError(err)
+
+	suite.fail("user@example.com", "1.1.1.1", 2)
+	_, err = suite.limiter.Check("user@example.com", "1.1.1.1")
+	require.NoError(err)
+}
+
+func (suite *LoginLimiterTestSuite) TestFailures_ExpireAfterWindow() {
+	require := suite.Require()
+
+	suite.fail("user@example.com", "1.1.1.1", 2)
+	suite.redisServer.FastForward(15 * time.Minute)
+	suite.fail("user@example.com", "1.1.1.1", 1)
+
+	_, err := suite.limiter.Check("user@example.com", "1.1.1.1")
+	require.NoError(err)
+}
+
+func (suite *LoginLimiterTestSuite) TestRegistration_Limit() {
+	require := suite.Require()
+
+	for i := 0; i < 2; i++ {
+		_, err := suite.limiter.Registration("1.1.1.1")
+		require.NoError(err)
+	}
+
+	wait, err := suite.limiter.Registration("1.1.1.1")
+	require.ErrorIs(err, ErrTooManyAttempts)
+	require.Equal(15*time.Minute, wait)
+
+	_, err = suite.limiter.Registration("2.2.2.2")
+	require.NoError(err)
+}
+
+func TestLoginLimiter(t *testing.T) {
+	suite.Run(t, new(LoginLimiterTestSuite))
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"net/smtp"
+	"strings"
+)
+
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// LogMailer writes emails to the application log instead of sending them,
+// which is enough to follow the flows on a development machine.
+type LogMailer struct{}
+
+func (m *LogMailer) Send(to, subject, body string) error {
+	log.Printf("mailer: to: %s, subject: %s\n%s", to, subject, body)
+	return nil
+}
+
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+func (m *SMTPMailer) Send(to, subject, body string) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	msg := strings.Join([]string{
+		"From: " + m.From,
+		"To: " + to,
+		"Subject: " + subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+	}, "\r\n")
+
+	err := smtp.SendMail(fmt.Sprintf("%s:%d", m.Host, m.Port), auth, m.From, []string{to}, []byte(msg))
+	if err != nil {
+		return fmt.Errorf("smtp_mailer: send failed, error: %w", err)
+	}
+
+	return nil
+}
+
+type Notification struct {
+	UserID  int32
+	Email   string
+	Subject string
+	Message string
+}
+
+type Notifier interface {
+	Notify(n Notification) error
+}
+
+type LogNotifier struct{}
+
+func (n *LogNotifier) Notify(notification Notification) error {
+	log.Printf("notifier: user: %d, subject: %s, message: %s", notification.UserID, notification.Subject, notification.Message)
+	return nil
+}
+
+type EmailNotifier struct {
+	Mailer Mailer
+}
+
+func (n *EmailNotifier) Notify(notification Notification) error {
+	if notification.Email == "" {
+		return fmt.Errorf("email_notifier: user %d has no email address", notification.UserID)
+	}
+
+	return n.Mailer.Send(notification.Email, notification.Subject, notification.Message)
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+var (
+	ErrOTPResendTooSoon    = errors.New("a code was sent recently")
+	ErrOTPSendLimit        = errors.New("too many codes were sent to this cellphone")
+	ErrInvalidOTP          = errors.New("invalid or expired code")
+	ErrOTPAttemptsExceeded = errors.New("too many wrong codes, request a new one")
+)
+
+// OTPStore sends one time codes by SMS and verifies them; they log users in
+// and verify cellphones. Codes are stored in Redis as an HMAC of the cellphone
+// and code, so a dump of Redis does not reveal them.
+type OTPStore struct {
+	Redis  *redis.Client
+	Sender SMSSender
+	Secret string
+
+	Length int
+	TTL    time.Duration
+	// MaxAttempts is how many times a code can be tried before it is dropped.
+	MaxAttempts int
+	// ResendInterval is the minimum time between two codes for a cellphone,
+	// and MaxSends the number of codes it can get per SendWindow.
+	ResendInterval time.Duration
+	MaxSends       int
+	SendWindow     time.Duration
+}
+
+func otpKey(cellphone string) string {
+	return "otp-" + cellphone
+}
+
+func otpCooldownKey(cellphone string) string {
+	return "otp-cooldown-" + cellphone
+}
+
+func otpSendsKey(cellphone string) string {
+	return "otp-sends-" + cellphone
+}
+
+func (s *OTPStore) hash(cellphone, code string) string {
+	mac := hmac.New(sha256.New, []byte(s.Secret))
+	mac.Write([]byte(cellphone + ":" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Request sends a new code to the cellphone, replacing the previous one.
+func (s *OTPStore) Request(cellphone string) error {
+	ok, err := s.Redis.SetNX(otpCooldownKey(cellphone), 1, s.ResendInterval).Result()
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return ErrOTPResendTooSoon
+	}
+
+	sends, err := s.Redis.Incr(otpSendsKey(cellphone)).Result()
+	if err != nil {
+		return err
+	}
+
+	if sends == 1 {
+		if err := s.Redis.Expire(otpSendsKey(cellphone), s.SendWindow).Err(); err != nil {
+			return err
+		}
+	}
+
+	if sends > int64(s.MaxSends) {
+		return ErrOTPSendLimit
+	}
+
+	code, err := randomDigits(s.Length)
+	if err != nil {
+		return err
+	}
+
+	key := otpKey(cellphone)
+	pipe := s.Redis.TxPipeline()
+	pipe.Del(key)
+	pipe.HSet(key, "hash", s.hash(cellphone, code))
+	pipe.Expire(key, s.TTL)
+	if _, err := pipe.Exec(); err != nil {
+		return err
+	}
+
+	if err := s.Sender.Send(cellphone, fmt.Sprintf("Your Aliagha code: %s", code)); err != nil {
+		// Let the user ask again right away; the send still counts.
+		s.Redis.Del(key, otpCooldownKey(cellphone))
+		return err
+	}
+
+	return nil
+}
+
+// Verify checks the code sent to the cellphone. A verified code can not be
+// used again.
+func (s *OTPStore) Verify(cellphone, code string) error {
+	key := otpKey(cellphone)
+
+	attempts, err := s.Redis.HIncrBy(key, "attempts", 1).Result()
+	if err != nil {
+		return err
+	}
+
+	hash, err := s.Redis.HGet(key, "hash").Result()
+	if err == redis.Nil {
+		// No code was requested or it expired; drop what HIncrBy created.
+		if err := s.Redis.Del(key).Err(); err != nil {
+			return err
+		}
+
+		return ErrInvalidOTP
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if attempts > int64(s.MaxAttempts) {
+		if err := s.Redis.Del(key).Err(); err != nil {
+			return err
+		}
+
+		return ErrOTPAttemptsExceeded
+	}
+
+	if !hmac.Equal([]byte(hash), []byte(s.hash(cellphone, code))) {
+		return ErrInvalidOTP
+	}
+
+	return s.Redis.Del(key).Err()
+}
+
+func randomDigits(n int) (string, error) {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+	v, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%0*d", n, v.Int64()), nil
+}
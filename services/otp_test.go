@@ -0,0 +1,124 @@
+package services
+
+import (
+	"aliagha/database"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/suite"
+)
+
+type recordingSMSSender struct {
+	messages []string
+}
+
+func (s *recordingSMSSender) Send(to, message string) error {
+	s.messages = append(s.messages, to+": "+message)
+	return nil
+}
+
+type OTPStoreTestSuite struct {
+	suite.Suite
+	redisServer *miniredis.Miniredis
+	sender      *recordingSMSSender
+	otp         *OTPStore
+}
+
+func (suite *OTPStoreTestSuite) SetupSuite() {
+	server, client := database.NewRedisMock()
+	suite.redisServer = server
+	suite.otp = &OTPStore{
+		Redis:          client,
+		Secret:         "secret",
+		Length:         6,
+		TTL:            2 * time.Minute,
+		MaxAttempts:    3,
+		ResendInterval: time.Minute,
+		MaxSends:       2,
+		SendWindow:     time.Hour,
+	}
+}
+
+func (suite *OTPStoreTestSuite) SetupTest() {
+	suite.redisServer.FlushAll()
+	suite.sender = &recordingSMSSender{}
+	suite.otp.Sender = suite.sender
+}
+
+func (suite *OTPStoreTestSuite) TearDownSuite() {
+	suite.redisServer.Close()
+}
+
+// lastCode returns the code of the last sent message.
+func (suite *OTPStoreTestSuite) lastCode() string {
+	suite.Require().NotEmpty(suite.sender.messages)
+	return regexp.MustCompile(`\d{6}$`).FindString(suite.sender.messages[len(suite.sender.messages)-1])
+}
+
+func (suite *OTPStoreTestSuite) TestVerify_Success() {
+	require := suite.Require()
+
+	require.NoError(suite.otp.Request("09121234567"))
+	code := suite.lastCode()
+	require.Len(code, 6)
+
+	hash := suite.redisServer.HGet(otpKey("09121234567"), "hash")
+	require.Len(hash, 64)
+	require.NotContains(hash, code)
+
+	require.ErrorIs(suite.otp.Verify("09120000000", code), ErrInvalidOTP)
+	require.NoError(suite.otp.Verify("09121234567", code))
+	require.ErrorIs(suite.otp.Verify("09121234567", code), ErrInvalidOTP, "codes are single use")
+}
+
+func (suite *OTPStoreTestSuite) TestVerify_Expired_Failure() {
+	require := suite.Require()
+
+	require.NoError(suite.otp.Request("09121234567"))
+	suite.redisServer.FastForward(3 * time.Minute)
+
+	require.ErrorIs(suite.otp.Verify("09121234567", suite.lastCode()), ErrInvalidOTP)
+}
+
+func (suite *OTPStoreTestSuite) TestVerify_TooManyAttempts_Failure() {
+	require := suite.Require()
+
+	require.NoError(suite.otp.Request("09121234567"))
+	code := suite.lastCode()
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+
+	for i := 0; i < 3; i++ {
+		require.ErrorIs(suite.otp.Verify("09121234567", wrong), ErrInvalidOTP)
+	}
+
+	require.ErrorIs(suite.otp.Verify("09121234567", code), ErrOTPAttemptsExceeded)
+	require.ErrorIs(suite.otp.Verify("09121234567", code), ErrInvalidOTP)
+}
+
+func (suite *OTPStoreTestSuite) TestRequest_Limits_Failure() {
+	require := suite.Require()
+
+	require.NoError(suite.otp.Request("09121234567"))
+	require.ErrorIs(suite.otp.Request("09121234567"), ErrOTPResendTooSoon)
+
+	suite.redisServer.FastForward(time.Minute)
+	require.NoError(suite.otp.Request("09121234567"))
+	first := suite.lastCode()
+
+	suite.redisServer.FastForward(time.Minute)
+	require.ErrorIs(suite.otp.Request("09121234567"), ErrOTPSendLimit)
+	require.Len(suite.sender.messages, 2)
+	require.NoError(suite.otp.Verify("09121234567", first), "a refused request keeps the last code")
+
+	suite.redisServer.FastForward(time.Hour)
+	require.NoError(suite.otp.Request("09121234567"))
+}
+
+func TestOTPStore(t *testing.T) {
+	suite.Run(t, new(OTPStoreTestSuite))
+}
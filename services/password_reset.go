@@ -0,0 +1,60 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+var ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+
+// PasswordResets mails single use password reset links. A user has at most
+// one valid link: asking for a new one drops the previous one.
+type PasswordResets struct {
+	Redis  *redis.Client
+	Mailer Mailer
+	TTL    time.Duration
+	// URL is the page of the frontend that resets the password; the token is
+	// added to it as the token query parameter.
+	URL string
+}
+
+func (p *PasswordResets) tokens() singleUseTokens {
+	return singleUseTokens{redis: p.Redis, kind: "password-reset", ttl: p.TTL}
+}
+
+// Send mails a new reset link to the user.
+func (p *PasswordResets) Send(userID int32, email string) error {
+	token, err := p.tokens().issue(userID, email)
+	if err != nil {
+		return err
+	}
+
+	link, err := tokenLink(p.URL, token)
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Someone asked to reset the password of your Aliagha account.\n\n"+
+		"Open this link within %s to choose a new password:\n%s\n\n"+
+		"If it was not you, ignore this email; your password stays the same.", p.TTL, link)
+
+	return p.Mailer.Send(email, "Reset your Aliagha password", body)
+}
+
+// Consume returns the user of the token and invalidates it.
+func (p *PasswordResets) Consume(token string) (int32, error) {
+	userID, _, err := p.tokens().consume(token)
+	if err == errUnknownToken {
+		return 0, ErrInvalidResetToken
+	}
+
+	return userID, err
+}
+
+// Revoke drops the reset link of the user, if there is one.
+func (p *PasswordResets) Revoke(userID int32) error {
+	return p.tokens().revoke(userID)
+}
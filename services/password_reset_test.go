@@ -0,0 +1,115 @@
+package services
+
+import (
+	"aliagha/database"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/suite"
+)
+
+type recordingMailer struct {
+	to, bodies []string
+}
+
+func (m *recordingMailer) Send(to, subject, body string) error {
+	m.to = append(m.to, to)
+	m.bodies = append(m.bodies, body)
+	return nil
+}
+
+type PasswordResetsTestSuite struct {
+	suite.Suite
+	redisServer *miniredis.Miniredis
+	mailer      *recordingMailer
+	resets      *PasswordResets
+}
+
+func (suite *PasswordResetsTestSuite) SetupSuite() {
+	server, client := database.NewRedisMock()
+	suite.redisServer = server
+	suite.resets = &PasswordResets{Redis: client, TTL: 30 * time.Minute, URL: "https://aliagha.test/reset-password?lang=fa"}
+}
+
+func (suite *PasswordResetsTestSuite) SetupTest() {
+	suite.redisServer.FlushAll()
+	suite.mailer = &recordingMailer{}
+	suite.resets.Mailer = suite.mailer
+}
+
+func (suite *PasswordResetsTestSuite) TearDownSuite() {
+	suite.redisServer.Close()
+}
+
+// lastToken returns the token of the last mailed link.
+func (suite *PasswordResetsTestSuite) lastToken() string {
+	require := suite.Require()
+	require.NotEmpty(suite.mailer.bodies)
+
+	link, err := url.Parse(regexp.MustCompile(`https://\S+`).FindString(suite.mailer.bodies[len(suite.mailer.bodies)-1]))
+	require.NoError(err)
+	require.Equal("fa", link.Query().Get("lang"))
+
+	return link.Query().Get("token")
+}
+
+func (suite *PasswordResetsTestSuite) TestConsume_Success() {
+	require := suite.Require()
+
+	require.NoError(suite.resets.Send(7, "user@example.com"))
+	require.Equal([]string{"user@example.com"}, suite.mailer.to)
+	token := suite.lastToken()
+
+	userID, err := suite.resets.Consume(token)
+	require.NoError(err)
+	require.Equal(int32(7), userID)
+
+	_, err = suite.resets.Consume(token)
+	require.ErrorIs(err, ErrInvalidResetToken, "tokens are single use")
+	require.Empty(suite.redisServer.Keys())
+}
+
+func (suite *PasswordResetsTestSuite) TestConsume_Replaced_Failure() {
+	require := suite.Require()
+
+	require.NoError(suite.resets.Send(7, "user@example.com"))
+	first := suite.lastToken()
+	require.NoError(suite.resets.Send(7, "user@example.com"))
+	second := suite.lastToken()
+
+	_, err := suite.resets.Consume(first)
+	require.ErrorIs(err, ErrInvalidResetToken)
+
+	userID, err := suite.resets.Consume(second)
+	require.NoError(err)
+	require.Equal(int32(7), userID)
+}
+
+func (suite *PasswordResetsTestSuite) TestConsume_Expired_Failure() {
+	require := suite.Require()
+
+	require.NoError(suite.resets.Send(7, "user@example.com"))
+	suite.redisServer.FastForward(31 * time.Minute)
+
+	_, err := suite.resets.Consume(suite.lastToken())
+	require.ErrorIs(err, ErrInvalidResetToken)
+}
+
+func (suite *PasswordResetsTestSuite) TestConsume_Revoked_Failure() {
+	require := suite.Require()
+
+	require.NoError(suite.resets.Revoke(7), "revoking without a link is a no-op")
+	require.NoError(suite.resets.Send(7, "user@example.com"))
+	require.NoError(suite.resets.Revoke(7))
+
+	_, err := suite.resets.Consume(suite.lastToken())
+	require.ErrorIs(err, ErrInvalidResetToken)
+	require.Empty(suite.redisServer.Keys())
+}
+
+func TestPasswordResets(t *testing.T) {
+	suite.Run(t, new(PasswordResetsTestSuite))
+}
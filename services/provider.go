@@ -0,0 +1,39 @@
+package services
+
+// FlightProvider is a source of flights that can also book and release seats.
+// APIMockClient is one implementation and Aggregator combines several.
+type FlightProvider interface {
+	GetFlights(depCity, arrCity, date string) ([]FlightResponse, error)
+	GetFlightInfo(flightId int32) (FlightInfoResponse, error)
+	Reserve(flightId, cnt int32) error
+	Cancel(flightId, cnt int32) error
+}
+
+// ProviderRouter is a FlightProvider combining several others. Flight ids are
+// only unique within a provider, so calls about a flight go to the provider
+// named in the search results.
+type ProviderRouter interface {
+	Provider(name string) (FlightProvider, error)
+	Name(name string) (string, error)
+}
+
+// ProviderFor returns the provider with the name when p combines several, and
+// p itself otherwise or when no name is given.
+func ProviderFor(p FlightProvider, name string) (FlightProvider, error) {
+	if router, ok := p.(ProviderRouter); ok && name != "" {
+		return router.Provider(name)
+	}
+
+	return p, nil
+}
+
+// ProviderName returns the name the flights of the provider with the name are
+// tagged with. A single provider is used when no name is given, and flights of
+// a provider that does not combine others are not tagged.
+func ProviderName(p FlightProvider, name string) (string, error) {
+	if router, ok := p.(ProviderRouter); ok {
+		return router.Name(name)
+	}
+
+	return "", nil
+}
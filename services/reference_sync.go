@@ -0,0 +1,207 @@
+package services
+
+import (
+	"aliagha/models"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ReferenceSource provides the reference data flights point to.
+type ReferenceSource interface {
+	GetCities() ([]GetCityResponse, error)
+	GetAirplanes() ([]GetAirplaneResponse, error)
+	GetCancelingSituations() ([]GetCancelingSituationResponse, error)
+}
+
+type SyncItem struct {
+	ID   int32
+	Name string
+}
+
+// SyncChanges lists what a sync did to one table. Removed items are only
+// reported, since flights and tickets may still reference them. Conflicts are
+// upstream items left alone because a local row with another id already uses
+// their name.
+type SyncChanges struct {
+	Added     []SyncItem
+	Changed   []SyncItem
+	Removed   []SyncItem
+	Conflicts []SyncItem
+}
+
+type SyncReport struct {
+	Cities              SyncChanges
+	Airplanes           SyncChanges
+	CancelingSituations SyncChanges
+}
+
+func (c SyncChanges) String() string {
+	return fmt.Sprintf("%d added, %d changed, %d removed upstream, %d conflicts",
+		len(c.Added), len(c.Changed), len(c.Removed), len(c.Conflicts))
+}
+
+func (r SyncReport) String() string {
+	return fmt.Sprintf("cities: %s; airplanes: %s; canceling situations: %s", r.Cities, r.Airplanes, r.CancelingSituations)
+}
+
+// syncRecord is the part of a reference row compared with upstream: the id
+// upstream uses, a name unique per table if unique is set, and the columns
+// synced from upstream.
+type syncRecord struct {
+	ID      int32
+	Name    string
+	Columns map[string]interface{}
+}
+
+// SyncReference upserts cities, airplanes and canceling situations from
+// upstream by id. With dryRun the report is computed but nothing is written.
+func SyncReference(db *gorm.DB, source ReferenceSource, dryRun bool) (SyncReport, error) {
+	cities, err := source.GetCities()
+	if err != nil {
+		return SyncReport{}, err
+	}
+
+	airplanes, err := source.GetAirplanes()
+	if err != nil {
+		return SyncReport{}, err
+	}
+
+	situations, err := source.GetCancelingSituations()
+	if err != nil {
+		return SyncReport{}, err
+	}
+
+	var report SyncReport
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var localCities []models.City
+		if err := tx.Model(&models.City{}).Find(&localCities).Error; err != nil {
+			return err
+		}
+
+		upstream := make([]syncRecord, 0, len(cities))
+		for _, city := range cities {
+			upstream = append(upstream, syncRecord{ID: city.ID, Name: city.Name, Columns: map[string]interface{}{"name": city.Name}})
+		}
+
+		local := make([]syncRecord, 0, len(localCities))
+		for _, city := range localCities {
+			local = append(local, syncRecord{ID: city.ID, Name: city.Name, Columns: map[string]interface{}{"name": city.Name}})
+		}
+
+		if report.Cities, err = syncTable(tx, &models.City{}, upstream, local, true, dryRun); err != nil {
+			return err
+		}
+
+		var localAirplanes []models.Airplane
+		if err := tx.Model(&models.Airplane{}).Find(&localAirplanes).Error; err != nil {
+			return err
+		}
+
+		upstream = make([]syncRecord, 0, len(airplanes))
+		for _, airplane := range airplanes {
+			upstream = append(upstream, syncRecord{ID: airplane.ID, Name: airplane.Name, Columns: map[string]interface{}{"name": airplane.Name}})
+		}
+
+		local = make([]syncRecord, 0, len(localAirplanes))
+		for _, airplane := range localAirplanes {
+			local = append(local, syncRecord{ID: airplane.ID, Name: airplane.Name, Columns: map[string]interface{}{"name": airplane.Name}})
+		}
+
+		if report.Airplanes, err = syncTable(tx, &models.Airplane{}, upstream, local, true, dryRun); err != nil {
+			return err
+		}
+
+		var localSituations []models.CancelingSituation
+		if err := tx.Model(&models.CancelingSituation{}).Find(&localSituations).Error; err != nil {
+			return err
+		}
+
+		upstream = make([]syncRecord, 0, len(situations))
+		for _, situation := range situations {
+			upstream = append(upstream, syncRecord{ID: situation.ID, Name: situation.Description,
+				Columns: map[string]interface{}{"description": situation.Description, "data": situation.Data}})
+		}
+
+		local = make([]syncRecord, 0, len(localSituations))
+		for _, situation := range localSituations {
+			local = append(local, syncRecord{ID: situation.ID, Name: situation.Description,
+				Columns: map[string]interface{}{"description": situation.Description, "data": situation.Data}})
+		}
+
+		report.CancelingSituations, err = syncTable(tx, &models.CancelingSituation{}, upstream, local, false, dryRun)
+		return err
+	})
+
+	return report, err
+}
+
+func syncTable(tx *gorm.DB, model interface{}, upstream, local []syncRecord, unique, dryRun bool) (SyncChanges, error) {
+	var changes SyncChanges
+
+	localByID := make(map[int32]syncRecord, len(local))
+	localByName := make(map[string]syncRecord, len(local))
+	for _, record := range local {
+		localByID[record.ID] = record
+		localByName[strings.ToLower(record.Name)] = record
+	}
+
+	seen := make(map[int32]bool, len(upstream))
+	for _, record := range upstream {
+		seen[record.ID] = true
+		item := SyncItem{ID: record.ID, Name: record.Name}
+
+		if other, taken := localByName[strings.ToLower(record.Name)]; unique && taken && other.ID != record.ID {
+			changes.Conflicts = append(changes.Conflicts, item)
+			continue
+		}
+
+		existing, ok := localByID[record.ID]
+		if !ok {
+			if !dryRun {
+				columns := map[string]interface{}{"id": record.ID}
+				for column, value := range record.Columns {
+					columns[column] = value
+				}
+
+				if err := tx.Model(model).Create(columns).Error; err != nil {
+					return SyncChanges{}, err
+				}
+			}
+
+			changes.Added = append(changes.Added, item)
+			continue
+		}
+
+		if sameColumns(existing.Columns, record.Columns) {
+			continue
+		}
+
+		if !dryRun {
+			if err := tx.Model(model).Where("id = ?", record.ID).Updates(record.Columns).Error; err != nil {
+				return SyncChanges{}, err
+			}
+		}
+
+		changes.Changed = append(changes.Changed, item)
+	}
+
+	for _, record := range local {
+		if !seen[record.ID] {
+			changes.Removed = append(changes.Removed, SyncItem{ID: record.ID, Name: record.Name})
+		}
+	}
+
+	return changes, nil
+}
+
+func sameColumns(a, b map[string]interface{}) bool {
+	for column, value := range b {
+		if a[column] != value {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+type fakeReferenceSource struct{}
+
+func (fakeReferenceSource) GetCities() ([]GetCityResponse, error) {
+	return []GetCityResponse{{ID: 1, Name: "Tehran"}, {ID: 2, Name: "Mashhad"}, {ID: 3, Name: "Shiraz"}, {ID: 4, Name: "Kish"}}, nil
+}
+
+func (fakeReferenceSource) GetAirplanes() ([]GetAirplaneResponse, error) {
+	return []GetAirplaneResponse{{ID: 1, Name: "Boeing737"}}, nil
+}
+
+func (fakeReferenceSource) GetCancelingSituations() ([]GetCancelingSituationResponse, error) {
+	return []GetCancelingSituationResponse{{ID: 1, Description: "Standard", Data: "72:0,3:50"}}, nil
+}
+
+type ReferenceSyncTestSuite struct {
+	suite.Suite
+	db      *gorm.DB
+	sqlMock sqlmock.Sqlmock
+}
+
+func (suite *ReferenceSyncTestSuite) SetupTest() {
+	mockDB, sqlMock, err := sqlmock.New()
+	suite.Require().NoError(err)
+
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: mockDB, SkipInitializeWithVersion: true}))
+	suite.Require().NoError(err)
+
+	suite.db = db
+	suite.sqlMock = sqlMock
+}
+
+func (suite *ReferenceSyncTestSuite) expectLocalRows() {
+	suite.sqlMock.ExpectQuery("^SELECT \\* FROM `cities`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+			AddRow(1, "Tehran").
+			AddRow(2, "Mashad").
+			AddRow(9, "Kish").
+			AddRow(10, "Yazd"))
+	suite.sqlMock.ExpectQuery("^SELECT \\* FROM `airplanes`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Boeing737"))
+	suite.sqlMock.ExpectQuery("^SELECT \\* FROM `canceling_situations`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "description", "data"}))
+}
+
+func (suite *ReferenceSyncTestSuite) TestSyncReference_DryRun_Success() {
+	require := suite.Require()
+
+	suite.sqlMock.ExpectBegin()
+	suite.expectLocalRows()
+	suite.sqlMock.ExpectCommit()
+
+	report, err := SyncReference(suite.db, fakeReferenceSource{}, true)
+	require.NoError(err)
+	require.Equal([]SyncItem{{ID: 3, Name: "Shiraz"}}, report.Cities.Added)
+	require.Equal([]SyncItem{{ID: 2, Name: "Mashhad"}}, report.Cities.Changed)
+	require.Equal([]SyncItem{{ID: 9, Name: "Kish"}, {ID: 10, Name: "Yazd"}}, report.Cities.Removed)
+	require.Equal([]SyncItem{{ID: 4, Name: "Kish"}}, report.Cities.Conflicts)
+	require.Empty(report.Airplanes.Added)
+	require.Empty(report.Airplanes.Changed)
+	require.Equal([]SyncItem{{ID: 1, Name: "Standard"}}, report.CancelingSituations.Added)
+	require.NoError(suite.sqlMock.ExpectationsWereMet())
+}
+
+func (suite *ReferenceSyncTestSuite) TestSyncReference_Write_Success() {
+	require := suite.Require()
+
+	suite.sqlMock.ExpectBegin()
+	suite.sqlMock.ExpectQuery("^SELECT \\* FROM `cities`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Tehran").AddRow(2, "Mashad"))
+	suite.sqlMock.ExpectExec("^UPDATE `cities` SET `name`=\\?.* WHERE id = \\?").
+		WithArgs("Mashhad", sqlmock.AnyArg(), 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.sqlMock.ExpectExec("^INSERT INTO `cities`").
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	suite.sqlMock.ExpectExec("^INSERT INTO `cities`").
+		WillReturnResult(sqlmock.NewResult(4, 1))
+	suite.sqlMock.ExpectQuery("^SELECT \\* FROM `airplanes`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Boeing737"))
+	suite.sqlMock.ExpectQuery("^SELECT \\* FROM `canceling_situations`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "description", "data"}).AddRow(1, "Standard", "72:0,3:50"))
+	suite.sqlMock.ExpectCommit()
+
+	report, err := SyncReference(suite.db, fakeReferenceSource{}, false)
+	require.NoError(err)
+	require.Len(report.Cities.Added, 2)
+	require.Len(report.Cities.Changed, 1)
+	require.Empty(report.CancelingSituations.Added)
+	require.Empty(report.CancelingSituations.Changed)
+	require.NoError(suite.sqlMock.ExpectationsWereMet())
+}
+
+func TestReferenceSync(t *testing.T) {
+	suite.Run(t, new(ReferenceSyncTestSuite))
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"aliagha/models"
+	"errors"
+	"sort"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Permissions checked by the back-office endpoints. Roles and the permissions
+// they grant live in the roles and role_permissions tables.
+const (
+	// PermissionAll grants every permission.
+	PermissionAll         = "*"
+	PermissionUsersRead   = "users:read"
+	PermissionTicketsRead = "tickets:read"
+	PermissionUsersUnlock = "users:unlock"
+)
+
+var ErrUnknownRole = errors.New("unknown role")
+
+// UserRoles returns the names of the roles of the user and the permissions
+// they grant, both sorted.
+func UserRoles(db *gorm.DB, userID int32) ([]string, []string, error) {
+	var rows []struct {
+		Name       string
+		Permission *string
+	}
+
+	err := db.Table("user_roles").
+		Select("roles.name, role_permissions.permission").
+		Joins("JOIN roles ON roles.id = user_roles.role_id").
+		Joins("LEFT JOIN role_permissions ON role_permissions.role_id = roles.id").
+		Where("user_roles.u_id = ?", userID).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	roles, permissions := make(map[string]bool), make(map[string]bool)
+	for _, row := range rows {
+		roles[row.Name] = true
+		if row.Permission != nil {
+			permissions[*row.Permission] = true
+		}
+	}
+
+	return sortedKeys(roles), sortedKeys(permissions), nil
+}
+
+// GrantRole gives the role to the user. Granting a role twice is a no-op.
+func GrantRole(db *gorm.DB, userID int32, role string) error {
+	var r models.Role
+	if err := db.Where("name = ?", role).First(&r).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return ErrUnknownRole
+		}
+
+		return err
+	}
+
+	return db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.UserRole{UID: userID, RoleID: r.ID}).Error
+}
+
+// RevokeRole takes the role away from the user.
+func RevokeRole(db *gorm.DB, userID int32, role string) error {
+	var r models.Role
+	if err := db.Where("name = ?", role).First(&r).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return ErrUnknownRole
+		}
+
+		return err
+	}
+
+	return db.Where("u_id = ? AND role_id = ?", userID, r.ID).Delete(&models.UserRole{}).Error
+}
+
+// HasPermission reports whether the granted permissions include permission.
+func HasPermission(granted []string, permission string) bool {
+	for _, p := range granted {
+		if p == permission || p == PermissionAll {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"aliagha/database"
+	"aliagha/models"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoles(t *testing.T) {
+	db := database.NewSQLiteMock()
+
+	user := models.User{Name: "Agent", Cellphone: "09121234567", Email: "agent@example.com"}
+	require.NoError(t, db.Create(&user).Error)
+
+	roles, permissions, err := UserRoles(db, user.ID)
+	require.NoError(t, err)
+	require.Empty(t, roles)
+	require.Empty(t, permissions)
+
+	require.NoError(t, GrantRole(db, user.ID, "agent"))
+	require.NoError(t, GrantRole(db, user.ID, "agent"))
+	require.ErrorIs(t, GrantRole(db, user.ID, "owner"), ErrUnknownRole)
+
+	roles, permissions, err = UserRoles(db, user.ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{"agent"}, roles)
+	require.Equal(t, []string{PermissionTicketsRead, PermissionUsersRead}, permissions)
+	require.True(t, HasPermission(permissions, PermissionUsersRead))
+	require.False(t, HasPermission(permissions, "roles:manage"))
+
+	require.NoError(t, GrantRole(db, user.ID, "admin"))
+	roles, permissions, err = UserRoles(db, user.ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{"admin", "agent"}, roles)
+	require.True(t, HasPermission(permissions, "roles:manage"))
+
+	require.NoError(t, RevokeRole(db, user.ID, "admin"))
+	require.NoError(t, RevokeRole(db, user.ID, "agent"))
+	roles, _, err = UserRoles(db, user.ID)
+	require.NoError(t, err)
+	require.Empty(t, roles)
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"aliagha/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// Sessions records a session per login, so users can see where they are
+// logged in and log other devices out.
+//
+// A session is the refresh token family its login started: it stays active as
+// long as the family does, and its access tokens carry the family in the sid
+// claim so they stop working once the session is revoked.
+type Sessions struct {
+	DB     *gorm.DB
+	Tokens *TokenStore
+}
+
+// SessionInfo describes where a login came from. Device is the name the
+// client gives itself, such as "Pixel 7".
+type SessionInfo struct {
+	Device    string
+	IP        string
+	UserAgent string
+}
+
+// Start records a new session of the user and returns it along with its first
+// refresh token. Sessions of the user that ended are deleted on the way.
+func (s *Sessions) Start(userID int32, info SessionInfo, now time.Time) (*models.Session, string, error) {
+	err := s.DB.Where("u_id = ? AND (revoked_at IS NOT NULL OR last_seen_at < ?)", userID, now.Add(-s.Tokens.RefreshTTL)).
+		Delete(&models.Session{}).Error
+	if err != nil {
+		return nil, "", err
+	}
+
+	family, token, err := s.Tokens.StartRefreshFamily(userID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	session := &models.Session{
+		UID:        userID,
+		Family:     family,
+		Device:     truncate(info.Device, 100),
+		IP:         truncate(info.IP, 45),
+		UserAgent:  truncate(info.UserAgent, 255),
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+	if err := s.DB.Create(session).Error; err != nil {
+		return nil, "", err
+	}
+
+	return session, token, nil
+}
+
+// Seen records that the session refreshed its tokens from ip.
+func (s *Sessions) Seen(family, ip string, now time.Time) error {
+	return s.DB.Model(&models.Session{}).Where("family = ?", family).
+		Updates(map[string]interface{}{"ip": truncate(ip, 45), "last_seen_at": now}).Error
+}
+
+// Active returns the active sessions of the user, the most recently seen
+// first.
+func (s *Sessions) Active(userID int32, now time.Time) ([]models.Session, error) {
+	var sessions []models.Session
+	err := s.DB.Where("u_id = ? AND revoked_at IS NULL AND last_seen_at >= ?", userID, now.Add(-s.Tokens.RefreshTTL)).
+		Order("last_seen_at DESC").Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Families also end without the session being revoked, when one of their
+	// tokens is reused or all tokens of the user are revoked.
+	active := sessions[:0]
+	for _, session := range sessions {
+		ok, err := s.Tokens.IsRefreshFamilyActive(session.Family)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			active = append(active, session)
+		}
+	}
+
+	return active, nil
+}
+
+// Revoke ends the session of the user with the id. It returns
+// ErrSessionNotFound when the user has no such session.
+func (s *Sessions) Revoke(userID, id int32, now time.Time) error {
+	var session models.Session
+	err := s.DB.Where("id = ? AND u_id = ? AND revoked_at IS NULL", id, userID).First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrSessionNotFound
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return s.revoke([]models.Session{session}, now)
+}
+
+// RevokeFamily ends the session of the family, if there is one.
+func (s *Sessions) RevokeFamily(family string, now time.Time) error {
+	var sessions []models.Session
+	if err := s.DB.Where("family = ? AND revoked_at IS NULL", family).Find(&sessions).Error; err != nil {
+		return err
+	}
+
+	if len(sessions) == 0 {
+		return s.Tokens.RevokeRefreshFamily(family)
+	}
+
+	return s.revoke(sessions, now)
+}
+
+// RevokeOthers ends every session of the user but the one of the family, and
+// returns how many were ended.
+func (s *Sessions) RevokeOthers(userID int32, family string, now time.Time) (int, error) {
+	var sessions []models.Session
+	err := s.DB.Where("u_id = ? AND family <> ? AND revoked_at IS NULL", userID, family).Find(&sessions).Error
+	if err != nil {
+		return 0, err
+	}
+
+	if len(sessions) == 0 {
+		return 0, nil
+	}
+
+	return len(sessions), s.revoke(sessions, now)
+}
+
+func (s *Sessions) revoke(sessions []models.Session, now time.Time) error {
+	ids := make([]int32, 0, len(sessions))
+	for _, session := range sessions {
+		if err := s.Tokens.RevokeRefreshFamily(session.Family); err != nil {
+			return err
+		}
+
+		ids = append(ids, session.ID)
+	}
+
+	return s.DB.Model(&models.Session{}).Where("id IN ?", ids).Update("revoked_at", now).Error
+}
+
+// truncate cuts s to size characters to fit its column.
+func truncate(s string, size int) string {
+	runes := []rune(s)
+	if len(runes) > size {
+		return string(runes[:size])
+	}
+
+	return s
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"aliagha/database"
+	"aliagha/models"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type SessionsTestSuite struct {
+	suite.Suite
+	redisServer *miniredis.Miniredis
+	db          *gorm.DB
+	sessions    *Sessions
+	user        models.User
+	now         time.Time
+}
+
+func (suite *SessionsTestSuite) SetupTest() {
+	server, client := database.NewRedisMock()
+	suite.redisServer = server
+	suite.db = database.NewSQLiteMock()
+	suite.sessions = &Sessions{DB: suite.db, Tokens: &TokenStore{Redis: client, AccessTTL: time.Hour, RefreshTTL: 24 * time.Hour}}
+	suite.now = time.Date(2023, 6, 28, 10, 0, 0, 0, time.UTC)
+
+	suite.user = models.User{Name: "Traveler", Cellphone: "09121234567", Email: "traveler@example.com"}
+	suite.Require().NoError(suite.db.Create(&suite.user).Error)
+}
+
+func (suite *SessionsTestSuite) TearDownTest() {
+	suite.redisServer.Close()
+}
+
+func (suite *SessionsTestSuite) start(device string, now time.Time) (*models.Session, string) {
+	session, token, err := suite.sessions.Start(suite.user.ID, SessionInfo{Device: device, IP: "192.0.2.1", UserAgent: "okhttp/4.9"}, now)
+	suite.Require().NoError(err)
+	return session, token
+}
+
+func (suite *SessionsTestSuite) TestStart_Success() {
+	require := suite.Require()
+
+	session, token := suite.start("Pixel 7", suite.now)
+	family, err := suite.sessions.Tokens.RefreshTokenFamily(token)
+	require.NoError(err)
+	require.Equal(family, session.Family)
+
+	var stored models.Session
+	require.NoError(suite.db.First(&stored, session.ID).Error)
+	require.Equal("Pixel 7", stored.Device)
+	require.Equal("192.0.2.1", stored.IP)
+	require.Equal("okhttp/4.9", stored.UserAgent)
+
+	require.NoError(suite.sessions.Seen(family, "198.51.100.7", suite.now.Add(time.Hour)))
+	require.NoError(suite.db.First(&stored, session.ID).Error)
+	require.Equal("198.51.100.7", stored.IP)
+	require.True(stored.LastSeenAt.Equal(suite.now.Add(time.Hour)))
+}
+
+func (suite *SessionsTestSuite) TestActive_Success() {
+	require := suite.Require()
+
+	old, _ := suite.start("Old phone", suite.now.Add(-48*time.Hour))
+	reused, token := suite.start("Laptop", suite.now.Add(-time.Hour))
+	current, _ := suite.start("Pixel 7", suite.now)
+
+	// A reused refresh token ends its session without revoking it.
+	_, _, _, err := suite.sessions.Tokens.RotateRefreshToken(token)
+	require.NoError(err)
+	_, _, _, err = suite.sessions.Tokens.RotateRefreshToken(token)
+	require.ErrorIs(err, ErrRefreshTokenReused)
+
+	sessions, err := suite.sessions.Active(suite.user.ID, suite.now)
+	require.NoError(err)
+	require.Len(sessions, 1)
+	require.Equal(current.ID, sessions[0].ID)
+
+	// Ended sessions are deleted on the next login.
+	suite.start("Tablet", suite.now)
+	var count int64
+	require.NoError(suite.db.Model(&models.Session{}).Where("id IN ?", []int32{old.ID, reused.ID}).Count(&count).Error)
+	require.Equal(int64(1), count)
+}
+
+func (suite *SessionsTestSuite) TestRevoke_Success() {
+	require := suite.Require()
+
+	session, _ := suite.start("Shared phone", suite.now)
+	require.NoError(suite.sessions.Revoke(suite.user.ID, session.ID, suite.now))
+
+	active, err := suite.sessions.Tokens.IsRefreshFamilyActive(session.Family)
+	require.NoError(err)
+	require.False(active)
+
+	require.ErrorIs(suite.sessions.Revoke(suite.user.ID, session.ID, suite.now), ErrSessionNotFound)
+
+	other := models.User{Name: "Other", Cellphone: "09127654321", Email: "other@example.com"}
+	require.NoError(suite.db.Create(&other).Error)
+	require.ErrorIs(suite.sessions.Revoke(other.ID, session.ID, suite.now), ErrSessionNotFound)
+}
+
+func (suite *SessionsTestSuite) TestRevokeOthers_Success() {
+	require := suite.Require()
+
+	current, _ := suite.start("Pixel 7", suite.now)
+	shared, _ := suite.start("Shared phone", suite.now)
+	laptop, _ := suite.start("Laptop", suite.now)
+
+	revoked, err := suite.sessions.RevokeOthers(suite.user.ID, current.Family, suite.now)
+	require.NoError(err)
+	require.Equal(2, revoked)
+
+	sessions, err := suite.sessions.Active(suite.user.ID, suite.now)
+	require.NoError(err)
+	require.Len(sessions, 1)
+	require.Equal(current.ID, sessions[0].ID)
+
+	for _, session := range []*models.Session{shared, laptop} {
+		active, err := suite.sessions.Tokens.IsRefreshFamilyActive(session.Family)
+		require.NoError(err)
+		require.False(active)
+	}
+}
+
+func TestSessions(t *testing.T) {
+	suite.Run(t, new(SessionsTestSuite))
+}
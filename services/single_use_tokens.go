@@ -0,0 +1,121 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+var errUnknownToken = errors.New("unknown token")
+
+// singleUseTokens stores random tokens of one kind in Redis by their SHA-256
+// hash, together with the user and a value they were issued for. A user has
+// at most one valid token of a kind: issuing a new one drops the previous one.
+type singleUseTokens struct {
+	redis *redis.Client
+	kind  string
+	ttl   time.Duration
+}
+
+func (t singleUseTokens) tokenKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return t.kind + "-" + hex.EncodeToString(sum[:])
+}
+
+func (t singleUseTokens) userKey(userID int32) string {
+	return fmt.Sprintf("%s-user-%d", t.kind, userID)
+}
+
+func (t singleUseTokens) issue(userID int32, value string) (string, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	previous, err := t.redis.Get(t.userKey(userID)).Result()
+	if err != nil && err != redis.Nil {
+		return "", err
+	}
+
+	key := t.tokenKey(token)
+	pipe := t.redis.TxPipeline()
+	if previous != "" {
+		pipe.Del(previous)
+	}
+	pipe.Set(key, strconv.Itoa(int(userID))+"|"+value, t.ttl)
+	pipe.Set(t.userKey(userID), key, t.ttl)
+	if _, err := pipe.Exec(); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// consume returns the user and value of the token and invalidates it. It
+// returns errUnknownToken for unknown, used and expired tokens.
+func (t singleUseTokens) consume(token string) (int32, string, error) {
+	key := t.tokenKey(token)
+
+	pipe := t.redis.TxPipeline()
+	get := pipe.Get(key)
+	pipe.Del(key)
+	if _, err := pipe.Exec(); err != nil && err != redis.Nil {
+		return 0, "", err
+	}
+
+	stored, err := get.Result()
+	if err == redis.Nil {
+		return 0, "", errUnknownToken
+	}
+
+	if err != nil {
+		return 0, "", err
+	}
+
+	parts := strings.SplitN(stored, "|", 2)
+	userID, err := strconv.ParseInt(parts[0], 10, 32)
+	if err != nil || len(parts) != 2 {
+		return 0, "", errUnknownToken
+	}
+
+	if err := t.redis.Del(t.userKey(int32(userID))).Err(); err != nil {
+		return 0, "", err
+	}
+
+	return int32(userID), parts[1], nil
+}
+
+// revoke drops the valid token of the user, if there is one.
+func (t singleUseTokens) revoke(userID int32) error {
+	key, err := t.redis.Get(t.userKey(userID)).Result()
+	if err == redis.Nil {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return t.redis.Del(key, t.userKey(userID)).Err()
+}
+
+// tokenLink adds the token to the page URL as the token query parameter.
+func tokenLink(page, token string) (string, error) {
+	link, err := url.Parse(page)
+	if err != nil {
+		return "", err
+	}
+
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	return link.String(), nil
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type SMSSender interface {
+	Send(to, message string) error
+}
+
+// ConsoleSMSSender writes messages to the application log and, when Path is
+// set, appends them to that file, so codes can be read on a development
+// machine or by end to end tests.
+type ConsoleSMSSender struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+func (s *ConsoleSMSSender) Send(to, message string) error {
+	log.Printf("sms: to: %s, message: %s", to, message)
+	if s.Path == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("console_sms: open failed, error: %w", err)
+	}
+	defer file.Close()
+
+	line := fmt.Sprintf("%s\t%s\t%s\n", time.Now().Format(time.RFC3339), to, strings.ReplaceAll(message, "\n", " "))
+	if _, err := file.WriteString(line); err != nil {
+		return fmt.Errorf("console_sms: write failed, error: %w", err)
+	}
+
+	return nil
+}
+
+// KavenegarSMSSender sends messages through the Kavenegar REST API.
+type KavenegarSMSSender struct {
+	Client  *http.Client
+	BaseURL string
+	APIKey  string
+	// Sender is the line number messages are sent from; the account default is
+	// used when it is empty.
+	Sender string
+}
+
+type kavenegarResponse struct {
+	Return struct {
+		Status  int    `json:"status"`
+		Message string `json:"message"`
+	} `json:"return"`
+}
+
+func (s *KavenegarSMSSender) Send(to, message string) error {
+	baseURL := s.BaseURL
+	if baseURL == "" {
+		baseURL = "https://api.kavenegar.com"
+	}
+
+	form := url.Values{"receptor": {to}, "message": {message}}
+	if s.Sender != "" {
+		form.Set("sender", s.Sender)
+	}
+
+	endpoint := fmt.Sprintf("%s/v1/%s/sms/send.json", strings.TrimSuffix(baseURL, "/"), url.PathEscape(s.APIKey))
+	response, err := s.Client.PostForm(endpoint, form)
+	if err != nil {
+		return fmt.Errorf("kavenegar_sms: send failed, error: %w", err)
+	}
+	defer response.Body.Close()
+
+	var body kavenegarResponse
+	if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+		return fmt.Errorf("kavenegar_sms: unhandeled response, status: %d", response.StatusCode)
+	}
+
+	if response.StatusCode != http.StatusOK || body.Return.Status != http.StatusOK {
+		return fmt.Errorf("kavenegar_sms: send failed, status: %d, message: %s", body.Return.Status, body.Return.Message)
+	}
+
+	return nil
+}
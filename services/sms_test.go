@@ -0,0 +1,49 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKavenegarSMSSender_Send(t *testing.T) {
+	var path, receptor, sender, message string
+	status, body := http.StatusOK, `{"return":{"status":200,"message":"تایید شد"},"entries":[]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		receptor, sender, message = r.FormValue("receptor"), r.FormValue("sender"), r.FormValue("message")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	s := &KavenegarSMSSender{Client: server.Client(), BaseURL: server.URL, APIKey: "key", Sender: "10004346"}
+	require.NoError(t, s.Send("09121234567", "hello"))
+	require.Equal(t, "/v1/key/sms/send.json", path)
+	require.Equal(t, "09121234567", receptor)
+	require.Equal(t, "10004346", sender)
+	require.Equal(t, "hello", message)
+
+	status, body = http.StatusBadRequest, `{"return":{"status":411,"message":"receptor is invalid"},"entries":null}`
+	require.ErrorContains(t, s.Send("09121234567", "hello"), "status: 411")
+
+	status, body = http.StatusBadGateway, `<html></html>`
+	require.ErrorContains(t, s.Send("09121234567", "hello"), "status: 502")
+}
+
+func TestConsoleSMSSender_Send(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sms.log")
+	s := &ConsoleSMSSender{Path: path}
+
+	require.NoError(t, s.Send("09121234567", "code: 123456"))
+	require.NoError(t, s.Send("09127654321", "two\nlines"))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(content), "\t09121234567\tcode: 123456\n")
+	require.Contains(t, string(content), "\t09127654321\ttwo lines\n")
+}
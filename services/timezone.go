@@ -0,0 +1,97 @@
+package services
+
+import (
+	"aliagha/models"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	// Embed the zone database so city zones resolve on hosts without tzdata.
+	_ "time/tzdata"
+
+	"gorm.io/gorm"
+)
+
+// CityTimezones resolves the time zone of a city by name. Zones are read from
+// the cities table and reloaded every TTL; unknown cities use Default.
+type CityTimezones struct {
+	DB      *gorm.DB
+	Default *time.Location
+	TTL     time.Duration
+
+	mu       sync.Mutex
+	zones    map[string]*time.Location
+	loadedAt time.Time
+}
+
+func NewCityTimezones(db *gorm.DB, defaultZone string, ttl time.Duration) (*CityTimezones, error) {
+	loc := time.UTC
+	if defaultZone != "" {
+		var err error
+		if loc, err = time.LoadLocation(defaultZone); err != nil {
+			return nil, err
+		}
+	}
+
+	return &CityTimezones{DB: db, Default: loc, TTL: ttl}, nil
+}
+
+// Location returns the zone of the named city. A nil resolver resolves every
+// city to UTC.
+func (t *CityTimezones) Location(city string) *time.Location {
+	if t == nil {
+		return time.UTC
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if time.Since(t.loadedAt) > t.TTL {
+		// A failed load keeps the previous zones until the next attempt.
+		if err := t.load(); err != nil {
+			log.Printf("timezones: failed to load city zones, error: %v", err)
+		}
+		t.loadedAt = time.Now()
+	}
+
+	if loc, ok := t.zones[strings.ToLower(city)]; ok {
+		return loc
+	}
+
+	return t.defaultLocation()
+}
+
+func (t *CityTimezones) load() error {
+	var cities []models.City
+	if err := t.DB.Model(&models.City{}).Select("name", "timezone").Find(&cities).Error; err != nil {
+		return err
+	}
+
+	zones := make(map[string]*time.Location, len(cities))
+	for _, city := range cities {
+		if city.Timezone == "" {
+			continue
+		}
+
+		loc, err := time.LoadLocation(city.Timezone)
+		if err != nil {
+			log.Printf("timezones: city %s has invalid zone %q", city.Name, city.Timezone)
+			continue
+		}
+
+		zones[strings.ToLower(city.Name)] = loc
+	}
+
+	t.zones = zones
+
+	return nil
+}
+
+func (t *CityTimezones) defaultLocation() *time.Location {
+	if t.Default == nil {
+		return time.UTC
+	}
+
+	return t.Default
+}
@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NormalizeCellphone returns an Iranian mobile number in its local form,
+// 09XXXXXXXXX. It accepts Persian and Arabic digits, separators and the +98,
+// 0098 and 98 country prefixes.
+func NormalizeCellphone(cellphone string) (string, error) {
+	digits := strings.ReplaceAll(NormalizePersian(cellphone), " ", "")
+	digits = strings.NewReplacer("(", "", ")", "", ".", "").Replace(digits)
+
+	switch {
+	case strings.HasPrefix(digits, "+98"):
+		digits = "0" + digits[3:]
+	case strings.HasPrefix(digits, "0098"):
+		digits = "0" + digits[4:]
+	case strings.HasPrefix(digits, "98") && len(digits) == 12:
+		digits = "0" + digits[2:]
+	case strings.HasPrefix(digits, "9") && len(digits) == 10:
+		digits = "0" + digits
+	}
+
+	if len(digits) != 11 || !strings.HasPrefix(digits, "09") || strings.Trim(digits, "0123456789") != "" {
+		return "", fmt.Errorf("invalid cellphone number %q", cellphone)
+	}
+
+	return digits, nil
+}
@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeCellphone(t *testing.T) {
+	valid := []string{"09121234567", "+989121234567", "00989121234567", "989121234567", "9121234567",
+		"0912 123 4567", "0912-123-4567", "۰۹۱۲۱۲۳۴۵۶۷"}
+	for _, cellphone := range valid {
+		normalized, err := NormalizeCellphone(cellphone)
+		require.NoError(t, err, cellphone)
+		require.Equal(t, "09121234567", normalized, cellphone)
+	}
+
+	invalid := []string{"", "0912123456", "021123456789", "+19121234567", "0912123456a", "091212345678"}
+	for _, cellphone := range invalid {
+		_, err := NormalizeCellphone(cellphone)
+		require.Error(t, err, cellphone)
+	}
+}
@@ -5,35 +5,57 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 )
 
 var parseDateRegex *regexp.Regexp
+var dateDigits = strings.NewReplacer(
+	"۰", "0", "۱", "1", "۲", "2", "۳", "3", "۴", "4", "۵", "5", "۶", "6", "۷", "7", "۸", "8", "۹", "9",
+	"٠", "0", "١", "1", "٢", "2", "٣", "3", "٤", "4", "٥", "5", "٦", "6", "٧", "7", "٨", "8", "٩", "9",
+)
 var parseDateTimeRegex *regexp.Regexp
 
 func init() {
-	parseDateRegex = regexp.MustCompile(`^(\d+)-(\d+)-(\d+)$`)
+	parseDateRegex = regexp.MustCompile(`^(\d+)[-/](\d+)[-/](\d+)$`)
 	parseDateTimeRegex = regexp.MustCompile(`^(\d{4})-(\d{2})-(\d{2})T(\d{2}):(\d{2}):(\d{2})Z$`)
 	parseDateTimeRegex = regexp.MustCompile(`^(\d{2}):(\d{2})$`)
 }
 
-func ParseDate(jalaliDate string) (time.Time, error) {
-	dd := parseDateRegex.FindAllStringSubmatch(jalaliDate, -1)
+// ParseDate parses a YYYY-MM-DD date, telling Jalali from Gregorian dates by
+// their year.
+func ParseDate(date string) (time.Time, error) {
+	return ParseDateIn(date, CalendarAuto)
+}
+
+// ParseDateIn parses a date written in the given calendar and returns it as a
+// Gregorian date at midnight UTC. Persian digits and "/" separators are
+// accepted.
+func ParseDateIn(date string, calendar Calendar) (time.Time, error) {
+	dd := parseDateRegex.FindAllStringSubmatch(dateDigits.Replace(date), -1)
 	if len(dd) != 1 {
 		return time.Time{}, errors.New("parse date failed")
 	}
 
-	jY, _ := strconv.Atoi(dd[0][1])
-	jM, _ := strconv.Atoi(dd[0][2])
-	jD, _ := strconv.Atoi(dd[0][3])
+	y, _ := strconv.Atoi(dd[0][1])
+	m, _ := strconv.Atoi(dd[0][2])
+	d, _ := strconv.Atoi(dd[0][3])
+
+	if calendar == CalendarJalali || (calendar == CalendarAuto && y < jalaliAutoYearLimit) {
+		var err error
+		if y, m, d, err = JalaliToGregorian(y, m, d); err != nil {
+			return time.Time{}, err
+		}
+	}
 
-	date, err := time.Parse("2006-01-02", fmt.Sprintf("%d-%02d-%02d", jY, jM, jD))
+	parsedDate, err := time.Parse("2006-01-02", fmt.Sprintf("%d-%02d-%02d", y, m, d))
 	if err != nil {
 		return time.Time{}, err
 	}
 
-	return date, nil
+	return parsedDate, nil
 }
+
 func ParseDateTime(datetime string) (time.Time, error) {
 	dd := parseDateTimeRegex.FindAllStringSubmatch(datetime, -1)
 	if len(dd) != 1 {
@@ -55,6 +77,12 @@ func ParseDateTime(datetime string) (time.Time, error) {
 	return parsedDatetime, nil
 }
 func ParseTime(FlightDate, timeString string) (time.Time, error) {
+	return ParseTimeIn(FlightDate, timeString, time.UTC)
+}
+
+// ParseTimeIn parses an "HH:MM" time on the given date as a wall clock time
+// in loc.
+func ParseTimeIn(FlightDate, timeString string, loc *time.Location) (time.Time, error) {
 	dd := parseDateTimeRegex.FindAllStringSubmatch(timeString, -1)
 	if len(dd) != 1 {
 		return time.Time{}, errors.New("parse time failed")
@@ -63,7 +91,7 @@ func ParseTime(FlightDate, timeString string) (time.Time, error) {
 	hour, _ := strconv.Atoi(dd[0][1])
 	minute, _ := strconv.Atoi(dd[0][2])
 
-	parsedTime, err := time.Parse("2006-01-02T15:04", fmt.Sprintf("%sT%02d:%02d", FlightDate, hour, minute))
+	parsedTime, err := time.ParseInLocation("2006-01-02T15:04", fmt.Sprintf("%sT%02d:%02d", FlightDate, hour, minute), loc)
 	if err != nil {
 		return time.Time{}, err
 	}
@@ -0,0 +1,195 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Calendar names the calendar a date string is written in.
+type Calendar string
+
+const (
+	CalendarAuto      Calendar = ""
+	CalendarGregorian Calendar = "gregorian"
+	CalendarJalali    Calendar = "jalali"
+)
+
+// jalaliAutoYearLimit separates the two calendars when the calendar is not
+// given: Solar Hijri years are far below it and Gregorian ones above it.
+const jalaliAutoYearLimit = 1700
+
+// jalaliBreaks are the years in which the 33-year leap cycle of the Solar
+// Hijri calendar shifts; conversions are valid between the first and the last.
+var jalaliBreaks = []int{-61, 9, 38, 199, 426, 686, 756, 818, 1111, 1181, 1210,
+	1635, 2060, 2097, 2192, 2262, 2324, 2394, 2456, 3178}
+
+// JalaliToGregorian converts a Solar Hijri date to the Gregorian calendar.
+func JalaliToGregorian(jy, jm, jd int) (int, int, int, error) {
+	if !IsValidJalali(jy, jm, jd) {
+		return 0, 0, 0, fmt.Errorf("invalid jalali date %d-%02d-%02d", jy, jm, jd)
+	}
+
+	gy, gm, gd := dayToGregorian(jalaliToDay(jy, jm, jd))
+	return gy, gm, gd, nil
+}
+
+// GregorianToJalali converts a Gregorian date to the Solar Hijri calendar.
+func GregorianToJalali(gy, gm, gd int) (int, int, int) {
+	return dayToJalali(gregorianToDay(gy, gm, gd))
+}
+
+func IsValidJalali(jy, jm, jd int) bool {
+	if jy < jalaliBreaks[0]+1 || jy >= jalaliBreaks[len(jalaliBreaks)-1] {
+		return false
+	}
+
+	return jm >= 1 && jm <= 12 && jd >= 1 && jd <= JalaliMonthLength(jy, jm)
+}
+
+func IsJalaliLeap(jy int) bool {
+	leap, _, _ := jalaliCal(jy)
+	return leap == 0
+}
+
+func JalaliMonthLength(jy, jm int) int {
+	switch {
+	case jm <= 6:
+		return 31
+	case jm <= 11:
+		return 30
+	case IsJalaliLeap(jy):
+		return 30
+	default:
+		return 29
+	}
+}
+
+// FormatJalali formats the date of t in the Solar Hijri calendar as YYYY-MM-DD.
+func FormatJalali(t time.Time) string {
+	jy, jm, jd := GregorianToJalali(t.Year(), int(t.Month()), t.Day())
+	return fmt.Sprintf("%04d-%02d-%02d", jy, jm, jd)
+}
+
+// FormatJalaliDateTime formats t as a Solar Hijri date followed by its wall
+// clock time, such as "1402-05-10 13:30".
+func FormatJalaliDateTime(t time.Time) string {
+	return FormatJalali(t) + t.Format(" 15:04")
+}
+
+// PrefersJalali reports whether the most preferred language of an
+// Accept-Language header is Persian, whose readers expect Solar Hijri dates.
+func PrefersJalali(acceptLanguage string) bool {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+
+		if q > bestQ {
+			best, bestQ = tag, q
+		}
+	}
+
+	return best == "fa" || strings.HasPrefix(best, "fa-")
+}
+
+// jalaliCal returns the leap position of jy in its 4-year cycle (0 for a leap
+// year), the Gregorian year in which jy starts and the day of March on which
+// it starts.
+func jalaliCal(jy int) (int, int, int) {
+	gy := jy + 621
+	leapJ := -14
+	jp := jalaliBreaks[0]
+
+	jump := 0
+	for i := 1; i < len(jalaliBreaks); i++ {
+		jm := jalaliBreaks[i]
+		jump = jm - jp
+		if jy < jm {
+			break
+		}
+
+		leapJ += jump/33*8 + jump%33/4
+		jp = jm
+	}
+
+	n := jy - jp
+	leapJ += n/33*8 + (n%33+3)/4
+	if jump%33 == 4 && jump-n == 4 {
+		leapJ++
+	}
+
+	leapG := gy/4 - (gy/100+1)*3/4 - 150
+	march := 20 + leapJ - leapG
+
+	if jump-n < 6 {
+		n = n - jump + (jump+4)/33*33
+	}
+
+	leap := ((n+1)%33 - 1) % 4
+	if leap == -1 {
+		leap = 4
+	}
+
+	return leap, gy, march
+}
+
+// jalaliToDay returns the Julian day number of a Solar Hijri date.
+func jalaliToDay(jy, jm, jd int) int {
+	_, gy, march := jalaliCal(jy)
+	return gregorianToDay(gy, 3, march) + (jm-1)*31 - jm/7*(jm-7) + jd - 1
+}
+
+func dayToJalali(jdn int) (int, int, int) {
+	gy, _, _ := dayToGregorian(jdn)
+	jy := gy - 621
+	leap, _, march := jalaliCal(jy)
+
+	k := jdn - gregorianToDay(gy, 3, march)
+	if k >= 0 {
+		if k <= 185 {
+			return jy, 1 + k/31, k%31 + 1
+		}
+		k -= 186
+	} else {
+		jy--
+		k += 179
+		if leap == 1 {
+			k++
+		}
+	}
+
+	return jy, 7 + k/30, k%30 + 1
+}
+
+// gregorianToDay returns the Julian day number of a Gregorian date.
+func gregorianToDay(gy, gm, gd int) int {
+	d := (gy+(gm-8)/6+100100)*1461/4 + (153*((gm+9)%12)+2)/5 + gd - 34840408
+	return d - (gy+100100+(gm-8)/6)/100*3/4 + 752
+}
+
+func dayToGregorian(jdn int) (int, int, int) {
+	j := 4*jdn + 139361631
+	j += (4*jdn+183187720)/146097*3/4*4 - 3908
+	i := j%1461/4*5 + 308
+
+	gd := i%153/5 + 1
+	gm := i/153%12 + 1
+	gy := j/1461 - 100100 + (8-gm)/6
+
+	return gy, gm, gd
+}
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJalaliToGregorian(t *testing.T) {
+	tests := []struct {
+		jalali    [3]int
+		gregorian [3]int
+	}{
+		{[3]int{1402, 5, 10}, [3]int{2023, 8, 1}},
+		{[3]int{1403, 1, 1}, [3]int{2024, 3, 20}},
+		{[3]int{1403, 12, 30}, [3]int{2025, 3, 20}},
+		{[3]int{1399, 12, 30}, [3]int{2021, 3, 20}},
+		{[3]int{1367, 6, 31}, [3]int{1988, 9, 22}},
+	}
+
+	for _, tt := range tests {
+		gy, gm, gd, err := JalaliToGregorian(tt.jalali[0], tt.jalali[1], tt.jalali[2])
+		require.NoError(t, err)
+		require.Equal(t, tt.gregorian, [3]int{gy, gm, gd})
+
+		jy, jm, jd := GregorianToJalali(gy, gm, gd)
+		require.Equal(t, tt.jalali, [3]int{jy, jm, jd})
+	}
+
+	_, _, _, err := JalaliToGregorian(1402, 12, 30)
+	require.Error(t, err)
+}
+
+func TestParseDateIn(t *testing.T) {
+	tests := []struct {
+		date     string
+		calendar Calendar
+		expected string
+		fails    bool
+	}{
+		{"2023-08-01", CalendarAuto, "2023-08-01", false},
+		{"1402-05-10", CalendarAuto, "2023-08-01", false},
+		{"۱۴۰۲/۰۵/۱۰", CalendarAuto, "2023-08-01", false},
+		{"1402-05-10", CalendarJalali, "2023-08-01", false},
+		{"1402-05-10", CalendarGregorian, "1402-05-10", false},
+		{"1402-13-01", CalendarAuto, "", true},
+		{"2023-02-30", CalendarAuto, "", true},
+		{"str", CalendarAuto, "", true},
+	}
+
+	for _, tt := range tests {
+		date, err := ParseDateIn(tt.date, tt.calendar)
+		if tt.fails {
+			require.Error(t, err, tt.date)
+			continue
+		}
+
+		require.NoError(t, err, tt.date)
+		require.Equal(t, tt.expected, date.Format("2006-01-02"))
+	}
+}
+
+func TestPrefersJalali(t *testing.T) {
+	require.True(t, PrefersJalali("fa"))
+	require.True(t, PrefersJalali("fa-IR,en;q=0.8"))
+	require.True(t, PrefersJalali("en;q=0.5, fa;q=0.9"))
+	require.False(t, PrefersJalali("en-US,fa;q=0.5"))
+	require.False(t, PrefersJalali(""))
+
+	require.Equal(t, "1402-05-10 13:30", FormatJalaliDateTime(time.Date(2023, 8, 1, 13, 30, 0, 0, time.UTC)))
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// NationalCodeCheckDigit returns the check digit of the first nine digits of
+// an Iranian national code.
+func NationalCodeCheckDigit(digits string) (byte, error) {
+	if len(digits) != 9 || strings.Trim(digits, "0123456789") != "" {
+		return 0, fmt.Errorf("national code body must be 9 digits, got %q", digits)
+	}
+
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += int(digits[i]-'0') * (10 - i)
+	}
+
+	r := sum % 11
+	if r < 2 {
+		return byte('0' + r), nil
+	}
+
+	return byte('0' + 11 - r), nil
+}
+
+// IsValidNationalCode reports whether code is a well formed Iranian national
+// code: ten digits, not all the same, ending with the right check digit.
+func IsValidNationalCode(code string) bool {
+	if len(code) != 10 || strings.Count(code, code[:1]) == 10 {
+		return false
+	}
+
+	check, err := NationalCodeCheckDigit(code[:9])
+	return err == nil && code[9] == check
+}
+
+// GenerateNationalCode returns a random valid national code starting with
+// prefix, which may hold up to nine digits.
+func GenerateNationalCode(r *rand.Rand, prefix string) string {
+	for {
+		var b strings.Builder
+		b.WriteString(prefix)
+		for b.Len() < 9 {
+			b.WriteByte(byte('0' + r.Intn(10)))
+		}
+
+		body := b.String()
+		check, err := NationalCodeCheckDigit(body)
+		if err != nil {
+			panic(err)
+		}
+
+		if code := body + string(check); IsValidNationalCode(code) {
+			return code
+		}
+	}
+}
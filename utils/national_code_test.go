@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsValidNationalCode(t *testing.T) {
+	tests := []struct {
+		code  string
+		valid bool
+	}{
+		{"0013542419", true},
+		{"1234567891", true},
+		{"4993708998", true},
+		{"1234567890", false},
+		{"1111111111", false},
+		{"0000000000", false},
+		{"123456789", false},
+		{"12345678a1", false},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.valid, IsValidNationalCode(tt.code), tt.code)
+	}
+}
+
+func TestGenerateNationalCode(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		code := GenerateNationalCode(r, "1")
+		require.True(t, IsValidNationalCode(code), code)
+		require.True(t, strings.HasPrefix(code, "1"), code)
+	}
+
+	first := GenerateNationalCode(rand.New(rand.NewSource(7)), "")
+	require.Equal(t, first, GenerateNationalCode(rand.New(rand.NewSource(7)), ""))
+}
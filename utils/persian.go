@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+var persianReplacer = strings.NewReplacer(
+	"ي", "ی",
+	"ى", "ی",
+	"ئ", "ی",
+	"ك", "ک",
+	"ة", "ه",
+	"ۀ", "ه",
+	"أ", "ا",
+	"إ", "ا",
+	"آ", "ا",
+	"ٱ", "ا",
+	"ؤ", "و",
+	"ـ", "",
+	"\u200c", " ",
+	"\u200f", "",
+	"\u200e", "",
+)
+
+// NormalizePersian folds the Arabic and Persian variants of the same letter,
+// strips diacritics and tatweel, converts Persian and Arabic digits to ASCII and
+// lowercases Latin letters, so that user input can be compared with stored names.
+func NormalizePersian(s string) string {
+	s = persianReplacer.Replace(s)
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		switch {
+		case r >= '۰' && r <= '۹':
+			b.WriteRune('0' + r - '۰')
+		case r >= '٠' && r <= '٩':
+			b.WriteRune('0' + r - '٠')
+		case unicode.Is(unicode.Mn, r):
+		case unicode.IsSpace(r) || r == '-' || r == '_':
+			b.WriteRune(' ')
+		default:
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// Levenshtein returns the edit distance between a and b, counted in runes.
+func Levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 {
+		return len(rb)
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			curr[j] = minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
+
+func minInt(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+
+	return m
+}